@@ -0,0 +1,43 @@
+// api/anomalies.go
+package api
+
+import (
+	"fitness/models"
+	"net/http"
+	"strings"
+)
+
+func GetAnomalies(w http.ResponseWriter, r *http.Request) {
+	var names []string
+	if metric := r.URL.Query().Get("metric"); metric != "" {
+		for _, name := range strings.Split(metric, ",") {
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+	start := r.URL.Query().Get("start")
+	end := r.URL.Query().Get("end")
+
+	anomalies := []models.Anomaly{}
+	for _, anomaly := range userStore(r).Anomalies() {
+		if (start != "" && anomaly.Date < start) || (end != "" && anomaly.Date > end) {
+			continue
+		}
+		if len(names) > 0 && !containsFold(names, anomaly.Metric) {
+			continue
+		}
+		anomalies = append(anomalies, anomaly)
+	}
+	respond(w, r, anomalies, func() []table {
+		return []table{recordTable("anomalies", anomalies)}
+	})
+}
+
+// containsFold reports whether the names include the name, ignoring case
+func containsFold(names []string, name string) bool {
+	for _, candidate := range names {
+		if strings.EqualFold(candidate, name) {
+			return true
+		}
+	}
+	return false
+}
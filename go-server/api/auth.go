@@ -0,0 +1,316 @@
+// api/auth.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fitness/auth"
+	"fitness/config"
+	"fitness/data"
+	"fitness/models"
+	"fitness/utils"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Cookies used by browsers, which cannot set headers on EventSource requests
+const (
+	accessCookie  = "access_token"
+	refreshCookie = "refresh_token"
+)
+
+// requireAuth rejects requests without a valid access token or personal API
+// token and stores the signed in user in the request context
+func requireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if token == "" {
+			unauthorized(w, "Authentication required")
+			return
+		}
+		user, err := auth.Users.Verify(token)
+		if err != nil {
+			unauthorized(w, "Invalid or expired token")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+	})
+}
+
+// bearerToken reads the token from the Authorization header, falling back to the access cookie
+func bearerToken(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+	if cookie, err := r.Cookie(accessCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// unauthorized writes a 401 response asking for a bearer token
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="stride"`)
+	http.Error(w, message, http.StatusUnauthorized)
+}
+
+// currentUser returns the signed in user of a request passed through requireAuth
+func currentUser(r *http.Request) models.User {
+	user, _ := auth.UserFrom(r.Context())
+	return user
+}
+
+// userStore returns the data store of the signed in user
+func userStore(r *http.Request) *data.Store {
+	return data.ForUser(currentUser(r))
+}
+
+// requestCalendar returns the calendar of the signed in user's profile,
+// falling back to the configured calendar for unset or invalid settings
+func requestCalendar(r *http.Request) utils.Calendar {
+	return userCalendar(currentUser(r).Profile)
+}
+
+// userCalendar returns the calendar of a profile, falling back to the
+// configured calendar for unset or invalid settings
+func userCalendar(profile models.Profile) utils.Calendar {
+	calendar := utils.DefaultCalendar()
+	if weekStart, err := utils.ParseWeekday(profile.WeekStart); err == nil {
+		calendar.WeekStart = weekStart
+	}
+	if profile.Timezone != "" {
+		if location, err := time.LoadLocation(profile.Timezone); err == nil {
+			calendar.Location = location
+		}
+	}
+	return calendar
+}
+
+func Signup(w http.ResponseWriter, r *http.Request) {
+	if !config.AllowSignup {
+		http.Error(w, "Signup is disabled", http.StatusForbidden)
+		return
+	}
+	// The owner inherits the single-user data, so nobody else may claim the address first
+	if config.OwnerEmail != "" {
+		if _, ok := auth.Users.UserByEmail(config.OwnerEmail); !ok {
+			http.Error(w, "Signup opens once the owner account exists", http.StatusForbidden)
+			return
+		}
+	}
+	var signup models.Signup
+	if !decodeBody(w, r, &signup) {
+		return
+	}
+
+	user, err := auth.Users.Register(signup)
+	switch {
+	case errors.Is(err, auth.ErrEmailTaken):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, auth.ErrInvalidEmail), errors.Is(err, auth.ErrWeakPassword), errors.Is(err, auth.ErrLongPassword):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, "Error creating account", http.StatusInternalServerError)
+		return
+	}
+
+	startSession(w, r, user, http.StatusCreated)
+}
+
+func Login(w http.ResponseWriter, r *http.Request) {
+	var credentials models.Credentials
+	if !decodeBody(w, r, &credentials) {
+		return
+	}
+
+	user, err := auth.Users.Authenticate(credentials)
+	if err != nil {
+		unauthorized(w, "Invalid email or password")
+		return
+	}
+	startSession(w, r, user, http.StatusOK)
+}
+
+func Refresh(w http.ResponseWriter, r *http.Request) {
+	session, err := auth.Users.Refresh(refreshToken(r))
+	if err != nil {
+		clearSessionCookies(w, r)
+		unauthorized(w, "Session expired, sign in again")
+		return
+	}
+	setSessionCookies(w, r, session)
+	writeJSON(w, http.StatusOK, session)
+}
+
+func Logout(w http.ResponseWriter, r *http.Request) {
+	if err := auth.Users.EndSession(refreshToken(r)); err != nil {
+		http.Error(w, "Error ending session", http.StatusInternalServerError)
+		return
+	}
+	clearSessionCookies(w, r)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func GetCurrentUser(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, currentUser(r))
+}
+
+func UpdateCurrentUser(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		return
+	}
+
+	// Fields present in the body replace the stored values, the others are kept
+	user, err := auth.Users.UpdateProfile(currentUser(r).ID, func(profile *models.Profile) error {
+		if err := json.Unmarshal(body, profile); err != nil {
+			return profileError("Error parsing request body")
+		}
+		return validateProfile(*profile)
+	})
+	var invalid profileError
+	if errors.As(err, &invalid) {
+		http.Error(w, invalid.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error updating profile", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, user)
+}
+
+// profileError is a profile update rejected because of the request, its
+// message is returned to the client
+type profileError string
+
+func (e profileError) Error() string {
+	return string(e)
+}
+
+// validateProfile checks the settings of a profile once the update is applied
+func validateProfile(profile models.Profile) error {
+	if profile.Timezone != "" {
+		if _, err := time.LoadLocation(profile.Timezone); err != nil {
+			return profileError("Invalid timezone")
+		}
+	}
+	if profile.WeekStart != "" {
+		if _, err := utils.ParseWeekday(profile.WeekStart); err != nil {
+			return profileError("Invalid week start")
+		}
+	}
+
+	for _, heartRate := range []float64{profile.RestingHeartRate, profile.MaxHeartRate, profile.LactateThresholdHeartRate} {
+		if heartRate != 0 && (heartRate < 30 || heartRate > 250) {
+			return profileError("Heart rates must be between 30 and 250 bpm")
+		}
+	}
+	if profile.RestingHeartRate != 0 && profile.MaxHeartRate != 0 && profile.RestingHeartRate >= profile.MaxHeartRate {
+		return profileError("Resting heart rate must be below the maximum heart rate")
+	}
+	if profile.LactateThresholdHeartRate != 0 && profile.MaxHeartRate != 0 && profile.LactateThresholdHeartRate >= profile.MaxHeartRate {
+		return profileError("Lactate threshold heart rate must be below the maximum heart rate")
+	}
+	if profile.Sex != "" && profile.Sex != "male" && profile.Sex != "female" {
+		return profileError("Sex must be male or female")
+	}
+	if _, err := utils.ParseUnitSystem(profile.Units); err != nil {
+		return profileError("Invalid unit system")
+	}
+	if _, err := utils.ParseZoneModel(profile.ZoneModel); err != nil {
+		return profileError("Invalid zone model")
+	}
+	return nil
+}
+
+func GetAPITokens(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, auth.Users.ListAPITokens(currentUser(r).ID))
+}
+
+func CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	var request models.NewAPIToken
+	if !decodeBody(w, r, &request) {
+		return
+	}
+	token, err := auth.Users.CreateAPIToken(currentUser(r).ID, request.Name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusCreated, token)
+}
+
+func DeleteAPIToken(w http.ResponseWriter, r *http.Request) {
+	err := auth.Users.DeleteAPIToken(currentUser(r).ID, r.PathValue("id"))
+	if errors.Is(err, auth.ErrNotFound) {
+		http.Error(w, "Token not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error deleting token", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// startSession signs the user in and writes the session tokens
+func startSession(w http.ResponseWriter, r *http.Request, user models.User, status int) {
+	session, err := auth.Users.StartSession(user)
+	if err != nil {
+		http.Error(w, "Error starting session", http.StatusInternalServerError)
+		return
+	}
+	setSessionCookies(w, r, session)
+	writeJSON(w, status, session)
+}
+
+// refreshToken reads the refresh token from the request body, falling back to the refresh cookie
+func refreshToken(r *http.Request) string {
+	var request models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err == nil && request.RefreshToken != "" {
+		return request.RefreshToken
+	}
+	if cookie, err := r.Cookie(refreshCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// setSessionCookies stores the session tokens in HTTP-only cookies for browser clients
+func setSessionCookies(w http.ResponseWriter, r *http.Request, session models.Session) {
+	http.SetCookie(w, sessionCookie(r, accessCookie, session.AccessToken, "/", config.AccessTokenTTL))
+	http.SetCookie(w, sessionCookie(r, refreshCookie, session.RefreshToken, "/auth", config.RefreshTokenTTL))
+}
+
+// clearSessionCookies removes the session cookies
+func clearSessionCookies(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, sessionCookie(r, accessCookie, "", "/", -1))
+	http.SetCookie(w, sessionCookie(r, refreshCookie, "", "/auth", -1))
+}
+
+// sessionCookie builds a session cookie, a negative lifetime deletes it
+func sessionCookie(r *http.Request, name, value, path string, lifetime time.Duration) *http.Cookie {
+	maxAge := int(lifetime.Seconds())
+	if lifetime < 0 {
+		maxAge = -1
+	}
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	}
+}
@@ -0,0 +1,175 @@
+// api/cache.go
+package api
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+// conditional adds an ETag and Last-Modified header derived from the data version
+// and answers matching If-None-Match and If-Modified-Since requests with 304.
+// Responses belong to the signed in user, so only private caches may keep them.
+func conditional(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version, modified := userStore(r).Version()
+		etag := versionETag(r, version, modified)
+
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "private, no-cache")
+		if !modified.IsZero() {
+			w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		}
+
+		if notModified(r, etag, modified) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// versionETag identifies a response by data version, request, negotiated format
+// and user, whose profile settings shape the response. The tag is weak so it
+// stays valid for compressed responses.
+func versionETag(r *http.Request, version uint64, modified time.Time) string {
+	format, _ := negotiateFormat(r)
+	user := currentUser(r)
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d\n%d\n%s\n%s\n%s\n%s\n%+v", version, modified.Unix(), r.URL.Path, r.URL.RawQuery, format, user.ID, user.Profile)
+	return `W/"` + hex.EncodeToString(hash.Sum(nil))[:32] + `"`
+}
+
+// notModified evaluates the conditional request headers. If-None-Match takes
+// precedence over If-Modified-Since as required by RFC 9110.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !modified.IsZero() {
+		return !modified.After(since)
+	}
+	return false
+}
+
+// compressor is the gzip or brotli writer compressing a response
+type compressor interface {
+	io.Writer
+	Flush() error
+	Close() error
+}
+
+// compressedResponseWriter compresses everything written to the response
+type compressedResponseWriter struct {
+	http.ResponseWriter
+	encoding    string // Content coding, either gzip or br
+	writer      compressor
+	wroteHeader bool
+}
+
+func (c *compressedResponseWriter) WriteHeader(status int) {
+	if !c.wroteHeader {
+		c.wroteHeader = true
+		// Bodiless responses are sent as is
+		if status != http.StatusNoContent && status != http.StatusNotModified {
+			c.Header().Del("Content-Length")
+			c.Header().Set("Content-Encoding", c.encoding)
+		} else {
+			c.writer = nil
+		}
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *compressedResponseWriter) Write(p []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+	if c.writer == nil {
+		return c.ResponseWriter.Write(p)
+	}
+	return c.writer.Write(p)
+}
+
+// Flush sends the compressed data written so far, keeping streamed responses moving
+func (c *compressedResponseWriter) Flush() {
+	if c.writer != nil {
+		c.writer.Flush()
+	}
+	if flusher, ok := c.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (c *compressedResponseWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// compress compresses responses with brotli or gzip, whichever the client
+// prefers, brotli winning ties as it compresses JSON better
+func compress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		br, gz := encodingQuality(r, "br"), encodingQuality(r, "gzip")
+		if (br <= 0 && gz <= 0) || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		compressed := &compressedResponseWriter{ResponseWriter: w}
+		if br >= gz {
+			compressed.encoding, compressed.writer = "br", brotli.NewWriterLevel(w, brotli.DefaultCompression)
+		} else {
+			compressed.encoding, compressed.writer = "gzip", gzip.NewWriter(w)
+		}
+		defer func() {
+			if compressed.writer != nil && compressed.wroteHeader {
+				compressed.writer.Close()
+			}
+		}()
+		next.ServeHTTP(compressed, r)
+	})
+}
+
+// encodingQuality returns the quality the Accept-Encoding header gives the
+// encoding, 0 when it is not accepted. An entry naming the encoding takes
+// precedence over the * wildcard.
+func encodingQuality(r *http.Request, encoding string) float64 {
+	quality := 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.TrimSpace(name)
+		if !strings.EqualFold(name, encoding) && name != "*" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if name != "*" {
+			return q
+		}
+		quality = q
+	}
+	return quality
+}
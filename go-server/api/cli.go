@@ -0,0 +1,152 @@
+// api/cli.go
+package api
+
+import (
+	"bufio"
+	"fitness/auth"
+	"fitness/config"
+	"fitness/data"
+	"fitness/models"
+	"fitness/utils"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RunReport writes a report from the cached data of a user, without starting
+// the server. Usage: report [-user email] [-format markdown|html|json] week|month|year [date]
+func RunReport(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("report", flag.ContinueOnError)
+	email := flags.String("user", config.OwnerEmail, "Email of the user to report on")
+	format := flags.String("format", reportMarkdown, "Report format: markdown, html or json")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: fitness report [flags] week|month|year [date]")
+		fmt.Fprintln(flags.Output(), "Reports on the period containing the date, the previous period by default")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		flags.Usage()
+		return fmt.Errorf("expected a period and an optional date")
+	}
+	period, err := utils.ParseReportPeriod(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if _, ok := reportContentTypes[*format]; !ok {
+		return fmt.Errorf("unknown report format %q", *format)
+	}
+
+	user, err := cliUser(*email)
+	if err != nil {
+		return err
+	}
+
+	calendar := userCalendar(user.Profile)
+	now := time.Now()
+	// Recaps are usually written once a period is over
+	date := calendar.StartOf(now, period).AddDate(0, 0, -1)
+	if flags.NArg() == 2 {
+		if date, err = time.ParseInLocation(config.DateFormat, flags.Arg(1), calendar.Location); err != nil {
+			return fmt.Errorf("invalid date %q: %v", flags.Arg(1), err)
+		}
+	}
+
+	store := data.ForUser(user)
+	report := utils.CalculateReport(store.Workouts(), store.Metrics(), calendar, period, date, now)
+	return writeReport(stdout, report, *format)
+}
+
+// RunReview writes the year in review of a user from the cached data, without
+// starting the server. Usage: review [-user email] [-format html|json] [year]
+func RunReview(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("review", flag.ContinueOnError)
+	email := flags.String("user", config.OwnerEmail, "Email of the user to review")
+	format := flags.String("format", reportHTML, "Review format: html or json")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: fitness review [flags] [year]")
+		fmt.Fprintln(flags.Output(), "Reviews the year, the previous year by default")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return fmt.Errorf("expected an optional year")
+	}
+	if *format != reportHTML && *format != reportJSON {
+		return fmt.Errorf("unknown review format %q", *format)
+	}
+	user, err := cliUser(*email)
+	if err != nil {
+		return err
+	}
+
+	calendar := userCalendar(user.Profile)
+	now := time.Now()
+	year := calendar.In(now).Year() - 1
+	if flags.NArg() == 1 {
+		if year, err = strconv.Atoi(flags.Arg(0)); err != nil {
+			return fmt.Errorf("invalid year %q: %v", flags.Arg(0), err)
+		}
+	}
+
+	review := utils.CalculateYearInReview(data.ForUser(user).Workouts(), calendar, year, now)
+	return writeReview(stdout, review, *format)
+}
+
+// CLIInput is read by commands expecting a password, standard input outside tests
+var CLIInput io.Reader = os.Stdin
+
+// RunAddUser registers an account without going through signup, which is how
+// the owner account is created. The password is read from the first line of
+// standard input. Usage: adduser [-name name] email
+func RunAddUser(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("adduser", flag.ContinueOnError)
+	name := flags.String("name", "", "Display name of the user")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: fitness adduser [flags] email < password")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected an email address")
+	}
+	password, err := bufio.NewReader(CLIInput).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("failed to read password: %v", err)
+	}
+
+	if err := auth.Users.Load(config.UsersFilePath); err != nil {
+		return fmt.Errorf("failed to load users: %v", err)
+	}
+	user, err := auth.Users.Register(models.Signup{
+		Email: flags.Arg(0), Password: strings.TrimRight(password, "\r\n"), Name: *name,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Created user %s with ID %s\n", user.Email, user.ID)
+	return nil
+}
+
+// cliUser loads the user accounts and returns the one with the email
+func cliUser(email string) (models.User, error) {
+	if err := auth.Users.Load(config.UsersFilePath); err != nil {
+		return models.User{}, fmt.Errorf("failed to load users: %v", err)
+	}
+	if user, ok := auth.Users.UserByEmail(email); ok {
+		return user, nil
+	}
+	return models.User{}, fmt.Errorf("no user with email %q, set -user or FITNESS_OWNER_EMAIL", email)
+}
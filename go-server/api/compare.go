@@ -0,0 +1,100 @@
+// api/compare.go
+package api
+
+import (
+	"fitness/config"
+	"fitness/data"
+	"fitness/models"
+	"fitness/utils"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func GetComparison(w http.ResponseWriter, r *http.Request) {
+	period := utils.Month
+	if name := r.URL.Query().Get("period"); name != "" {
+		parsed, err := utils.ParsePeriod(name)
+		if err != nil {
+			http.Error(w, "Invalid comparison period", http.StatusBadRequest)
+			return
+		}
+		period = parsed
+	}
+	offset := 1
+	if value := r.URL.Query().Get("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 120 {
+			http.Error(w, "Offset must be between 1 and 120 periods", http.StatusBadRequest)
+			return
+		}
+		offset = parsed
+	}
+
+	comparison := models.Comparison{Period: string(period), Offset: offset}
+	comparison.Current, comparison.Previous = utils.ComparisonPeriods(requestCalendar(r), period, offset, time.Now())
+	store := userStore(r)
+
+	if name := r.URL.Query().Get("metric"); name != "" {
+		metrics, ok := data.FilterMetricName(store.Metrics(), name)
+		if !ok {
+			http.Error(w, "Metric not found", http.StatusNotFound)
+			return
+		}
+		if len(metrics) > 1 {
+			http.Error(w, "Only one metric can be compared at a time", http.StatusBadRequest)
+			return
+		}
+		comparison.Metric, comparison.Units = metrics[0].Name, metrics[0].Units
+		comparison.Totals, comparison.Averages = utils.CompareMetric(
+			periodMetric(metrics[0], comparison.Current), periodMetric(metrics[0], comparison.Previous))
+	} else {
+		// Filters matching nothing leave both periods empty rather than failing
+		workouts := store.Workouts()
+		if workout := r.URL.Query().Get("workout"); workout != "" {
+			workouts, _ = data.FilterWorkout(workouts, workout)
+		}
+		if calories := r.URL.Query().Get("calories"); calories != "" {
+			threshold, err := strconv.ParseFloat(calories, 64)
+			if err != nil {
+				http.Error(w, "Error parsing calories threshold", http.StatusBadRequest)
+				return
+			}
+			workouts, _ = data.FilterCalories(workouts, threshold)
+		}
+		comparison.Totals, comparison.Averages = utils.CompareWorkouts(
+			periodWorkouts(workouts, comparison.Current), periodWorkouts(workouts, comparison.Previous))
+	}
+
+	respond(w, r, comparison, func() []table {
+		return []table{recordTable("totals", comparison.Totals), recordTable("averages", comparison.Averages)}
+	})
+}
+
+// periodWorkouts keeps the workouts of a comparison period with the same
+// bounds as the start and end filters, the end filter being given the day after
+func periodWorkouts(workouts []models.Workout, period models.ComparisonPeriod) []models.Workout {
+	workouts, _ = data.FilterDate(workouts, period.Start, true)
+	workouts, _ = data.FilterDate(workouts, dayAfter(period.End), false)
+	return workouts
+}
+
+// periodMetric keeps the data points of a metric within a comparison period,
+// bounded like periodWorkouts
+func periodMetric(metric models.Metric, period models.ComparisonPeriod) models.Metric {
+	metrics, _ := data.FilterMetricDate([]models.Metric{metric}, period.Start, true)
+	metrics, _ = data.FilterMetricDate(metrics, dayAfter(period.End), false)
+	if len(metrics) == 0 {
+		return models.Metric{Name: metric.Name, Units: metric.Units}
+	}
+	return metrics[0]
+}
+
+// dayAfter returns the day following a date in the date filter format
+func dayAfter(date string) string {
+	day, err := time.Parse(config.DateFormat, date)
+	if err != nil {
+		return date
+	}
+	return day.AddDate(0, 0, 1).Format(config.DateFormat)
+}
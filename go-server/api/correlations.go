@@ -0,0 +1,108 @@
+// api/correlations.go
+package api
+
+import (
+	"fitness/models"
+	"fitness/utils"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Days a series can be shifted by, either way
+const maxCorrelationLag = 90
+
+// Series in a correlation matrix at most
+const maxMatrixSeries = 12
+
+func GetCorrelations(w http.ResponseWriter, r *http.Request) {
+	x := r.URL.Query().Get("x")
+	y := r.URL.Query().Get("y")
+	if x == "" || y == "" {
+		http.Error(w, "Both series x and y are required", http.StatusBadRequest)
+		return
+	}
+	lags := []int{0}
+	if value := r.URL.Query().Get("lags"); value != "" {
+		lags = nil
+		for _, part := range strings.Split(value, ",") {
+			lag, ok := parseLag(strings.TrimSpace(part))
+			if !ok {
+				http.Error(w, "Lags must be days between -90 and 90", http.StatusBadRequest)
+				return
+			}
+			lags = append(lags, lag)
+		}
+	}
+	workoutData, ok := filterWorkoutData(w, r)
+	if !ok {
+		return
+	}
+
+	metrics := filterMetricData(r)
+	calendar := requestCalendar(r)
+	xSeries := utils.DailySeries(x, workoutData, metrics, calendar)
+	ySeries := utils.DailySeries(y, workoutData, metrics, calendar)
+	correlations := make([]models.Correlation, 0, len(lags))
+	for _, lag := range lags {
+		correlations = append(correlations, utils.Correlate(x, y, xSeries, ySeries, lag))
+	}
+	respond(w, r, correlations, func() []table {
+		return []table{recordTable("correlations", correlations)}
+	})
+}
+
+func GetCorrelationMatrix(w http.ResponseWriter, r *http.Request) {
+	var names []string
+	for _, name := range strings.Split(r.URL.Query().Get("series"), ",") {
+		if name = strings.TrimSpace(name); name != "" && !containsFold(names, name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) < 2 || len(names) > maxMatrixSeries {
+		http.Error(w, "Between 2 and 12 series are required", http.StatusBadRequest)
+		return
+	}
+	lag := 0
+	if value := r.URL.Query().Get("lag"); value != "" {
+		parsed, ok := parseLag(value)
+		if !ok {
+			http.Error(w, "Lag must be days between -90 and 90", http.StatusBadRequest)
+			return
+		}
+		lag = parsed
+	}
+	method := r.URL.Query().Get("method")
+	switch method {
+	case "":
+		method = utils.Pearson
+	case utils.Pearson, utils.Spearman:
+	default:
+		http.Error(w, "Method must be pearson or spearman", http.StatusBadRequest)
+		return
+	}
+	workoutData, ok := filterWorkoutData(w, r)
+	if !ok {
+		return
+	}
+
+	metrics := filterMetricData(r)
+	calendar := requestCalendar(r)
+	series := make(map[string]map[string]float64, len(names))
+	for _, name := range names {
+		series[name] = utils.DailySeries(name, workoutData, metrics, calendar)
+	}
+	matrix := utils.CalculateCorrelationMatrix(names, series, lag, method)
+	respond(w, r, matrix, func() []table {
+		return []table{recordTable("correlations", matrix.Pairs)}
+	})
+}
+
+// parseLag parses a number of days a series is shifted by
+func parseLag(value string) (int, bool) {
+	lag, err := strconv.Atoi(value)
+	if err != nil || lag < -maxCorrelationLag || lag > maxCorrelationLag {
+		return 0, false
+	}
+	return lag, true
+}
@@ -0,0 +1,75 @@
+// api/events.go
+package api
+
+import (
+	"encoding/json"
+	"fitness/config"
+	"fitness/models"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// GetEvents streams the signed in user's data changes as Server-Sent Events.
+// Clients that reconnect with Last-Event-ID receive the events they missed.
+func GetEvents(w http.ResponseWriter, r *http.Request) {
+	controller := http.NewResponseController(w)
+	// The stream outlives the server's write timeout
+	controller.SetWriteDeadline(time.Time{})
+	lastEventID, err := strconv.ParseUint(r.Header.Get("Last-Event-ID"), 10, 64)
+	resume := err == nil
+
+	missed, events, cancel := userStore(r).Subscribe(lastEventID, resume)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Keep reverse proxies from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", (3 * time.Second).Milliseconds())
+	for _, event := range missed {
+		if writeEvent(w, event) != nil {
+			return
+		}
+	}
+	if controller.Flush() != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(config.EventHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-shuttingDown:
+			// Clients reconnect with Last-Event-ID once the server is back
+			return
+		case event, ok := <-events:
+			// A closed channel means the client fell behind, it resumes when reconnecting
+			if !ok || writeEvent(w, event) != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		if controller.Flush() != nil {
+			return
+		}
+	}
+}
+
+// writeEvent writes an event in the Server-Sent Events format
+func writeEvent(w http.ResponseWriter, event models.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, payload)
+	return err
+}
@@ -0,0 +1,357 @@
+// api/export.go
+package api
+
+import (
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"fitness/models"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Response formats supported through content negotiation
+const (
+	formatJSON   = "json"
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+	formatXLSX   = "xlsx"
+)
+
+// formatContentTypes maps each response format to the content type it is served with
+var formatContentTypes = map[string]string{
+	formatJSON:   "application/json",
+	formatCSV:    "text/csv; charset=utf-8",
+	formatNDJSON: "application/x-ndjson",
+	formatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// acceptedMediaTypes maps Accept header media types to response formats
+var acceptedMediaTypes = map[string]string{
+	"application/json":     formatJSON,
+	"application/*":        formatJSON,
+	"*/*":                  formatJSON,
+	"text/csv":             formatCSV,
+	"application/x-ndjson": formatNDJSON,
+	"application/ndjson":   formatNDJSON,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": formatXLSX,
+}
+
+// flushEvery is the number of streamed records written between flushes
+const flushEvery = 500
+
+// table is a flat view of one entity, used for CSV and XLSX exports
+type table struct {
+	name    string     // Name of the entity, used as the XLSX sheet name
+	columns []string   // Column headers
+	rows    [][]string // Cell values, one slice per row
+}
+
+// negotiateFormat picks the response format from the format query parameter,
+// falling back to the Accept header and then JSON
+func negotiateFormat(r *http.Request) (string, bool) {
+	// An explicit format parameter wins over the Accept header
+	if format := strings.ToLower(r.URL.Query().Get("format")); format != "" {
+		_, ok := formatContentTypes[format]
+		return format, ok
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return formatJSON, true
+	}
+
+	// Pick the supported media type with the highest quality value
+	best, bestQuality := "", 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		format, ok := acceptedMediaTypes[mediaType]
+		if !ok {
+			continue
+		}
+		quality := 1.0
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil {
+			quality = q
+		}
+		if quality > bestQuality {
+			best, bestQuality = format, quality
+		}
+	}
+	return best, best != ""
+}
+
+// respond writes value in the negotiated format. JSON encodes value as is, NDJSON
+// streams slices one element per line, and CSV and XLSX use the flattened tables.
+func respond(w http.ResponseWriter, r *http.Request, value any, tables func() []table) {
+	format, ok := negotiateFormat(r)
+	if !ok {
+		http.Error(w, "Unsupported response format", http.StatusNotAcceptable)
+		return
+	}
+	w.Header().Set("Content-Type", formatContentTypes[format])
+	w.Header().Add("Vary", "Accept")
+
+	var err error
+	switch format {
+	case formatCSV:
+		w.Header().Set("Content-Disposition", attachment(r, "csv"))
+		err = writeCSV(w, tables())
+	case formatNDJSON:
+		err = writeNDJSON(w, value, tables)
+	case formatXLSX:
+		w.Header().Set("Content-Disposition", attachment(r, "xlsx"))
+		err = writeXLSX(w, tables())
+	default:
+		err = json.NewEncoder(w).Encode(value)
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "writing response", "format", format, "error", err)
+	}
+}
+
+// attachment names the download after the last segment of the request path
+func attachment(r *http.Request, extension string) string {
+	name := strings.Trim(r.URL.Path, "/")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if name == "" {
+		name = "export"
+	}
+	return fmt.Sprintf("attachment; filename=%q", name+"."+extension)
+}
+
+// writeCSV writes the tables as a single CSV document. Tables with different
+// columns are merged under the union of their headers.
+func writeCSV(w http.ResponseWriter, tables []table) error {
+	var columns []string
+	index := make(map[string]int)
+	for _, t := range tables {
+		for _, column := range t.columns {
+			if _, ok := index[column]; !ok {
+				index[column] = len(columns)
+				columns = append(columns, column)
+			}
+		}
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+	written := 0
+	for _, t := range tables {
+		for _, row := range t.rows {
+			record := make([]string, len(columns))
+			for i, cell := range row {
+				record[index[t.columns[i]]] = cell
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+			if written++; written%flushEvery == 0 {
+				flush(w, writer)
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeNDJSON streams slices one element per line. Other values are streamed
+// as one object per table row.
+func writeNDJSON(w http.ResponseWriter, value any, tables func() []table) error {
+	encoder := json.NewEncoder(w)
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Slice {
+		for i := 0; i < v.Len(); i++ {
+			if err := encoder.Encode(v.Index(i).Interface()); err != nil {
+				return err
+			}
+			if (i+1)%flushEvery == 0 {
+				flush(w, nil)
+			}
+		}
+		return nil
+	}
+
+	for _, t := range tables() {
+		for _, row := range t.rows {
+			record := make(map[string]any, len(row))
+			for i, cell := range row {
+				record[t.columns[i]] = cellValue(cell)
+			}
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// flush pushes buffered output to the client so large exports stream
+func flush(w http.ResponseWriter, writer *csv.Writer) {
+	if writer != nil {
+		writer.Flush()
+	}
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// cellValue converts a numeric cell back into a number
+func cellValue(cell string) any {
+	if number, err := strconv.ParseFloat(cell, 64); err == nil {
+		return number
+	}
+	return cell
+}
+
+// recordTable flattens a slice of structs into a table. Nested structs such as
+// Measurement become "field.subfield" columns, and slice fields are skipped.
+func recordTable(name string, records any) table {
+	t := table{name: name}
+	v := reflect.ValueOf(records)
+	elemType := v.Type().Elem()
+	t.columns = flattenColumns(elemType, "")
+	for i := 0; i < v.Len(); i++ {
+		t.rows = append(t.rows, flattenValues(v.Index(i), elemType))
+	}
+	return t
+}
+
+// flattenColumns lists the column names of a struct type using its JSON tags
+func flattenColumns(structType reflect.Type, prefix string) []string {
+	var columns []string
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name, ok := jsonName(field)
+		if !ok {
+			continue
+		}
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		switch {
+		case isScalar(fieldType):
+			columns = append(columns, prefix+name)
+		case fieldType.Kind() == reflect.Struct:
+			columns = append(columns, flattenColumns(fieldType, prefix+name+".")...)
+		}
+	}
+	return columns
+}
+
+// flattenValues lists the cell values of a struct in flattenColumns order
+func flattenValues(v reflect.Value, structType reflect.Type) []string {
+	var values []string
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if _, ok := jsonName(field); !ok {
+			continue
+		}
+		fieldType := field.Type
+		fieldValue := v.Field(i)
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+			if fieldValue.IsNil() {
+				// Keep the columns of missing values aligned with blank cells
+				if isScalar(fieldType) {
+					values = append(values, "")
+				} else if fieldType.Kind() == reflect.Struct {
+					values = append(values, make([]string, len(flattenColumns(fieldType, "")))...)
+				}
+				continue
+			}
+			fieldValue = fieldValue.Elem()
+		}
+		switch {
+		case isScalar(fieldType):
+			values = append(values, formatCell(fieldValue))
+		case fieldType.Kind() == reflect.Struct:
+			values = append(values, flattenValues(fieldValue, fieldType)...)
+		}
+	}
+	return values
+}
+
+// textMarshalerType is used to treat types such as time.Time as single cells
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// isScalar reports whether values of the type fit in a single cell
+func isScalar(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.Interface, reflect.Func, reflect.Chan:
+		return false
+	case reflect.Struct:
+		return t.Implements(textMarshalerType)
+	}
+	return true
+}
+
+// formatCell formats a scalar value for a table cell
+func formatCell(v reflect.Value) string {
+	if marshaler, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, _ := marshaler.MarshalText()
+		return string(text)
+	}
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// jsonName returns the JSON field name of a struct field and whether it is encoded
+func jsonName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+// seriesTable converts a map of labelled values into a two column table sorted by label
+func seriesTable[V int | float64](name, keyColumn, valueColumn string, series map[string]V) table {
+	t := table{name: name, columns: []string{keyColumn, valueColumn}}
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		t.rows = append(t.rows, []string{key, strconv.FormatFloat(float64(series[key]), 'f', -1, 64)})
+	}
+	return t
+}
+
+// metricTables converts metrics into one table per metric, one row per data point
+func metricTables(metrics []models.Metric) []table {
+	var tables []table
+	for _, metric := range metrics {
+		t := table{name: metric.Name, columns: []string{"name", "units", "date", "qty"}}
+		for _, point := range metric.Data {
+			qty := strconv.FormatFloat(point.Qty, 'f', -1, 64)
+			t.rows = append(t.rows, []string{metric.Name, metric.Units, point.Date, qty})
+		}
+		tables = append(tables, t)
+	}
+	return tables
+}
@@ -0,0 +1,139 @@
+// api/goals.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fitness/data"
+	"fitness/models"
+	"fitness/utils"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Completed periods included in goal progress by default
+const defaultGoalHistory = 12
+
+func GetGoals(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, userStore(r).Goals())
+}
+
+func CreateGoal(w http.ResponseWriter, r *http.Request) {
+	var goal models.Goal
+	if !decodeBody(w, r, &goal) {
+		return
+	}
+	if err := utils.ValidateGoal(&goal); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	goal, err := userStore(r).CreateGoal(goal)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "creating goal", "error", err)
+		http.Error(w, "Error creating goal", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, goal)
+}
+
+func UpdateGoal(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		return
+	}
+
+	// Fields present in the body replace the stored values, the others are kept
+	var invalid error
+	goal, err := userStore(r).UpdateGoal(r.PathValue("id"), func(goal *models.Goal) error {
+		if err := json.Unmarshal(body, goal); err != nil {
+			return err
+		}
+		invalid = utils.ValidateGoal(goal)
+		return invalid
+	})
+	switch {
+	case errors.Is(err, data.ErrGoalNotFound):
+		http.Error(w, "Goal not found", http.StatusNotFound)
+	case invalid != nil:
+		http.Error(w, invalid.Error(), http.StatusBadRequest)
+	case err != nil:
+		slog.ErrorContext(r.Context(), "updating goal", "error", err)
+		http.Error(w, "Error updating goal", http.StatusInternalServerError)
+	default:
+		writeJSON(w, http.StatusOK, goal)
+	}
+}
+
+func DeleteGoal(w http.ResponseWriter, r *http.Request) {
+	err := userStore(r).DeleteGoal(r.PathValue("id"))
+	if errors.Is(err, data.ErrGoalNotFound) {
+		http.Error(w, "Goal not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "deleting goal", "error", err)
+		http.Error(w, "Error deleting goal", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func GetGoalsProgress(w http.ResponseWriter, r *http.Request) {
+	history, ok := goalHistory(w, r)
+	if !ok {
+		return
+	}
+
+	progress := []models.GoalProgress{}
+	for _, goal := range userStore(r).Goals() {
+		progress = append(progress, goalProgress(r, goal, history))
+	}
+	writeJSON(w, http.StatusOK, progress)
+}
+
+func GetGoalProgress(w http.ResponseWriter, r *http.Request) {
+	goal, ok := userStore(r).Goal(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "Goal not found", http.StatusNotFound)
+		return
+	}
+	history, ok := goalHistory(w, r)
+	if !ok {
+		return
+	}
+
+	progress := goalProgress(r, goal, history)
+	respond(w, r, progress, func() []table {
+		return []table{recordTable("goal progress", append([]models.GoalPeriod{progress.Current}, progress.History...))}
+	})
+}
+
+// goalProgress computes the progress of a goal against the user's live data
+func goalProgress(r *http.Request, goal models.Goal, history int) models.GoalProgress {
+	store := userStore(r)
+	workouts, ok := data.FilterWorkout(store.Workouts(), goal.Workout)
+	if !ok {
+		workouts = nil
+	}
+	return utils.CalculateGoalProgress(goal, workouts, store.Metrics(), requestCalendar(r), time.Now(), history)
+}
+
+// goalHistory parses the number of completed periods to include, writing an
+// error response when it is invalid
+func goalHistory(w http.ResponseWriter, r *http.Request) (int, bool) {
+	value := r.URL.Query().Get("history")
+	if value == "" {
+		return defaultGoalHistory, true
+	}
+	history, err := strconv.Atoi(value)
+	if err != nil || history < 0 {
+		http.Error(w, "Error parsing history", http.StatusBadRequest)
+		return 0, false
+	}
+	return history, true
+}
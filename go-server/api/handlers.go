@@ -3,30 +3,42 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fitness/data"
 	"fitness/models"
-	"fmt"
+	"fitness/utils"
+	"io"
+	"log/slog"
 	"net/http"
 	"strconv"
 )
 
-// Each handler corresponds to a different endpoint in the API
-// Define API endpoints and map them to their respective handlers
-func HandleWorkoutData(w http.ResponseWriter, r *http.Request) {
-	// Handle workout data
-	switch r.Method {
-	case http.MethodGet:
-		GetWorkoutData(w, r)
-	case http.MethodPatch:
-		UpdateWorkoutData(w, r)
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// Each handler corresponds to a different endpoint in the API, see routes.go
+func GetWorkoutData(w http.ResponseWriter, r *http.Request) {
+	workoutData, ok := filterWorkoutData(w, r)
+	if !ok {
+		return
+	}
+
+	system, ok := requestUnits(w, r)
+	if !ok {
+		return
+	}
+	for i := range workoutData {
+		workoutData[i].Pace = utils.WorkoutPace(workoutData[i], system)
 	}
+
+	// Return the filtered workout data in the negotiated format
+	respond(w, r, workoutData, func() []table {
+		return []table{recordTable("workouts", workoutData)}
+	})
 }
 
-func GetWorkoutData(w http.ResponseWriter, r *http.Request) {
-	// Create a copy of the data from AllWorkouts to avoid modifying the original data
-	workoutData := append([]models.Workout(nil), data.AllWorkouts...)
+// filterWorkoutData applies the workout, calories, start and end query parameters
+// to a copy of the workout data, writing an error response when filtering fails
+func filterWorkoutData(w http.ResponseWriter, r *http.Request) ([]models.Workout, bool) {
+	// Get a copy of the signed in user's workouts to avoid modifying the original data
+	workoutData := userStore(r).Workouts()
 	ok := true
 
 	// Get the workout query parameter from the request
@@ -36,7 +48,7 @@ func GetWorkoutData(w http.ResponseWriter, r *http.Request) {
 		workoutData, ok = data.FilterWorkout(workoutData, workout)
 		if !ok || workoutData == nil {
 			http.Error(w, "Error filtering workout data by workout name", http.StatusInternalServerError)
-			return
+			return nil, false
 		}
 	}
 
@@ -46,15 +58,15 @@ func GetWorkoutData(w http.ResponseWriter, r *http.Request) {
 	if calories != "" {
 		caloriesParsed, err := strconv.ParseFloat(calories, 64)
 		if err != nil {
-			fmt.Println("Error parsing string to float:", err)
+			slog.WarnContext(r.Context(), "invalid calories threshold", "error", err)
 			http.Error(w, "Error parsing calories threshold", http.StatusBadRequest)
-			return
+			return nil, false
 		}
 		// Filter the workout data based on the parsed calorie threshold
 		workoutData, ok = data.FilterCalories(workoutData, caloriesParsed)
 		if !ok || workoutData == nil {
 			http.Error(w, "Error filtering workout data by calorie threshold", http.StatusInternalServerError)
-			return
+			return nil, false
 		}
 	}
 
@@ -68,7 +80,7 @@ func GetWorkoutData(w http.ResponseWriter, r *http.Request) {
 		workoutData, ok = data.FilterDate(workoutData, start, true)
 		if !ok || workoutData == nil {
 			http.Error(w, "Error filtering workout data by start date", http.StatusInternalServerError)
-			return
+			return nil, false
 		}
 	}
 	if end != "" {
@@ -76,16 +88,130 @@ func GetWorkoutData(w http.ResponseWriter, r *http.Request) {
 		workoutData, ok = data.FilterDate(workoutData, end, false)
 		if !ok || workoutData == nil {
 			http.Error(w, "Error filtering workout data by end date", http.StatusInternalServerError)
+			return nil, false
+		}
+	}
+
+	return workoutData, true
+}
+
+func UpdateWorkoutData(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		return
+	}
+
+	// Fields present in the body replace the stored values, the others are kept
+	var parseErr error
+	workout, err := userStore(r).UpdateWorkout(r.PathValue("id"), func(workout *models.Workout) error {
+		if parseErr = json.Unmarshal(body, workout); parseErr != nil {
+			return parseErr
+		}
+		// Pace is derived when served and never stored
+		workout.Pace = nil
+		return nil
+	})
+	if errors.Is(err, data.ErrWorkoutNotFound) {
+		http.Error(w, "Workout not found", http.StatusNotFound)
+		return
+	}
+	if parseErr != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "updating workout", "error", err)
+		http.Error(w, "Error updating workout", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, workout)
+}
+
+func DeleteWorkoutData(w http.ResponseWriter, r *http.Request) {
+	err := userStore(r).DeleteWorkout(r.PathValue("id"))
+	if errors.Is(err, data.ErrWorkoutNotFound) {
+		http.Error(w, "Workout not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "deleting workout", "error", err)
+		http.Error(w, "Error deleting workout", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func GetWorkoutHistory(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, userStore(r).History())
+}
+
+func IngestData(w http.ResponseWriter, r *http.Request) {
+	// Health Auto Export pushes the same format it writes to iCloud Drive
+	var healthData models.HealthData
+	if !decodeBody(w, r, &healthData) {
+		return
+	}
+	result, err := userStore(r).Ingest(healthData)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "ingesting data", "error", err)
+		http.Error(w, "Error storing ingested data", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func GetMetricData(w http.ResponseWriter, r *http.Request) {
+	// Get a copy of the signed in user's metrics to avoid modifying the original data
+	metricData := userStore(r).Metrics()
+	ok := true
+
+	// Get the metric name query parameter from the request
+	var name = r.URL.Query().Get("name")
+	if name != "" {
+		metricData, ok = data.FilterMetricName(metricData, name)
+		if !ok || metricData == nil {
+			http.Error(w, "Error filtering metric data by metric name", http.StatusInternalServerError)
 			return
 		}
 	}
 
-	// Set response header and return the filtered workout data as JSON
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(workoutData)
+	// Get the date query parameters from the request
+	var start = r.URL.Query().Get("start")
+	var end = r.URL.Query().Get("end")
+	if start != "" {
+		metricData, ok = data.FilterMetricDate(metricData, start, true)
+		if !ok || metricData == nil {
+			http.Error(w, "Error filtering metric data by start date", http.StatusInternalServerError)
+			return
+		}
+	}
+	if end != "" {
+		metricData, ok = data.FilterMetricDate(metricData, end, false)
+		if !ok || metricData == nil {
+			http.Error(w, "Error filtering metric data by end date", http.StatusInternalServerError)
+			return
+		}
+	}
 
+	// Return the filtered metric data in the negotiated format, one sheet per metric
+	respond(w, r, metricData, func() []table {
+		return metricTables(metricData)
+	})
 }
 
-func UpdateWorkoutData(w http.ResponseWriter, r *http.Request) {
-	// Update workout data
+// decodeBody decodes the JSON request body into v, writing an error response when it fails
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// writeJSON writes v as a JSON response with the given status
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
 }
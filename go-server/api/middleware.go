@@ -0,0 +1,164 @@
+// api/middleware.go
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fitness/config"
+	"fitness/utils"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers sent and read across origins by the Next.js client
+var (
+	corsMethods = []string{http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodDelete}
+	corsHeaders = []string{"Authorization", "Content-Type", "Accept", "Last-Event-ID", "If-None-Match", "If-Modified-Since", "X-Request-ID"}
+	corsExposed = []string{"ETag", "Last-Modified", "Content-Disposition", "X-Request-ID"}
+)
+
+// NewHandler wraps the routes in the middleware shared by every request:
+// request IDs, access logs, panic recovery and CORS
+func NewHandler(mux http.Handler) http.Handler {
+	return withRequestID(accessLog(recoverPanic(cors(mux))))
+}
+
+// statusRecorder remembers the status and size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(p []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	n, err := s.ResponseWriter.Write(p)
+	s.bytes += n
+	return n, err
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// Flush keeps streamed responses moving through the recorder
+func (s *statusRecorder) Flush() {
+	http.NewResponseController(s.ResponseWriter).Flush()
+}
+
+// withRequestID tags the request with the client's X-Request-ID or a new one,
+// echoes it in the response and adds it to every log record of the request
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID(id) {
+			random := make([]byte, 8)
+			rand.Read(random)
+			id = hex.EncodeToString(random)
+		}
+		w.Header().Set("X-Request-ID", id)
+		ctx := utils.WithLogAttrs(r.Context(), slog.String("request_id", id))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID accepts short printable IDs so clients cannot inject into the logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// accessLog logs every request with its status, size and latency
+func accessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.LogAttrs(r.Context(), level, "request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Int("bytes", recorder.bytes),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("remote", r.RemoteAddr),
+		)
+	})
+}
+
+// recoverPanic answers a panicking handler with a 500 instead of dropping the connection
+func recoverPanic(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w}
+		defer func() {
+			value := recover()
+			if value == nil {
+				return
+			}
+			// The server uses this panic to abort a response on purpose
+			if value == http.ErrAbortHandler {
+				panic(value)
+			}
+			slog.ErrorContext(r.Context(), "handler panicked", "panic", value, "stack", string(debug.Stack()))
+			if recorder.status == 0 {
+				http.Error(recorder, "Internal server error", http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(recorder, r)
+	})
+}
+
+// cors allows the configured client origins to call the API with credentials
+// and answers their preflight requests
+func cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		w.Header().Add("Vary", "Origin")
+		if origin == "" || !slices.Contains(config.CORSOrigins, origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		w.Header().Set("Access-Control-Expose-Headers", strings.Join(corsExposed, ", "))
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(corsMethods, ", "))
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(corsHeaders, ", "))
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int((10 * time.Minute).Seconds())))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,269 @@
+// api/openapi.go
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// docsPage renders the OpenAPI document served at /openapi.json
+//
+//go:embed docs.html
+var docsPage []byte
+
+// formatParam is accepted by every route that supports content negotiation
+var formatParam = param{
+	Name: "format", In: "query", Type: "string",
+	Enum:        []string{formatJSON, formatCSV, formatNDJSON, formatXLSX},
+	Description: "Response format, overrides the Accept header",
+}
+
+// schema is an OpenAPI schema object
+type schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+// schemaBuilder converts Go types into schemas, collecting named structs as components
+type schemaBuilder struct {
+	components map[string]*schema
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor returns the schema of a Go type, referencing named structs by component
+func (b *schemaBuilder) schemaFor(t reflect.Type) *schema {
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := b.schemaFor(t.Elem())
+		if s.Ref != "" {
+			return s
+		}
+		nullable := *s
+		nullable.Nullable = true
+		return &nullable
+	case reflect.Bool:
+		return &schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &schema{Type: "number"}
+	case reflect.String:
+		return &schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &schema{Type: "array", Items: b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &schema{Type: "object", AdditionalProperties: b.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t == timeType {
+			return &schema{Type: "string", Format: "date-time"}
+		}
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		// Register named structs once and reference them from then on
+		if _, ok := b.components[t.Name()]; !ok {
+			b.components[t.Name()] = &schema{}
+			*b.components[t.Name()] = *b.structSchema(t)
+		}
+		return &schema{Ref: "#/components/schemas/" + t.Name()}
+	}
+	return &schema{}
+}
+
+// structSchema describes the JSON encoding of a struct type. Fields are required
+// unless they are pointers, omitted when empty or tagged openapi:"optional".
+func (b *schemaBuilder) structSchema(t reflect.Type) *schema {
+	s := &schema{Type: "object", Properties: make(map[string]*schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if embedded := field.Type; field.Anonymous && field.Tag.Get("json") == "" {
+			// Fields of embedded structs are encoded inline, and only required
+			// when the struct is not behind a pointer
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				inline := b.structSchema(embedded)
+				for name, property := range inline.Properties {
+					s.Properties[name] = property
+				}
+				if field.Type.Kind() != reflect.Pointer {
+					s.Required = append(s.Required, inline.Required...)
+				}
+				continue
+			}
+		}
+		name, ok := jsonName(field)
+		if !ok {
+			continue
+		}
+		s.Properties[name] = b.schemaFor(field.Type)
+		optional := strings.Contains(field.Tag.Get("json"), "omitempty") || field.Tag.Get("openapi") == "optional"
+		if !optional && field.Type.Kind() != reflect.Pointer {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+// resolve follows a component reference
+func (b *schemaBuilder) resolve(s *schema) *schema {
+	if s.Ref == "" {
+		return s
+	}
+	return b.components[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+}
+
+// routeParams returns the declared parameters of a route, including the format
+// parameter when the route supports content negotiation
+func routeParams(rt route) []param {
+	params := append([]param(nil), rt.Params...)
+	if rt.Export {
+		params = append(params, formatParam)
+	}
+	return params
+}
+
+// operationID names an operation after its handler function
+func operationID(handler http.HandlerFunc) string {
+	name := runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// paramSchema describes the value of a parameter
+func paramSchema(p param) *schema {
+	return &schema{Type: p.Type, Format: p.Format, Enum: p.Enum}
+}
+
+// buildOpenAPI builds the OpenAPI document for the routes
+func buildOpenAPI(routes []route) map[string]any {
+	builder := &schemaBuilder{components: make(map[string]*schema)}
+	paths := make(map[string]map[string]any)
+	for _, rt := range routes {
+		operation := map[string]any{
+			"operationId": operationID(rt.Handler),
+			"summary":     rt.Summary,
+		}
+
+		var parameters []map[string]any
+		for _, p := range routeParams(rt) {
+			parameters = append(parameters, map[string]any{
+				"name":        p.Name,
+				"in":          p.In,
+				"required":    p.Required || p.In == "path",
+				"description": p.Description,
+				"schema":      paramSchema(p),
+			})
+		}
+		if parameters != nil {
+			operation["parameters"] = parameters
+		}
+
+		if rt.Body != nil {
+			operation["requestBody"] = map[string]any{
+				"required": !rt.Optional,
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": builder.schemaFor(reflect.TypeOf(rt.Body))},
+				},
+			}
+		}
+
+		// Describe the response in every format the route can produce
+		content := make(map[string]any)
+		switch {
+		case rt.Stream:
+			content["text/event-stream"] = map[string]any{"schema": builder.schemaFor(reflect.TypeOf(rt.Response))}
+		case rt.Response != nil:
+			content[formatContentTypes[formatJSON]] = map[string]any{"schema": builder.schemaFor(reflect.TypeOf(rt.Response))}
+		case rt.Produces == "" && rt.Method == http.MethodGet:
+			content["text/html"] = map[string]any{"schema": &schema{Type: "string"}}
+		}
+		if rt.Produces != "" {
+			for _, mediaType := range strings.Split(rt.Produces, ",") {
+				content[mediaType] = map[string]any{"schema": &schema{Type: "string"}}
+			}
+		}
+		if rt.Export {
+			for _, format := range []string{formatCSV, formatNDJSON, formatXLSX} {
+				content[formatContentTypes[format]] = map[string]any{"schema": &schema{Type: "string", Format: "binary"}}
+			}
+		}
+		status := rt.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := map[string]any{"description": http.StatusText(status)}
+		if len(content) > 0 {
+			success["content"] = content
+		}
+		responses := map[string]any{
+			strconv.Itoa(status): success,
+			"400":                map[string]any{"description": "The request does not match this document"},
+		}
+		if !rt.Public {
+			operation["security"] = []map[string][]string{{"bearerAuth": {}}, {"cookieAuth": {}}}
+			responses["401"] = map[string]any{"description": "Missing, invalid or expired token"}
+		}
+		operation["responses"] = responses
+
+		if paths[rt.Path] == nil {
+			paths[rt.Path] = make(map[string]any)
+		}
+		paths[rt.Path][strings.ToLower(rt.Method)] = operation
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "Stride API",
+			"description": "Apple Health & Fitness data exported by Health Auto Export",
+			"version":     "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": builder.components,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{
+					"type": "http", "scheme": "bearer",
+					"description": "Access token from /auth/login or a personal API token from /auth/tokens",
+				},
+				"cookieAuth": map[string]any{"type": "apiKey", "in": "cookie", "name": accessCookie},
+			},
+		},
+	}
+}
+
+var (
+	openAPIOnce     sync.Once
+	openAPIDocument []byte
+)
+
+func GetOpenAPI(w http.ResponseWriter, r *http.Request) {
+	// Build the document once, the routes never change at runtime
+	openAPIOnce.Do(func() {
+		openAPIDocument, _ = json.MarshalIndent(buildOpenAPI(apiRoutes()), "", "  ")
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPIDocument)
+}
+
+func GetDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(docsPage)
+}
@@ -0,0 +1,37 @@
+// api/predictions.go
+package api
+
+import (
+	"fitness/utils"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Days of running efforts race predictions use by default
+const defaultPredictionDays = 90
+
+func GetRacePredictions(w http.ResponseWriter, r *http.Request) {
+	days := defaultPredictionDays
+	if value := r.URL.Query().Get("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 730 {
+			http.Error(w, "Days must be between 1 and 730", http.StatusBadRequest)
+			return
+		}
+		days = parsed
+	}
+
+	store := userStore(r)
+	workouts, metrics := store.Workouts(), store.Metrics()
+	heartRate := utils.NewHeartRateProfile(currentUser(r).Profile, workouts, metrics)
+	calendar := requestCalendar(r)
+	since := calendar.StartOf(time.Now(), utils.Day).AddDate(0, 0, -days)
+	predictions := utils.CalculateRacePredictions(workouts, metrics, heartRate, since)
+	respond(w, r, predictions, func() []table {
+		return []table{
+			recordTable("predictions", predictions.Predictions),
+			recordTable("efforts", predictions.Efforts),
+		}
+	})
+}
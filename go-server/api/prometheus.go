@@ -0,0 +1,50 @@
+// api/prometheus.go
+package api
+
+import (
+	"crypto/subtle"
+	"fitness/config"
+	"fitness/data"
+	"fitness/telemetry"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// instrument counts the requests of a route and measures their latency, labelled
+// by the route pattern rather than the request path
+func instrument(rt route, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		telemetry.HTTPRequests.Inc(rt.Method, rt.Path, strconv.Itoa(status))
+		telemetry.HTTPRequestDuration.Observe(time.Since(start).Seconds(), rt.Method, rt.Path)
+	})
+}
+
+func GetPrometheusMetrics(w http.ResponseWriter, r *http.Request) {
+	if config.MetricsToken != "" {
+		token := []byte(bearerToken(r))
+		if subtle.ConstantTimeCompare(token, []byte(config.MetricsToken)) != 1 {
+			unauthorized(w, "Invalid metrics token")
+			return
+		}
+	}
+
+	// Gauges of the current state are set when scraped
+	workouts, metricPoints := data.MemoryTotals()
+	telemetry.WorkoutsInMemory.Set(float64(workouts))
+	telemetry.MetricPointsInMemory.Set(float64(metricPoints))
+	if last, ok := data.LastImport(); ok {
+		telemetry.SecondsSinceLastImport.Set(time.Since(last).Seconds())
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	telemetry.Default.WriteText(w)
+}
@@ -0,0 +1,45 @@
+// api/records.go
+package api
+
+import (
+	"fitness/data"
+	"fitness/utils"
+	"net/http"
+)
+
+func GetRecords(w http.ResponseWriter, r *http.Request) {
+	workoutData := userStore(r).Workouts()
+	// Records are kept per workout type, so only the type filter applies
+	if workout := r.URL.Query().Get("workout"); workout != "" {
+		filtered, ok := data.FilterWorkout(workoutData, workout)
+		if !ok {
+			filtered = nil
+		}
+		workoutData = filtered
+	}
+
+	records := utils.CalculateRecords(workoutData)
+	respond(w, r, records, func() []table {
+		return []table{
+			recordTable("current records", records.Current),
+			recordTable("record history", records.History),
+		}
+	})
+}
+
+func GetWorkoutAchievements(w http.ResponseWriter, r *http.Request) {
+	store := userStore(r)
+	id := r.PathValue("id")
+	if _, ok := store.Workout(id); !ok {
+		http.Error(w, "Workout not found", http.StatusNotFound)
+		return
+	}
+
+	// Baselines set by a first workout are not achievements
+	records := utils.CalculateRecords(store.Workouts())
+	records.History = utils.Breakthroughs(records.History)
+	achievements := utils.WorkoutAchievements(records, id)
+	respond(w, r, achievements, func() []table {
+		return []table{recordTable("achievements", achievements)}
+	})
+}
@@ -0,0 +1,388 @@
+// api/reports.go
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fitness/config"
+	"fitness/models"
+	"fitness/utils"
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+	"math"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Formats a report can be written in
+const (
+	reportJSON     = "json"
+	reportMarkdown = "markdown"
+	reportHTML     = "html"
+)
+
+// reportContentTypes maps each report format to the content type it is served with
+var reportContentTypes = map[string]string{
+	reportJSON:     "application/json",
+	reportMarkdown: "text/markdown; charset=utf-8",
+	reportHTML:     "text/html; charset=utf-8",
+}
+
+// reportPage is the template of HTML reports
+//
+//go:embed report.html
+var reportPage string
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"sparkline": sparklineSVG,
+}).Parse(reportPage))
+
+func GetReport(w http.ResponseWriter, r *http.Request) {
+	period, err := utils.ParseReportPeriod(r.PathValue("period"))
+	if err != nil {
+		http.Error(w, "Invalid report period", http.StatusBadRequest)
+		return
+	}
+	calendar := requestCalendar(r)
+	date, err := time.ParseInLocation(config.DateFormat, r.PathValue("date"), calendar.Location)
+	if err != nil {
+		http.Error(w, "Invalid report date", http.StatusBadRequest)
+		return
+	}
+	now := time.Now()
+	if calendar.StartOf(date, period).After(now) {
+		http.Error(w, "Reports are only available up to the current period", http.StatusBadRequest)
+		return
+	}
+	format, ok := reportFormat(r)
+	if !ok {
+		http.Error(w, "Unsupported report format", http.StatusNotAcceptable)
+		return
+	}
+
+	store := userStore(r)
+	report := utils.CalculateReport(store.Workouts(), store.Metrics(), calendar, period, date, now)
+	w.Header().Set("Content-Type", reportContentTypes[format])
+	w.Header().Add("Vary", "Accept")
+	if err := writeReport(w, report, format); err != nil {
+		slog.ErrorContext(r.Context(), "writing report", "format", format, "error", err)
+	}
+}
+
+// reportFormat picks the report format from the format query parameter,
+// falling back to the first supported media type of the Accept header and then JSON
+func reportFormat(r *http.Request) (string, bool) {
+	if format := strings.ToLower(r.URL.Query().Get("format")); format != "" {
+		_, ok := reportContentTypes[format]
+		return format, ok
+	}
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "text/markdown":
+			return reportMarkdown, true
+		case "text/html":
+			return reportHTML, true
+		case "application/json", "*/*":
+			return reportJSON, true
+		}
+	}
+	return reportJSON, true
+}
+
+// writeReport writes the report as JSON, Markdown or HTML
+func writeReport(w io.Writer, report models.Report, format string) error {
+	switch format {
+	case reportJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case reportMarkdown:
+		return writeMarkdownReport(w, newReportView(report))
+	case reportHTML:
+		return reportTemplate.Execute(w, newReportView(report))
+	}
+	return fmt.Errorf("unknown report format %q", format)
+}
+
+// reportView holds the report formatted for reading, shared by Markdown and HTML
+type reportView struct {
+	Title    string
+	Subtitle string
+	Previous string // Heading of the previous period column
+	Totals   []reportRow
+	Buckets  []reportSeries // Workout totals per day or week
+	Records  []string
+	Streaks  []string
+	Workouts []string
+	Metrics  []reportRow
+}
+
+// reportRow is a row of the totals or metrics tables
+type reportRow struct {
+	Label    string
+	Current  string
+	Previous string
+	Change   string
+	Values   []float64 // Daily values of a metric, drawn as a sparkline
+}
+
+// reportSeries is a workout total per day or week, drawn as a sparkline
+type reportSeries struct {
+	Label  string
+	Values []float64
+}
+
+// newReportView formats the report for reading
+func newReportView(report models.Report) reportView {
+	view := reportView{Subtitle: report.Start + " to " + report.End}
+	start, _ := time.Parse(config.DateFormat, report.Start)
+	switch report.Period {
+	case string(utils.Week):
+		view.Title, view.Previous = "Week of "+report.Start, "Previous week"
+	case string(utils.Month):
+		view.Title, view.Previous = start.Format("January 2006"), "Previous month"
+	default:
+		view.Title, view.Previous = start.Format("2006"), "Previous year"
+	}
+	if report.Partial {
+		view.Subtitle += ", in progress and compared with the same part of the previous period"
+	}
+
+	labels := map[string]string{
+		"workouts": "Workouts", "activeDays": "Active days", "duration": "Duration",
+		"distance": "Distance", "energy": "Active energy",
+	}
+	for _, change := range report.Changes {
+		row := reportRow{
+			Label:    labels[change.Field],
+			Current:  formatTotal(change.Field, change.Current),
+			Previous: formatTotal(change.Field, change.Previous),
+			Change:   signed(formatTotal(change.Field, math.Abs(change.Delta)), change.Delta),
+		}
+		if change.Percent != nil {
+			row.Change += fmt.Sprintf(" (%+.1f%%)", *change.Percent)
+		}
+		view.Totals = append(view.Totals, row)
+	}
+
+	per := "day"
+	if report.Period == string(utils.Year) {
+		per = "week"
+	}
+	distance, duration, energy := make([]float64, len(report.Buckets)), make([]float64, len(report.Buckets)), make([]float64, len(report.Buckets))
+	for i, bucket := range report.Buckets {
+		distance[i], duration[i], energy[i] = bucket.Distance, bucket.Duration/60, bucket.Energy
+	}
+	view.Buckets = []reportSeries{
+		{Label: "Distance per " + per, Values: distance},
+		{Label: "Minutes per " + per, Values: duration},
+		{Label: "Active energy per " + per, Values: energy},
+	}
+
+	for _, record := range report.Records {
+		line := fmt.Sprintf("%s, %s: %s on %s", record.WorkoutType, recordName(record), formatRecord(record.Value, record.Units), record.Date[:min(len(record.Date), len(config.DateFormat))])
+		if record.Previous != nil {
+			line += fmt.Sprintf(", previously %s", formatRecord(*record.Previous, record.Units))
+		}
+		view.Records = append(view.Records, line)
+	}
+
+	view.Streaks = []string{
+		streakLine("Daily streak", "day", report.Streaks),
+		streakLine("Weekly streak", "week", report.WeekStreaks),
+	}
+
+	for _, workout := range report.TopWorkouts {
+		line := fmt.Sprintf("%s on %s: %s", workout.Name, workout.Start[:min(len(workout.Start), len(config.DateFormat))], utils.FormatTime(workout.Duration))
+		if workout.Distance > 0 {
+			line += fmt.Sprintf(", %.2f km", workout.Distance)
+		}
+		if workout.Energy > 0 {
+			line += fmt.Sprintf(", %.0f kcal", workout.Energy)
+		}
+		view.Workouts = append(view.Workouts, line)
+	}
+
+	for _, metric := range report.Metrics {
+		view.Metrics = append(view.Metrics, reportRow{
+			Label:    strings.ReplaceAll(metric.Name, "_", " "),
+			Current:  fmt.Sprintf("%g %s", metric.Average, metric.Units),
+			Previous: fmt.Sprintf("%g %s", metric.Previous, metric.Units),
+			Change:   fmt.Sprintf("%+.1f%%", metric.Percent),
+			Values:   metric.Daily,
+		})
+	}
+	return view
+}
+
+// writeMarkdownReport writes the report as Markdown, with sparklines drawn with block characters
+func writeMarkdownReport(w io.Writer, view reportView) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n_%s_\n\n", view.Title, view.Subtitle)
+
+	b.WriteString("## Totals\n\n")
+	fmt.Fprintf(&b, "| | This period | %s | Change |\n|---|---:|---:|---:|\n", view.Previous)
+	for _, row := range view.Totals {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", row.Label, row.Current, row.Previous, row.Change)
+	}
+	b.WriteString("\n")
+	for _, series := range view.Buckets {
+		fmt.Fprintf(&b, "- %s: `%s`\n", series.Label, sparklineText(series.Values))
+	}
+
+	b.WriteString("\n## Personal records\n\n")
+	writeMarkdownList(&b, view.Records, "No new records")
+	b.WriteString("\n## Streaks\n\n")
+	writeMarkdownList(&b, view.Streaks, "")
+	b.WriteString("\n## Top workouts\n\n")
+	if len(view.Workouts) == 0 {
+		b.WriteString("No workouts\n")
+	}
+	for i, line := range view.Workouts {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, line)
+	}
+
+	b.WriteString("\n## Notable metric changes\n\n")
+	if len(view.Metrics) == 0 {
+		b.WriteString("No notable changes\n")
+	} else {
+		fmt.Fprintf(&b, "| Metric | This period | %s | Change | Daily |\n|---|---:|---:|---:|---|\n", view.Previous)
+		for _, row := range view.Metrics {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | `%s` |\n", row.Label, row.Current, row.Previous, row.Change, sparklineText(row.Values))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeMarkdownList writes the lines as a bullet list, or the fallback without lines
+func writeMarkdownList(b *strings.Builder, lines []string, fallback string) {
+	if len(lines) == 0 {
+		b.WriteString(fallback + "\n")
+	}
+	for _, line := range lines {
+		fmt.Fprintf(b, "- %s\n", line)
+	}
+}
+
+// formatTotal formats a value of a report total
+func formatTotal(field string, value float64) string {
+	switch field {
+	case "duration":
+		return utils.FormatTime(value)
+	case "distance":
+		return fmt.Sprintf("%.2f km", value)
+	case "energy":
+		return fmt.Sprintf("%.0f kcal", value)
+	}
+	return fmt.Sprintf("%g", value)
+}
+
+// signed prefixes a formatted absolute value with the sign of delta
+func signed(formatted string, delta float64) string {
+	switch {
+	case delta > 0:
+		return "+" + formatted
+	case delta < 0:
+		return "-" + formatted
+	}
+	return formatted
+}
+
+// recordName describes the category of a personal record
+func recordName(record models.PersonalRecord) string {
+	switch record.Category {
+	case models.RecordLongestDistance:
+		return "longest distance"
+	case models.RecordLongestDuration:
+		return "longest workout"
+	case models.RecordMostEnergy:
+		return "most active energy"
+	case models.RecordFastestPace:
+		return "fastest pace"
+	case models.RecordBestEffort:
+		return "best " + record.Distance
+	}
+	return record.Category
+}
+
+// formatRecord formats the value of a personal record in its units
+func formatRecord(value float64, units string) string {
+	switch units {
+	case "s":
+		return utils.FormatTime(value)
+	case "s/km":
+		return utils.FormatTime(value) + "/km"
+	case "km":
+		return fmt.Sprintf("%.2f km", value)
+	}
+	return fmt.Sprintf("%.0f %s", value, units)
+}
+
+// streakLine describes the current and longest streak of one kind
+func streakLine(name, unit string, streaks models.StreakSummary) string {
+	plural := func(n int) string {
+		if n == 1 {
+			return fmt.Sprintf("%d %s", n, unit)
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+	line := fmt.Sprintf("%s: %s", name, plural(streaks.Current.Length))
+	if streaks.Current.Length > 0 {
+		line += " since " + streaks.Current.Start
+	}
+	return line + fmt.Sprintf(", longest %s", plural(streaks.Longest.Length))
+}
+
+// sparklineText draws the values with block characters, scaled to their maximum
+func sparklineText(values []float64) string {
+	blocks := []rune("▁▂▃▄▅▆▇█")
+	peak := 0.0
+	for _, value := range values {
+		peak = math.Max(peak, value)
+	}
+	line := make([]rune, len(values))
+	for i, value := range values {
+		level := 0
+		if peak > 0 {
+			level = int(math.Round(math.Max(value, 0) / peak * float64(len(blocks)-1)))
+		}
+		line[i] = blocks[level]
+	}
+	return string(line)
+}
+
+// sparklineSVG draws the values as an inline SVG polyline, scaled between their minimum and maximum
+func sparklineSVG(values []float64) template.HTML {
+	const width, height = 160.0, 32.0
+	if len(values) == 0 {
+		return ""
+	}
+	low, high := values[0], values[0]
+	for _, value := range values {
+		low, high = math.Min(low, value), math.Max(high, value)
+	}
+	points := make([]string, len(values))
+	for i, value := range values {
+		x := width / 2
+		if len(values) > 1 {
+			x = width * float64(i) / float64(len(values)-1)
+		}
+		y := height / 2
+		if high > low {
+			y = height - 2 - (height-4)*(value-low)/(high-low)
+		}
+		points[i] = fmt.Sprintf("%.1f,%.1f", x, y)
+	}
+	return template.HTML(fmt.Sprintf(
+		`<svg class="sparkline" width="%g" height="%g" viewBox="0 0 %g %g" role="img"><polyline fill="none" stroke="currentColor" stroke-width="1.5" points="%s"/></svg>`,
+		width, height, width, height, strings.Join(points, " ")))
+}
@@ -0,0 +1,159 @@
+// api/review.go
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fitness/config"
+	"fitness/models"
+	"fitness/utils"
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// reviewPage is the template of the shareable year in review page
+//
+//go:embed review.html
+var reviewPage string
+
+var reviewTemplate = template.Must(template.New("review").Funcs(template.FuncMap{
+	"sparkline": sparklineSVG,
+}).Parse(reviewPage))
+
+func GetYearInReview(w http.ResponseWriter, r *http.Request) {
+	calendar := requestCalendar(r)
+	now := time.Now()
+	year, err := strconv.Atoi(r.PathValue("year"))
+	if err != nil || year < 1970 || year > calendar.In(now).Year() {
+		http.Error(w, "Year must be between 1970 and the current year", http.StatusBadRequest)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = reportJSON
+	}
+
+	review := utils.CalculateYearInReview(userStore(r).Workouts(), calendar, year, now)
+	w.Header().Set("Content-Type", reportContentTypes[format])
+	if err := writeReview(w, review, format); err != nil {
+		slog.ErrorContext(r.Context(), "writing year in review", "format", format, "error", err)
+	}
+}
+
+// writeReview writes the year in review as JSON or as a self-contained HTML page
+func writeReview(w io.Writer, review models.YearInReview, format string) error {
+	switch format {
+	case reportJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(review)
+	case reportHTML:
+		return reviewTemplate.Execute(w, newReviewView(review))
+	}
+	return fmt.Errorf("unknown review format %q", format)
+}
+
+// reviewView holds the year in review formatted for the HTML page
+type reviewView struct {
+	Title        string
+	Subtitle     string
+	Stats        []reviewStat
+	Types        []reviewType
+	Weeks        []float64 // Hours of every week, drawn as a sparkline
+	Highlights   []string
+	Records      []string
+	Equivalences []string
+}
+
+// reviewStat is a headline total and its change from the previous year
+type reviewStat struct {
+	Label  string
+	Value  string
+	Change string
+}
+
+// reviewType is a row of the workout types table
+type reviewType struct {
+	Name     string
+	Workouts int
+	Time     string
+	Distance string
+}
+
+// newReviewView formats the year in review for the HTML page
+func newReviewView(review models.YearInReview) reviewView {
+	view := reviewView{Title: fmt.Sprintf("%d in review", review.Year)}
+	if review.Partial {
+		view.Subtitle = "So far this year, compared with the same part of last year"
+	} else {
+		view.Subtitle = fmt.Sprintf("Compared with %d", review.Year-1)
+	}
+
+	labels := map[string]string{
+		"workouts": "Workouts", "activeDays": "Active days", "duration": "Time",
+		"distance": "Distance", "energy": "Active energy",
+	}
+	for _, change := range review.Changes {
+		stat := reviewStat{Label: labels[change.Field], Value: formatTotal(change.Field, change.Current)}
+		if change.Percent != nil {
+			stat.Change = fmt.Sprintf("%+.0f%%", *change.Percent)
+		} else if change.Current > 0 {
+			stat.Change = "new"
+		}
+		view.Stats = append(view.Stats, stat)
+	}
+
+	for _, kind := range review.Types {
+		row := reviewType{Name: kind.Name, Workouts: kind.Workouts, Time: utils.FormatTime(kind.Duration)}
+		if kind.Distance > 0 {
+			row.Distance = fmt.Sprintf("%.1f km", kind.Distance)
+		}
+		view.Types = append(view.Types, row)
+	}
+	for _, week := range review.Weeks {
+		view.Weeks = append(view.Weeks, math.Round(week.Duration/36)/100)
+	}
+
+	if month := review.BiggestMonth; month != nil {
+		name := month.Key
+		if start, err := time.Parse("2006-01", month.Key); err == nil {
+			name = start.Format("January")
+		}
+		view.Highlights = append(view.Highlights, fmt.Sprintf("Biggest month: %s, %d workouts in %s", name, month.Workouts, utils.FormatTime(month.Duration)))
+	}
+	if week := review.BiggestWeek; week != nil {
+		view.Highlights = append(view.Highlights, fmt.Sprintf("Biggest week: %s, %d workouts in %s", week.Key, week.Workouts, utils.FormatTime(week.Duration)))
+	}
+	if streak := review.LongestStreak; streak.Length > 0 {
+		view.Highlights = append(view.Highlights, fmt.Sprintf("Longest streak: %d days from %s to %s", streak.Length, streak.Start, streak.End))
+	}
+	if streak := review.LongestWeekStreak; streak.Length > 0 {
+		view.Highlights = append(view.Highlights, fmt.Sprintf("Most consistent run: %d active weeks in a row from %s", streak.Length, streak.Start))
+	}
+	if review.FavoriteTime != "" {
+		view.Highlights = append(view.Highlights, fmt.Sprintf("Favorite time: %s, most often at %02d:00", review.FavoriteTime, *review.FavoriteHour))
+	}
+	if review.FavoriteWeekday != "" {
+		view.Highlights = append(view.Highlights, "Favorite day: "+review.FavoriteWeekday)
+	}
+	if workout := review.FirstWorkout; workout != nil {
+		view.Highlights = append(view.Highlights, fmt.Sprintf("First workout: %s on %s", workout.Name, workout.Start[:min(len(workout.Start), len(config.DateFormat))]))
+	}
+	if workout := review.LastWorkout; workout != nil {
+		view.Highlights = append(view.Highlights, fmt.Sprintf("Last workout: %s on %s", workout.Name, workout.Start[:min(len(workout.Start), len(config.DateFormat))]))
+	}
+
+	for _, record := range review.Records {
+		view.Records = append(view.Records, fmt.Sprintf("%s, %s: %s", record.WorkoutType, recordName(record), formatRecord(record.Value, record.Units)))
+	}
+	for _, equivalence := range review.Equivalences {
+		view.Equivalences = append(view.Equivalences, equivalence.Description)
+	}
+	return view
+}
@@ -1,11 +1,421 @@
 // api/routes.go
 package api
 
-import "net/http"
+import (
+	"fitness/config"
+	"fitness/models"
+	"net/http"
+)
+
+// route describes an API endpoint. The same description registers the handler,
+// builds the OpenAPI document and validates incoming requests.
+type route struct {
+	Method   string           // HTTP method of the endpoint
+	Path     string           // Path pattern, with {name} path parameters
+	Handler  http.HandlerFunc // Handler serving the endpoint
+	Summary  string           // Short description for the API documentation
+	Params   []param          // Path and query parameters accepted by the endpoint
+	Body     any              // Zero value of the request body type, nil when there is none
+	Optional bool             // Whether the request body may be left out
+	Response any              // Zero value of the response type
+	Produces string           // Comma separated media types of responses that are not JSON, text/html when empty without a Response
+	Stream   bool             // Whether the response is a text/event-stream of Response values
+	Status   int              // Status code of a successful response, 200 when zero
+	Export   bool             // Whether the response supports content negotiation
+	Cached   bool             // Whether the response only changes with the data version
+	Public   bool             // Whether the endpoint can be used without signing in
+}
+
+// param describes a path, query or header parameter
+type param struct {
+	Name        string   // Name of the parameter
+	In          string   // Either "query", "path" or "header"
+	Type        string   // OpenAPI type: string, number, integer or boolean
+	Format      string   // OpenAPI format such as "date"
+	Enum        []string // Allowed values, if restricted
+	Required    bool     // Whether the parameter must be present
+	Description string   // Description for the API documentation
+}
+
+// workoutFilterParams are the query parameters accepted by filterWorkoutData
+var workoutFilterParams = []param{
+	{Name: "workout", In: "query", Type: "string", Description: "Comma separated workout names to include"},
+	{Name: "calories", In: "query", Type: "number", Description: "Minimum active energy burned"},
+	{Name: "start", In: "query", Type: "string", Format: "date", Description: "Only include data on or after this date"},
+	{Name: "end", In: "query", Type: "string", Format: "date", Description: "Only include data on or before this date"},
+}
+
+// unitsParam selects the unit system of pace, speed and splits
+var unitsParam = param{
+	Name: "units", In: "query", Type: "string", Enum: []string{"metric", "imperial"},
+	Description: "Unit system of pace, speed and splits, the profile setting by default",
+}
+
+// zoneModelParam selects the heart rate zone model
+var zoneModelParam = param{
+	Name: "model", In: "query", Type: "string", Enum: []string{"max", "reserve", "threshold"},
+	Description: "Zones as a percent of the maximum heart rate, heart rate reserve or lactate threshold, the profile setting by default",
+}
+
+// sleepParams select the nights of the sleep endpoints
+var sleepParams = []param{
+	{Name: "start", In: "query", Type: "string", Format: "date", Description: "Only include nights ending on or after this date"},
+	{Name: "end", In: "query", Type: "string", Format: "date", Description: "Only include nights ending on or before this date"},
+	{Name: "target", In: "query", Type: "number", Description: "Hours of sleep a night the debt is measured against, 8 by default"},
+}
+
+// workoutIDParam identifies a workout in the path
+var workoutIDParam = param{Name: "id", In: "path", Type: "string", Description: "ID of the workout"}
+
+// goalIDParam identifies a goal in the path
+var goalIDParam = param{Name: "id", In: "path", Type: "string", Description: "ID of the goal"}
+
+// goalHistoryParam limits the completed periods of goal progress
+var goalHistoryParam = param{Name: "history", In: "query", Type: "integer", Description: "Completed periods to include, 12 by default"}
+
+// apiRoutes lists every endpoint served by the API
+func apiRoutes() []route {
+	return []route{
+		{
+			Method: http.MethodGet, Path: "/workouts", Handler: GetWorkoutData,
+			Summary:  "List workouts with their average pace and speed",
+			Params:   append(append([]param(nil), workoutFilterParams...), unitsParam),
+			Response: []models.Workout{}, Export: true, Cached: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/workouts/history", Handler: GetWorkoutHistory,
+			Summary: "List the edits made to workouts", Response: []models.WorkoutEdit{},
+		},
+		{
+			Method: http.MethodPatch, Path: "/workouts/{id}", Handler: UpdateWorkoutData,
+			Summary: "Update fields of a workout", Body: models.Workout{}, Response: models.Workout{},
+			Params: []param{workoutIDParam},
+		},
+		{
+			Method: http.MethodDelete, Path: "/workouts/{id}", Handler: DeleteWorkoutData,
+			Summary: "Delete a workout", Status: http.StatusNoContent,
+			Params: []param{workoutIDParam},
+		},
+		{
+			Method: http.MethodGet, Path: "/stats/streaks", Handler: GetStreaks,
+			Summary: "Current and longest daily and weekly streaks with weekly consistency",
+			Params: append(append([]param(nil), workoutFilterParams...),
+				param{Name: "minDuration", In: "query", Type: "number", Description: "Minutes of exercise a day needs to count, 0 by default"},
+				param{Name: "minEnergy", In: "query", Type: "number", Description: "Active kilocalories a day needs to count, 0 by default"},
+				param{Name: "restDays", In: "query", Type: "integer", Description: "Rest days in a row allowed in a daily streak, 0 by default"},
+				param{Name: "weekDays", In: "query", Type: "integer", Description: "Active days a week needs for a weekly streak, 1 by default"},
+			),
+			// Not cached, the current streaks change with the date
+			Response: models.Streaks{}, Export: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/stats/trends", Handler: GetTrends,
+			Summary: "Moving averages, linear trend and Holt-Winters forecast of a series per period",
+			Params: append(append([]param(nil), workoutFilterParams...),
+				param{Name: "series", In: "query", Type: "string", Description: "Workout field (workouts, distance, duration or energy) or health metric, distance by default"},
+				param{Name: "bucket", In: "query", Type: "string", Enum: []string{"day", "week", "isoweek", "month", "year"}, Description: "Period of the series, week by default"},
+				param{Name: "horizon", In: "query", Type: "integer", Description: "Periods to forecast, 8 by default"},
+				param{Name: "window", In: "query", Type: "integer", Description: "Periods in the moving averages, 4 by default"},
+				unitsParam,
+			),
+			// Not cached, the series ends with the last completed period
+			Response: models.Trends{}, Export: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/stats/correlations", Handler: GetCorrelations,
+			Summary: "Pearson and Spearman correlations between two daily series at one or more lags",
+			Params: append(append([]param(nil), workoutFilterParams...),
+				param{Name: "x", In: "query", Type: "string", Required: true, Description: "Workout field (workouts, distance, duration or energy) or health metric"},
+				param{Name: "y", In: "query", Type: "string", Required: true, Description: "Workout field or health metric compared with x"},
+				param{Name: "lags", In: "query", Type: "string", Description: "Comma separated days y is taken after x, between -90 and 90, 0 by default"},
+			),
+			Response: []models.Correlation{}, Export: true, Cached: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/stats/correlations/matrix", Handler: GetCorrelationMatrix,
+			Summary: "Correlation matrix between 2 to 12 daily series",
+			Params: append(append([]param(nil), workoutFilterParams...),
+				param{Name: "series", In: "query", Type: "string", Required: true, Description: "Comma separated workout fields or health metrics"},
+				param{Name: "lag", In: "query", Type: "integer", Description: "Days the column series are taken after the row series, 0 by default"},
+				param{Name: "method", In: "query", Type: "string", Enum: []string{"pearson", "spearman"}, Description: "Coefficient in the matrix, pearson by default"},
+			),
+			Response: models.CorrelationMatrix{}, Export: true, Cached: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/stats/training-load", Handler: GetTrainingLoad,
+			Summary: "Daily training load with ATL, CTL, TSB and the acute to chronic workload ratio",
+			Params:  workoutFilterParams,
+			// Not cached, the series runs up to today
+			Response: models.TrainingLoad{}, Export: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/predictions/race", Handler: GetRacePredictions,
+			Summary: "Riegel, Cameron and VDOT race time predictions with the efforts they are based on",
+			Params: []param{
+				{Name: "days", In: "query", Type: "integer", Description: "Days of running efforts to predict from, 90 by default"},
+			},
+			// Not cached, the efforts are the most recent ones
+			Response: models.RacePredictions{}, Export: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/stats/energy-balance", Handler: GetEnergyBalance,
+			Summary: "Daily energy intake against basal and active expenditure with projected and actual weight change",
+			Params: []param{
+				{Name: "start", In: "query", Type: "string", Format: "date", Description: "Only include days on or after this date"},
+				{Name: "end", In: "query", Type: "string", Format: "date", Description: "Only include days on or before this date"},
+				{Name: "window", In: "query", Type: "integer", Description: "Days in the rolling average balance, 7 by default"},
+			},
+			Response: models.EnergyBalance{}, Export: true, Cached: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/reports/{period}/{date}", Handler: GetReport,
+			Summary: "Weekly, monthly or yearly summary with totals, comparison, records, streaks and notable metric changes",
+			Params: []param{
+				{Name: "period", In: "path", Type: "string", Enum: []string{"week", "month", "year"}, Description: "Period of the report"},
+				{Name: "date", In: "path", Type: "string", Format: "date", Description: "Any day of the period"},
+				{Name: "format", In: "query", Type: "string", Enum: []string{reportJSON, reportMarkdown, reportHTML}, Description: "Report format, overrides the Accept header"},
+			},
+			// Not cached, a period in progress is reported up to now
+			Response: models.Report{}, Produces: "text/markdown,text/html",
+		},
+		{
+			Method: http.MethodGet, Path: "/review/{year}", Handler: GetYearInReview,
+			Summary: "Year in review with totals per workout type, highlights, records and equivalences, as JSON or a shareable page",
+			Params: []param{
+				{Name: "year", In: "path", Type: "integer", Description: "Calendar year to review"},
+				{Name: "format", In: "query", Type: "string", Enum: []string{reportJSON, reportHTML}, Description: "Either json or a self-contained html page, json by default"},
+			},
+			// Not cached, the current year is reviewed up to now
+			Response: models.YearInReview{}, Produces: "text/html",
+		},
+		{
+			Method: http.MethodGet, Path: "/compare", Handler: GetComparison,
+			Summary: "Totals and averages of the period in progress against the same days of an earlier period",
+			Params: []param{
+				workoutFilterParams[0], workoutFilterParams[1],
+				{Name: "metric", In: "query", Type: "string", Description: "Health metric to compare instead of workouts"},
+				{Name: "period", In: "query", Type: "string", Enum: []string{"day", "week", "isoweek", "month", "year"}, Description: "Period compared, month by default"},
+				{Name: "offset", In: "query", Type: "integer", Description: "Periods between the compared periods, 1 by default"},
+			},
+			// Not cached, the period in progress changes with the date
+			Response: models.Comparison{}, Export: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/sleep/nights", Handler: GetSleepNights,
+			Summary:  "Nightly sleep duration, efficiency, stages and debt with bed time consistency",
+			Params:   sleepParams,
+			Response: models.SleepReport{}, Export: true, Cached: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/sleep/weekly", Handler: GetWeeklySleep,
+			Summary:  "Weekly averages of sleep duration, efficiency, stages and consistency",
+			Params:   sleepParams,
+			Response: []models.SleepWeek{}, Export: true, Cached: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/stats/zones", Handler: GetZoneTrends,
+			Summary: "Time in heart rate zones and intensity distribution per period",
+			Params: append(append([]param(nil), workoutFilterParams...),
+				param{Name: "bucket", In: "query", Type: "string", Enum: []string{"day", "week", "isoweek", "month", "year"}, Description: "Period of the rollups, week by default"},
+				zoneModelParam,
+			),
+			Response: models.ZoneTrends{}, Export: true, Cached: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/stats/zones/distribution", Handler: GetZoneDistribution,
+			Summary:  "Time in heart rate zones with a polarized training distribution report",
+			Params:   append(append([]param(nil), workoutFilterParams...), zoneModelParam),
+			Response: models.ZoneDistribution{}, Export: true, Cached: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/workouts/{id}/zones", Handler: GetWorkoutZones,
+			Summary:  "Time a workout spent in every heart rate zone",
+			Params:   []param{workoutIDParam, zoneModelParam},
+			Response: models.WorkoutZones{}, Export: true, Cached: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/workouts/{id}/splits", Handler: GetWorkoutSplits,
+			Summary:  "Kilometer or mile splits of a workout with negative split detection",
+			Params:   []param{workoutIDParam, unitsParam},
+			Response: models.WorkoutSplits{}, Export: true, Cached: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/workouts/{id}/achievements", Handler: GetWorkoutAchievements,
+			Summary: "List the personal records set by a workout", Params: []param{workoutIDParam},
+			Response: []models.PersonalRecord{}, Export: true, Cached: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/records", Handler: GetRecords,
+			Summary: "Personal records per workout type and their history",
+			Params: []param{
+				{Name: "workout", In: "query", Type: "string", Description: "Comma separated workout names to include"},
+			},
+			Response: models.Records{}, Export: true, Cached: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/goals", Handler: GetGoals,
+			Summary: "List goals", Response: []models.Goal{},
+		},
+		{
+			Method: http.MethodPost, Path: "/goals", Handler: CreateGoal,
+			Summary: "Create a goal for a workout field or metric per period", Body: models.Goal{},
+			Response: models.Goal{}, Status: http.StatusCreated,
+		},
+		{
+			Method: http.MethodPatch, Path: "/goals/{id}", Handler: UpdateGoal,
+			Summary: "Update fields of a goal", Body: models.Goal{}, Response: models.Goal{},
+			Params: []param{goalIDParam},
+		},
+		{
+			Method: http.MethodDelete, Path: "/goals/{id}", Handler: DeleteGoal,
+			Summary: "Delete a goal", Status: http.StatusNoContent,
+			Params: []param{goalIDParam},
+		},
+		{
+			Method: http.MethodGet, Path: "/goals/progress", Handler: GetGoalsProgress,
+			Summary: "Progress of every goal", Params: []param{goalHistoryParam},
+			// Not cached, progress is projected from the current time
+			Response: []models.GoalProgress{},
+		},
+		{
+			Method: http.MethodGet, Path: "/goals/{id}/progress", Handler: GetGoalProgress,
+			Summary: "Progress of a goal with its met and missed periods and projected completion",
+			Params:  []param{goalIDParam, goalHistoryParam},
+			// Not cached, progress is projected from the current time
+			Response: models.GoalProgress{}, Export: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/events", Handler: GetEvents,
+			Summary:  "Stream changes to the data as Server-Sent Events",
+			Response: models.Event{}, Stream: true,
+			Params: []param{{
+				Name: "Last-Event-ID", In: "header", Type: "integer",
+				Description: "ID of the last event received, set by EventSource when reconnecting",
+			}},
+		},
+		{
+			Method: http.MethodPost, Path: "/ingest", Handler: IngestData,
+			Summary: "Push Health Auto Export data, usually with a personal API token",
+			Body:    models.HealthData{}, Response: models.IngestResult{},
+		},
+		{
+			Method: http.MethodGet, Path: "/metrics", Handler: GetMetricData,
+			Summary: "List health metrics",
+			Params: []param{
+				{Name: "name", In: "query", Type: "string", Description: "Comma separated metric names to include"},
+				{Name: "start", In: "query", Type: "string", Format: "date", Description: "Only include data points on or after this date"},
+				{Name: "end", In: "query", Type: "string", Format: "date", Description: "Only include data points on or before this date"},
+			},
+			Response: []models.Metric{}, Export: true, Cached: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/anomalies", Handler: GetAnomalies,
+			Summary: "Days on which health metrics strayed from their rolling baseline",
+			Params: []param{
+				{Name: "metric", In: "query", Type: "string", Description: "Comma separated metric names to include"},
+				{Name: "start", In: "query", Type: "string", Format: "date", Description: "Only include anomalies on or after this date"},
+				{Name: "end", In: "query", Type: "string", Format: "date", Description: "Only include anomalies on or before this date"},
+			},
+			Response: []models.Anomaly{}, Export: true, Cached: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/stats/workouts-per-month", Handler: GetWorkoutsPerMonth,
+			Summary: "Count workouts per month", Params: workoutFilterParams,
+			Response: map[string]int{}, Export: true, Cached: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/stats/distance-per-workout", Handler: GetDistancePerWorkout,
+			Summary: "Total distance per workout type", Params: workoutFilterParams,
+			Response: map[string]float64{}, Export: true, Cached: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/stats/distance-per-week", Handler: GetDistancePerWeek,
+			Summary: "Total distance per week", Params: workoutFilterParams,
+			Response: map[string]float64{}, Export: true, Cached: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/stats/energy-per-week", Handler: GetEnergyPerWeek,
+			Summary: "Total active energy per week", Params: workoutFilterParams,
+			Response: map[string]float64{}, Export: true, Cached: true,
+		},
+		{
+			Method: http.MethodPost, Path: "/auth/signup", Handler: Signup,
+			Summary: "Register an account and sign in", Body: models.Signup{},
+			Response: models.Session{}, Status: http.StatusCreated, Public: true,
+		},
+		{
+			Method: http.MethodPost, Path: "/auth/login", Handler: Login,
+			Summary: "Sign in with email and password", Body: models.Credentials{},
+			Response: models.Session{}, Public: true,
+		},
+		{
+			Method: http.MethodPost, Path: "/auth/refresh", Handler: Refresh,
+			Summary: "Exchange a refresh token, from the body or cookie, for new tokens",
+			Body:    models.RefreshRequest{}, Optional: true, Response: models.Session{}, Public: true,
+		},
+		{
+			Method: http.MethodPost, Path: "/auth/logout", Handler: Logout,
+			Summary: "Sign out the session of a refresh token, from the body or cookie",
+			Body:    models.RefreshRequest{}, Optional: true, Status: http.StatusNoContent, Public: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/auth/me", Handler: GetCurrentUser,
+			Summary: "Get the signed in user", Response: models.User{},
+		},
+		{
+			Method: http.MethodPatch, Path: "/auth/me", Handler: UpdateCurrentUser,
+			Summary: "Update the profile of the signed in user, fields missing from the body are kept", Body: models.Profile{}, Response: models.User{},
+		},
+		{
+			Method: http.MethodGet, Path: "/auth/tokens", Handler: GetAPITokens,
+			Summary: "List personal API tokens", Response: []models.APIToken{},
+		},
+		{
+			Method: http.MethodPost, Path: "/auth/tokens", Handler: CreateAPIToken,
+			Summary: "Create a personal API token for scripts", Body: models.NewAPIToken{},
+			Response: models.APIToken{}, Status: http.StatusCreated,
+		},
+		{
+			Method: http.MethodDelete, Path: "/auth/tokens/{id}", Handler: DeleteAPIToken,
+			Summary: "Revoke a personal API token", Status: http.StatusNoContent,
+			Params: []param{{Name: "id", In: "path", Type: "string", Description: "ID of the token"}},
+		},
+		{
+			Method: http.MethodGet, Path: "/healthz", Handler: GetHealth,
+			Summary: "Report that the server is alive", Response: models.HealthStatus{}, Public: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/readyz", Handler: GetReadiness,
+			Summary:  "Report whether the initial import has finished, 503 until then or while shutting down",
+			Response: models.HealthStatus{}, Public: true,
+		},
+		{
+			Method: http.MethodGet, Path: config.MetricsPath, Handler: GetPrometheusMetrics,
+			Summary:  "Server metrics in the Prometheus text format, guarded by FITNESS_METRICS_TOKEN when set",
+			Produces: "text/plain", Public: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/openapi.json", Handler: GetOpenAPI,
+			Summary: "OpenAPI document describing this API", Response: map[string]any{}, Public: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/docs", Handler: GetDocs,
+			Summary: "API documentation page", Public: true,
+		},
+	}
+}
 
 // Register API endpoints and their respective handlers
 func RegisterRoutes() {
-	// Register the workout data handler
-	http.HandleFunc("/workouts", HandleWorkoutData)
-
+	for _, rt := range apiRoutes() {
+		var handler http.Handler = rt.Handler
+		if rt.Cached {
+			handler = conditional(handler)
+		}
+		handler = validateRequest(rt, handler)
+		if !rt.Public {
+			handler = requireAuth(handler)
+		}
+		http.Handle(rt.Method+" "+rt.Path, instrument(rt, compress(handler)))
+	}
 }
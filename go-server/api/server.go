@@ -1,19 +1,132 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"fitness/auth"
+	"fitness/config"
 	"fitness/data"
+	"fitness/models"
+	"fitness/utils"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 )
 
-func StartServer() {
-	// Import data from the JSON file
-	data.ImportData()
+var (
+	// ready is set once the initial import has finished
+	ready atomic.Bool
+	// shuttingDown is closed when the server starts shutting down, ending event streams
+	shuttingDown = make(chan struct{})
+)
+
+// StartServer serves the API until SIGINT or SIGTERM, then drains in-flight
+// requests and stops the background imports before returning
+func StartServer() error {
+	logger, err := utils.NewLogger(os.Stderr, config.LogFormat, config.LogLevel)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+
+	// Load the user accounts
+	if err := auth.Users.Load(config.UsersFilePath); err != nil {
+		return fmt.Errorf("failed to load users: %v", err)
+	}
+	if config.JWTSecret == "" {
+		slog.Warn("FITNESS_JWT_SECRET is not set, sessions will not survive a restart")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Import in the background so liveness checks pass while the caches load
+	var imports sync.WaitGroup
+	imports.Add(1)
+	go func() {
+		defer imports.Done()
+		runImports(ctx)
+	}()
 
 	// Run the RESTful API Server
 	RegisterRoutes()
-	fmt.Println("Starting server on :8080")
-	if err := http.ListenAndServe(":8080", nil); err != nil {
-		fmt.Println("Error starting server:", err)
+	server := &http.Server{
+		Addr:         config.ServerAddr,
+		Handler:      NewHandler(http.DefaultServeMux),
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+		ReadTimeout:  config.ReadTimeout,
+		WriteTimeout: config.WriteTimeout,
+		IdleTimeout:  config.IdleTimeout,
+	}
+	server.RegisterOnShutdown(func() { close(shuttingDown) })
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("starting server", "addr", config.ServerAddr)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err = <-serveErr:
+		err = fmt.Errorf("error starting server: %v", err)
+	case <-ctx.Done():
+		slog.Info("shutting down, waiting for in-flight requests")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+		defer cancel()
+		if shutdownErr := server.Shutdown(shutdownCtx); shutdownErr != nil {
+			err = fmt.Errorf("error shutting down server: %v", shutdownErr)
+		}
+		if serveErr := <-serveErr; !errors.Is(serveErr, http.ErrServerClosed) && err == nil {
+			err = serveErr
+		}
+	}
+
+	stop()
+	imports.Wait()
+	return err
+}
+
+// runImports imports every user's data at startup and then every
+// config.ImportInterval until ctx is cancelled
+func runImports(ctx context.Context) {
+	data.ImportData(ctx, auth.Users.ListUsers())
+	ready.Store(true)
+	if config.ImportInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(config.ImportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			data.ImportData(ctx, auth.Users.ListUsers())
+		}
+	}
+}
+
+func GetHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, models.HealthStatus{Status: "ok"})
+}
+
+func GetReadiness(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-shuttingDown:
+		writeJSON(w, http.StatusServiceUnavailable, models.HealthStatus{Status: "shutting down"})
+		return
+	default:
+	}
+	if !ready.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, models.HealthStatus{Status: "importing"})
+		return
 	}
+	writeJSON(w, http.StatusOK, models.HealthStatus{Status: "ok"})
 }
@@ -0,0 +1,61 @@
+// api/sleep.go
+package api
+
+import (
+	"fitness/models"
+	"fitness/utils"
+	"net/http"
+	"strconv"
+)
+
+// Hours of sleep a night the debt is measured against by default
+const defaultSleepTarget = 8.0
+
+func GetSleepNights(w http.ResponseWriter, r *http.Request) {
+	nights, target, ok := requestSleepNights(w, r)
+	if !ok {
+		return
+	}
+	report := utils.CalculateSleepReport(nights, target)
+	respond(w, r, report, func() []table {
+		return []table{recordTable("sleep", report.Nights)}
+	})
+}
+
+func GetWeeklySleep(w http.ResponseWriter, r *http.Request) {
+	nights, _, ok := requestSleepNights(w, r)
+	if !ok {
+		return
+	}
+	weeks := utils.WeeklySleep(nights, requestCalendar(r))
+	respond(w, r, weeks, func() []table {
+		return []table{recordTable("sleep", weeks)}
+	})
+}
+
+// requestSleepNights parses the signed in user's nights with their sleep debt
+// against the target parameter and keeps those within the date filters. The
+// debt is computed over every night so it is complete from the first one kept.
+func requestSleepNights(w http.ResponseWriter, r *http.Request) ([]models.SleepNight, float64, bool) {
+	target := defaultSleepTarget
+	if value := r.URL.Query().Get("target"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 3 || parsed > 14 {
+			http.Error(w, "Target must be between 3 and 14 hours", http.StatusBadRequest)
+			return nil, 0, false
+		}
+		target = parsed
+	}
+
+	nights := utils.SleepNights(userStore(r).Metrics())
+	utils.CalculateSleepDebt(nights, target)
+	start := r.URL.Query().Get("start")
+	end := r.URL.Query().Get("end")
+	kept := nights[:0]
+	for _, night := range nights {
+		if (start == "" || night.Night >= start) && (end == "" || night.Night <= end) {
+			kept = append(kept, night)
+		}
+	}
+	return kept, target, true
+}
@@ -0,0 +1,39 @@
+// api/splits.go
+package api
+
+import (
+	"fitness/utils"
+	"net/http"
+)
+
+func GetWorkoutSplits(w http.ResponseWriter, r *http.Request) {
+	workout, ok := userStore(r).Workout(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "Workout not found", http.StatusNotFound)
+		return
+	}
+	system, ok := requestUnits(w, r)
+	if !ok {
+		return
+	}
+
+	splits := utils.CalculateSplits(workout, system)
+	respond(w, r, splits, func() []table {
+		return []table{recordTable("splits", splits.Splits)}
+	})
+}
+
+// requestUnits returns the unit system of the units parameter, falling back to
+// the one in the user's profile, writing an error response when it is unknown
+func requestUnits(w http.ResponseWriter, r *http.Request) (string, bool) {
+	name := r.URL.Query().Get("units")
+	if name == "" {
+		name = currentUser(r).Profile.Units
+	}
+	system, err := utils.ParseUnitSystem(name)
+	if err != nil {
+		http.Error(w, "Invalid unit system", http.StatusBadRequest)
+		return "", false
+	}
+	return system, true
+}
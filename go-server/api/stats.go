@@ -0,0 +1,232 @@
+// api/stats.go
+package api
+
+import (
+	"fitness/data"
+	"fitness/models"
+	"fitness/utils"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Stats endpoints aggregate the filtered workout data using the utils calculations
+
+func GetWorkoutsPerMonth(w http.ResponseWriter, r *http.Request) {
+	workoutData, ok := filterWorkoutData(w, r)
+	if !ok {
+		return
+	}
+
+	workoutsPerMonth := utils.CalculateWorkoutsPerMonth(workoutData, requestCalendar(r))
+	respond(w, r, workoutsPerMonth, func() []table {
+		return []table{seriesTable("workouts per month", "month", "workouts", workoutsPerMonth)}
+	})
+}
+
+func GetDistancePerWorkout(w http.ResponseWriter, r *http.Request) {
+	workoutData, ok := filterWorkoutData(w, r)
+	if !ok {
+		return
+	}
+
+	distancePerWorkout := utils.CalculateDistancePerWorkout(workoutData)
+	respond(w, r, distancePerWorkout, func() []table {
+		return []table{seriesTable("distance per workout", "workout", "distance", distancePerWorkout)}
+	})
+}
+
+func GetDistancePerWeek(w http.ResponseWriter, r *http.Request) {
+	workoutData, ok := filterWorkoutData(w, r)
+	if !ok {
+		return
+	}
+
+	distancePerWeek := utils.CalculateDistancePerWeek(workoutData, requestCalendar(r))
+	respond(w, r, distancePerWeek, func() []table {
+		return []table{seriesTable("distance per week", "week", "distance", distancePerWeek)}
+	})
+}
+
+func GetEnergyPerWeek(w http.ResponseWriter, r *http.Request) {
+	workoutData, ok := filterWorkoutData(w, r)
+	if !ok {
+		return
+	}
+
+	energyPerWeek := utils.CalculateEnergyPerWeek(workoutData, requestCalendar(r))
+	respond(w, r, energyPerWeek, func() []table {
+		return []table{seriesTable("energy per week", "week", "energy", energyPerWeek)}
+	})
+}
+
+func GetStreaks(w http.ResponseWriter, r *http.Request) {
+	workoutData, ok := filterWorkoutData(w, r)
+	if !ok {
+		return
+	}
+
+	// Rules default to any workout making a day active and no rest days
+	var rules utils.StreakRules
+	var err error
+	if minDuration := r.URL.Query().Get("minDuration"); minDuration != "" {
+		if rules.MinDuration, err = strconv.ParseFloat(minDuration, 64); err != nil {
+			http.Error(w, "Error parsing minimum duration", http.StatusBadRequest)
+			return
+		}
+		rules.MinDuration *= 60
+	}
+	if minEnergy := r.URL.Query().Get("minEnergy"); minEnergy != "" {
+		if rules.MinEnergy, err = strconv.ParseFloat(minEnergy, 64); err != nil {
+			http.Error(w, "Error parsing minimum energy", http.StatusBadRequest)
+			return
+		}
+	}
+	if restDays := r.URL.Query().Get("restDays"); restDays != "" {
+		if rules.RestDays, err = strconv.Atoi(restDays); err != nil {
+			http.Error(w, "Error parsing rest days", http.StatusBadRequest)
+			return
+		}
+	}
+	if weekDays := r.URL.Query().Get("weekDays"); weekDays != "" {
+		if rules.WeekDays, err = strconv.Atoi(weekDays); err != nil {
+			http.Error(w, "Error parsing active days per week", http.StatusBadRequest)
+			return
+		}
+	}
+
+	streaks := utils.CalculateStreaks(workoutData, requestCalendar(r), rules, time.Now())
+	respond(w, r, streaks, func() []table {
+		return []table{
+			recordTable("streaks", []streakRow{
+				newStreakRow("daily", "current", streaks.Daily.Current),
+				newStreakRow("daily", "longest", streaks.Daily.Longest),
+				newStreakRow("weekly", "current", streaks.Weekly.Current),
+				newStreakRow("weekly", "longest", streaks.Weekly.Longest),
+			}),
+			recordTable("weeks", streaks.Weeks),
+		}
+	})
+}
+
+// streakRow is a row of the exported streaks table
+type streakRow struct {
+	Kind   string `json:"kind"`
+	Streak string `json:"streak"`
+	Length int    `json:"length"`
+	Start  string `json:"start"`
+	End    string `json:"end"`
+}
+
+func newStreakRow(kind, name string, streak models.Streak) streakRow {
+	return streakRow{Kind: kind, Streak: name, Length: streak.Length, Start: streak.Start, End: streak.End}
+}
+
+func GetTrainingLoad(w http.ResponseWriter, r *http.Request) {
+	workoutData, ok := filterWorkoutData(w, r)
+	if !ok {
+		return
+	}
+
+	// Heart rates fall back to the user's full history, not just the filtered workouts
+	store := userStore(r)
+	heartRate := utils.NewHeartRateProfile(currentUser(r).Profile, store.Workouts(), store.Metrics())
+	trainingLoad := utils.CalculateTrainingLoad(workoutData, requestCalendar(r), heartRate, time.Now())
+	respond(w, r, trainingLoad, func() []table {
+		return []table{
+			recordTable("training load", trainingLoad.Days),
+			recordTable("workouts", trainingLoad.Workouts),
+		}
+	})
+}
+
+func GetTrends(w http.ResponseWriter, r *http.Request) {
+	workoutData, ok := filterWorkoutData(w, r)
+	if !ok {
+		return
+	}
+	system, ok := requestUnits(w, r)
+	if !ok {
+		return
+	}
+
+	series := r.URL.Query().Get("series")
+	if series == "" {
+		series = models.GoalDistance
+	}
+	period := utils.Week
+	if bucket := r.URL.Query().Get("bucket"); bucket != "" {
+		parsed, err := utils.ParsePeriod(bucket)
+		if err != nil {
+			http.Error(w, "Invalid bucket", http.StatusBadRequest)
+			return
+		}
+		period = parsed
+	}
+	horizon, window := 8, 4
+	if value := r.URL.Query().Get("horizon"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 || parsed > 104 {
+			http.Error(w, "Horizon must be between 0 and 104", http.StatusBadRequest)
+			return
+		}
+		horizon = parsed
+	}
+	if value := r.URL.Query().Get("window"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			http.Error(w, "Window must be a positive number of periods", http.StatusBadRequest)
+			return
+		}
+		window = parsed
+	}
+
+	metrics := filterMetricData(r)
+	units := "km"
+	if system == utils.Imperial {
+		units = "mi"
+	}
+	calendar := requestCalendar(r)
+	buckets := utils.TrendSeries(series, units, workoutData, metrics, calendar, period, time.Now())
+	trends := utils.CalculateTrends(buckets, calendar, period, window, horizon)
+	trends.Series = series
+	respond(w, r, trends, func() []table {
+		return []table{
+			recordTable("trend", trends.Points),
+			recordTable("forecast", trends.Forecast),
+		}
+	})
+}
+
+func GetEnergyBalance(w http.ResponseWriter, r *http.Request) {
+	window := 7
+	if value := r.URL.Query().Get("window"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 90 {
+			http.Error(w, "Window must be between 1 and 90 days", http.StatusBadRequest)
+			return
+		}
+		window = parsed
+	}
+
+	// The whole history is used so the rolling averages are full at the start
+	store := userStore(r)
+	balance := utils.CalculateEnergyBalance(store.Workouts(), store.Metrics(), window,
+		r.URL.Query().Get("start"), r.URL.Query().Get("end"))
+	respond(w, r, balance, func() []table {
+		return []table{recordTable("energy balance", balance.Days)}
+	})
+}
+
+// filterMetricData returns the signed in user's metrics within the date filters
+// of the request, which also limit metric series
+func filterMetricData(r *http.Request) []models.Metric {
+	metrics := userStore(r).Metrics()
+	if start := r.URL.Query().Get("start"); start != "" {
+		metrics, _ = data.FilterMetricDate(metrics, start, true)
+	}
+	if end := r.URL.Query().Get("end"); end != "" {
+		metrics, _ = data.FilterMetricDate(metrics, end, false)
+	}
+	return metrics
+}
@@ -0,0 +1,212 @@
+// api/validate.go
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fitness/config"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// maxBodyBytes limits the size of request bodies, leaving room for Health Auto Export pushes
+const maxBodyBytes = 64 << 20
+
+// validateRequest wraps the route's handler chain, rejecting requests whose
+// parameters or body do not match the route's OpenAPI description
+func validateRequest(rt route, next http.Handler) http.Handler {
+	params := routeParams(rt)
+	builder := &schemaBuilder{components: make(map[string]*schema)}
+	var body *schema
+	if rt.Body != nil {
+		body = builder.schemaFor(reflect.TypeOf(rt.Body))
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := validateParams(r, params); err != nil {
+			http.Error(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if body != nil {
+			content, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+			if err != nil {
+				http.Error(w, "Error reading request body", http.StatusBadRequest)
+				return
+			}
+			if rt.Optional && len(bytes.TrimSpace(content)) == 0 {
+				r.Body = io.NopCloser(bytes.NewReader(content))
+				next.ServeHTTP(w, r)
+				return
+			}
+			decoder := json.NewDecoder(bytes.NewReader(content))
+			decoder.UseNumber()
+			var value any
+			if err := decoder.Decode(&value); err != nil {
+				http.Error(w, "Request body is not valid JSON", http.StatusBadRequest)
+				return
+			}
+			// Partial updates may leave out required fields
+			checkRequired := r.Method != http.MethodPatch
+			if err := builder.validate(body, value, "body", checkRequired); err != nil {
+				http.Error(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(content))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validateParams checks the path, query and header parameters of a request
+func validateParams(r *http.Request, params []param) error {
+	query := r.URL.Query()
+
+	// Reject parameters the route does not declare, usually a typo by the client
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		declared := slices.ContainsFunc(params, func(p param) bool {
+			return p.In == "query" && p.Name == name
+		})
+		if !declared {
+			return fmt.Errorf("unknown query parameter %q", name)
+		}
+	}
+
+	for _, p := range params {
+		value := query.Get(p.Name)
+		switch p.In {
+		case "path":
+			value = r.PathValue(p.Name)
+		case "header":
+			value = r.Header.Get(p.Name)
+		}
+		if value == "" {
+			if p.Required {
+				return fmt.Errorf("missing required parameter %q", p.Name)
+			}
+			continue
+		}
+		if err := validateParam(p, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateParam checks a single parameter value against its declared type
+func validateParam(p param, value string) error {
+	var err error
+	switch p.Type {
+	case "number":
+		_, err = strconv.ParseFloat(value, 64)
+	case "integer":
+		_, err = strconv.Atoi(value)
+	case "boolean":
+		_, err = strconv.ParseBool(value)
+	}
+	if err == nil && p.Format == "date" {
+		_, err = time.Parse(config.DateFormat, value)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s parameter %q: expected %s", p.In, p.Name, describeParam(p))
+	}
+	if len(p.Enum) > 0 && !slices.Contains(p.Enum, value) {
+		return fmt.Errorf("invalid %s parameter %q: expected one of %v", p.In, p.Name, p.Enum)
+	}
+	return nil
+}
+
+// describeParam names the expected type of a parameter for error messages
+func describeParam(p param) string {
+	if p.Format != "" {
+		return p.Format
+	}
+	return p.Type
+}
+
+// validate checks a decoded JSON value against a schema
+func (b *schemaBuilder) validate(s *schema, value any, path string, checkRequired bool) error {
+	s = b.resolve(s)
+	if value == nil {
+		if s.Nullable || s.Type == "" {
+			return nil
+		}
+		return fmt.Errorf("%s must not be null", path)
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s must be an object", path)
+		}
+		if checkRequired {
+			for _, name := range s.Required {
+				if _, ok := object[name]; !ok {
+					return fmt.Errorf("%s.%s is required", path, name)
+				}
+			}
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			property := s.AdditionalProperties
+			if s.Properties != nil {
+				property = s.Properties[key]
+			}
+			if property == nil {
+				// Unknown fields are allowed, as in OpenAPI, and ignored by the handlers
+				continue
+			}
+			if err := b.validate(property, object[key], path+"."+key, checkRequired); err != nil {
+				return err
+			}
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s must be an array", path)
+		}
+		for i, item := range items {
+			if err := b.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i), checkRequired); err != nil {
+				return err
+			}
+		}
+	case "number", "integer":
+		number, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s must be a number", path)
+		}
+		if _, err := number.Int64(); s.Type == "integer" && err != nil {
+			return fmt.Errorf("%s must be an integer", path)
+		}
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a string", path)
+		}
+		if _, err := time.Parse(time.RFC3339, text); s.Format == "date-time" && err != nil {
+			return fmt.Errorf("%s must be an RFC 3339 timestamp", path)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s must be a boolean", path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+// api/xlsx.go
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Static parts of a minimal SpreadsheetML package
+const (
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	xlsxSheetType  = "application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"
+	xlsxMainNS     = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	xlsxMaxNameLen = 31
+)
+
+// writeXLSX writes the tables as an XLSX workbook with one sheet per table
+func writeXLSX(w io.Writer, tables []table) error {
+	if len(tables) == 0 {
+		tables = []table{{name: "data"}}
+	}
+	names := sheetNames(tables)
+
+	// Describe the package parts
+	var contentTypes, workbook, workbookRels strings.Builder
+	contentTypes.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	workbook.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="` + xlsxMainNS + `" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	workbookRels.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := range tables {
+		id := i + 1
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="%s"/>`, id, xlsxSheetType)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapeXML(names[i]), id, id)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, id, id)
+	}
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	workbookRels.WriteString(`</Relationships>`)
+
+	archive := zip.NewWriter(w)
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+	}
+	for _, part := range parts {
+		if err := writeZipPart(archive, part.name, part.content); err != nil {
+			return err
+		}
+	}
+
+	// Write each table as its own worksheet
+	for i, t := range tables {
+		part, err := archive.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1))
+		if err != nil {
+			return err
+		}
+		if err := writeSheet(part, t); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// writeZipPart adds a file with the given content to the archive
+func writeZipPart(archive *zip.Writer, name, content string) error {
+	part, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(part, content)
+	return err
+}
+
+// writeSheet writes the header and rows of a table as worksheet XML
+func writeSheet(w io.Writer, t table) error {
+	var sheet bytes.Buffer
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="` + xlsxMainNS + `"><sheetData>`)
+	writeRow(&sheet, 1, t.columns, false)
+	for i, row := range t.rows {
+		writeRow(&sheet, i+2, row, true)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+	_, err := sheet.WriteTo(w)
+	return err
+}
+
+// writeRow writes a worksheet row, storing numeric cells as numbers when allowed
+func writeRow(sheet *bytes.Buffer, number int, cells []string, numeric bool) {
+	fmt.Fprintf(sheet, `<row r="%d">`, number)
+	for i, cell := range cells {
+		ref := columnName(i) + strconv.Itoa(number)
+		if value, err := strconv.ParseFloat(cell, 64); numeric && err == nil && !math.IsInf(value, 0) && !math.IsNaN(value) {
+			fmt.Fprintf(sheet, `<c r="%s"><v>%s</v></c>`, ref, cell)
+		} else if cell != "" {
+			fmt.Fprintf(sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, escapeXML(cell))
+		}
+	}
+	sheet.WriteString(`</row>`)
+}
+
+// columnName converts a zero based column index into a spreadsheet column name such as "AB"
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// sheetNames returns valid, unique sheet names for the tables
+func sheetNames(tables []table) []string {
+	names := make([]string, len(tables))
+	used := make(map[string]bool)
+	for i, t := range tables {
+		name := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`[]:*?/\`, r) {
+				return '_'
+			}
+			return r
+		}, t.name)
+		if name == "" {
+			name = "Sheet"
+		}
+		base := []rune(name)
+		if len(base) > xlsxMaxNameLen {
+			base = base[:xlsxMaxNameLen]
+		}
+		name = string(base)
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			suffix := fmt.Sprintf(" (%d)", n)
+			trimmed := base
+			if len(trimmed)+len(suffix) > xlsxMaxNameLen {
+				trimmed = trimmed[:xlsxMaxNameLen-len(suffix)]
+			}
+			name = string(trimmed) + suffix
+		}
+		used[strings.ToLower(name)] = true
+		names[i] = name
+	}
+	return names
+}
+
+// escapeXML escapes text for use in XML content and attributes
+func escapeXML(text string) string {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(text))
+	return escaped.String()
+}
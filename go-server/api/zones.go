@@ -0,0 +1,87 @@
+// api/zones.go
+package api
+
+import (
+	"fitness/utils"
+	"net/http"
+)
+
+func GetWorkoutZones(w http.ResponseWriter, r *http.Request) {
+	store := userStore(r)
+	workout, ok := store.Workout(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "Workout not found", http.StatusNotFound)
+		return
+	}
+	model, ok := requestZoneModel(w, r)
+	if !ok {
+		return
+	}
+
+	heartRate := utils.NewHeartRateProfile(currentUser(r).Profile, store.Workouts(), store.Metrics())
+	zones := utils.CalculateWorkoutZones(workout, model, heartRate)
+	respond(w, r, zones, func() []table {
+		return []table{recordTable("zones", zones.Time)}
+	})
+}
+
+func GetZoneTrends(w http.ResponseWriter, r *http.Request) {
+	workoutData, ok := filterWorkoutData(w, r)
+	if !ok {
+		return
+	}
+	model, ok := requestZoneModel(w, r)
+	if !ok {
+		return
+	}
+	period := utils.Week
+	if bucket := r.URL.Query().Get("bucket"); bucket != "" {
+		parsed, err := utils.ParsePeriod(bucket)
+		if err != nil {
+			http.Error(w, "Invalid bucket", http.StatusBadRequest)
+			return
+		}
+		period = parsed
+	}
+
+	// Heart rates fall back to the user's full history, not just the filtered workouts
+	store := userStore(r)
+	heartRate := utils.NewHeartRateProfile(currentUser(r).Profile, store.Workouts(), store.Metrics())
+	trends := utils.CalculateZoneTrends(workoutData, requestCalendar(r), period, model, heartRate)
+	respond(w, r, trends, func() []table {
+		return []table{recordTable("zones", trends.Periods)}
+	})
+}
+
+func GetZoneDistribution(w http.ResponseWriter, r *http.Request) {
+	workoutData, ok := filterWorkoutData(w, r)
+	if !ok {
+		return
+	}
+	model, ok := requestZoneModel(w, r)
+	if !ok {
+		return
+	}
+
+	store := userStore(r)
+	heartRate := utils.NewHeartRateProfile(currentUser(r).Profile, store.Workouts(), store.Metrics())
+	distribution := utils.CalculateZoneDistribution(workoutData, model, heartRate)
+	respond(w, r, distribution, func() []table {
+		return []table{recordTable("zones", distribution.Time)}
+	})
+}
+
+// requestZoneModel returns the zone model of the model parameter, falling back
+// to the one in the user's profile, writing an error response when it is unknown
+func requestZoneModel(w http.ResponseWriter, r *http.Request) (string, bool) {
+	name := r.URL.Query().Get("model")
+	if name == "" {
+		name = currentUser(r).Profile.ZoneModel
+	}
+	model, err := utils.ParseZoneModel(name)
+	if err != nil {
+		http.Error(w, "Invalid zone model", http.StatusBadRequest)
+		return "", false
+	}
+	return model, true
+}
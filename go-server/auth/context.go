@@ -0,0 +1,21 @@
+// auth/context.go
+package auth
+
+import (
+	"context"
+	"fitness/models"
+)
+
+// contextKey is the type of the request context key holding the signed in user
+type contextKey struct{}
+
+// WithUser returns a copy of the context carrying the signed in user
+func WithUser(ctx context.Context, user models.User) context.Context {
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+// UserFrom returns the signed in user carried by the context
+func UserFrom(ctx context.Context) (models.User, bool) {
+	user, ok := ctx.Value(contextKey{}).(models.User)
+	return user, ok
+}
@@ -0,0 +1,71 @@
+// auth/jwt.go
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// jwtHeader is the encoded header of every access token, HS256 signed
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// claims are the JWT claims of an access token
+type claims struct {
+	Subject   string `json:"sub"` // ID of the user
+	SessionID string `json:"sid"` // Session the token belongs to, revoked on logout
+	IssuedAt  int64  `json:"iat"` // Unix time the token was issued
+	ExpiresAt int64  `json:"exp"` // Unix time the token expires
+}
+
+// Errors returned when an access token is rejected
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token expired")
+)
+
+// signJWT encodes and signs the claims as a compact JWT
+func signJWT(c claims, secret []byte) (string, error) {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + jwtSignature(unsigned, secret), nil
+}
+
+// parseJWT verifies the signature and expiry of a token and returns its claims
+func parseJWT(token string, secret []byte, now time.Time) (claims, error) {
+	var c claims
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return c, ErrInvalidToken
+	}
+	expected := jwtSignature(parts[0]+"."+parts[1], secret)
+	if !hmac.Equal([]byte(parts[2]), []byte(expected)) {
+		return c, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return c, ErrInvalidToken
+	}
+	if err := json.Unmarshal(payload, &c); err != nil || c.Subject == "" {
+		return c, ErrInvalidToken
+	}
+	if now.Unix() >= c.ExpiresAt {
+		return c, ErrExpiredToken
+	}
+	return c, nil
+}
+
+// jwtSignature computes the HS256 signature of the encoded header and payload
+func jwtSignature(unsigned string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,34 @@
+// auth/password.go
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// passwordCost is the bcrypt work factor of new password hashes
+const passwordCost = 12
+
+// HashPassword hashes a password with bcrypt, which salts it and encodes the
+// cost in the hash
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
+	if err != nil {
+		return "", fmt.Errorf("error hashing password: %v", err)
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether the password matches the encoded hash
+func CheckPassword(encoded, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("invalid password hash: %v", err)
+	}
+	return true, nil
+}
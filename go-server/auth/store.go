@@ -0,0 +1,487 @@
+// auth/store.go
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fitness/config"
+	"fitness/models"
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APITokenPrefix starts every personal API token, telling them apart from JWTs
+const APITokenPrefix = "fit_"
+
+// Lengths of the passwords accepted at signup, bcrypt only uses the first 72 bytes
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72
+)
+
+// Errors returned by the store
+var (
+	ErrEmailTaken         = errors.New("an account with this email already exists")
+	ErrInvalidEmail       = errors.New("invalid email address")
+	ErrWeakPassword       = fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	ErrLongPassword       = fmt.Errorf("password must be at most %d bytes", maxPasswordLength)
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrSessionExpired     = errors.New("session expired or revoked")
+	ErrNotFound           = errors.New("not found")
+)
+
+// storedUser is a user as persisted, including the password hash
+type storedUser struct {
+	models.User
+	PasswordHash string `json:"passwordHash"`
+}
+
+// session is a signed in device, identified by its refresh token
+type session struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"userId"`
+	RefreshHash string    `json:"refreshHash"` // SHA-256 of the current refresh token
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+// apiToken is a personal API token as persisted
+type apiToken struct {
+	models.APIToken
+	UserID    string `json:"userId"`
+	TokenHash string `json:"tokenHash"` // SHA-256 of the secret token
+}
+
+// storeFile is the layout of the users file
+type storeFile struct {
+	Users     []storedUser `json:"users"`
+	Sessions  []session    `json:"sessions"`
+	APITokens []apiToken   `json:"apiTokens"`
+}
+
+// Store keeps accounts, sessions and personal API tokens, persisted to a JSON file
+type Store struct {
+	mu       sync.RWMutex
+	path     string // File the store is persisted to, in memory only when empty
+	secret   []byte // HMAC key for access tokens
+	users    map[string]*storedUser
+	sessions map[string]*session
+	tokens   map[string]*apiToken
+}
+
+// Users is the store used by the server
+var Users = NewStore("")
+
+// NewStore creates an empty store persisted to path, or kept in memory when path is empty
+func NewStore(path string) *Store {
+	secret := []byte(config.JWTSecret)
+	if len(secret) == 0 {
+		// Without a configured secret access tokens only survive until the next restart
+		secret = make([]byte, 32)
+		rand.Read(secret)
+	}
+	return &Store{
+		path:     path,
+		secret:   secret,
+		users:    make(map[string]*storedUser),
+		sessions: make(map[string]*session),
+		tokens:   make(map[string]*apiToken),
+	}
+}
+
+// Load reads the store from path, which is then used for persistence. A missing
+// file leaves the store empty.
+func (s *Store) Load(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.path = path
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var file storeFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return fmt.Errorf("error parsing users file: %v", err)
+	}
+	for i := range file.Users {
+		s.users[file.Users[i].ID] = &file.Users[i]
+	}
+	for i := range file.Sessions {
+		s.sessions[file.Sessions[i].ID] = &file.Sessions[i]
+	}
+	for i := range file.APITokens {
+		s.tokens[file.APITokens[i].ID] = &file.APITokens[i]
+	}
+	return nil
+}
+
+// save persists the store, the caller must hold the write lock
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+	var file storeFile
+	for _, user := range s.users {
+		file.Users = append(file.Users, *user)
+	}
+	now := time.Now()
+	for _, sess := range s.sessions {
+		if sess.ExpiresAt.After(now) {
+			file.Sessions = append(file.Sessions, *sess)
+		}
+	}
+	for _, token := range s.tokens {
+		file.APITokens = append(file.APITokens, *token)
+	}
+	sort.Slice(file.Users, func(i, j int) bool { return file.Users[i].ID < file.Users[j].ID })
+	sort.Slice(file.Sessions, func(i, j int) bool { return file.Sessions[i].ID < file.Sessions[j].ID })
+	sort.Slice(file.APITokens, func(i, j int) bool { return file.APITokens[i].ID < file.APITokens[j].ID })
+
+	content, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling users: %v", err)
+	}
+	// Write to a temporary file first so a crash never leaves a truncated file
+	temp := s.path + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("error creating users directory: %v", err)
+	}
+	if err := os.WriteFile(temp, content, 0600); err != nil {
+		return fmt.Errorf("error writing users file: %v", err)
+	}
+	return os.Rename(temp, s.path)
+}
+
+// Register creates an account
+func (s *Store) Register(signup models.Signup) (models.User, error) {
+	email, err := normalizeEmail(signup.Email)
+	if err != nil {
+		return models.User{}, err
+	}
+	if len(signup.Password) < minPasswordLength {
+		return models.User{}, ErrWeakPassword
+	}
+	if len(signup.Password) > maxPasswordLength {
+		return models.User{}, ErrLongPassword
+	}
+	hash, err := HashPassword(signup.Password)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.findByEmail(email); ok {
+		return models.User{}, ErrEmailTaken
+	}
+	user := &storedUser{
+		User: models.User{
+			ID:        randomID(),
+			Email:     email,
+			CreatedAt: time.Now().UTC(),
+			Profile:   models.Profile{Name: strings.TrimSpace(signup.Name)},
+		},
+		PasswordHash: hash,
+	}
+	s.users[user.ID] = user
+	return user.User, s.save()
+}
+
+// Authenticate checks an email and password and returns the matching user
+func (s *Store) Authenticate(credentials models.Credentials) (models.User, error) {
+	email, err := normalizeEmail(credentials.Email)
+	if err != nil {
+		return models.User{}, ErrInvalidCredentials
+	}
+	s.mu.RLock()
+	user, ok := s.findByEmail(email)
+	s.mu.RUnlock()
+	if !ok {
+		// Hash anyway so response times do not reveal which emails exist
+		HashPassword(credentials.Password)
+		return models.User{}, ErrInvalidCredentials
+	}
+	if match, err := CheckPassword(user.PasswordHash, credentials.Password); err != nil || !match {
+		return models.User{}, ErrInvalidCredentials
+	}
+	return user.User, nil
+}
+
+// User returns the user with the given ID
+func (s *Store) User(id string) (models.User, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	user, ok := s.users[id]
+	if !ok {
+		return models.User{}, false
+	}
+	return user.User, true
+}
+
+// UserByEmail returns the user registered with the email address
+func (s *Store) UserByEmail(email string) (models.User, bool) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return models.User{}, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	user, ok := s.findByEmail(email)
+	if !ok {
+		return models.User{}, false
+	}
+	return user.User, true
+}
+
+// ListUsers returns every registered user ordered by ID
+func (s *Store) ListUsers() []models.User {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	users := make([]models.User, 0, len(s.users))
+	for _, user := range s.users {
+		users = append(users, user.User)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users
+}
+
+// UpdateProfile applies update to a copy of the user's profile, storing it
+// unless update fails
+func (s *Store) UpdateProfile(id string, update func(*models.Profile) error) (models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[id]
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+	profile := user.Profile
+	if err := update(&profile); err != nil {
+		return models.User{}, err
+	}
+	user.Profile = profile
+	return user.User, s.save()
+}
+
+// StartSession signs the user in, issuing an access token and a refresh token
+func (s *Store) StartSession(user models.User) (models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess := &session{ID: randomID(), UserID: user.ID}
+	s.sessions[sess.ID] = sess
+	return s.issue(sess, user)
+}
+
+// Refresh exchanges a refresh token for a new session token pair. Refresh
+// tokens are single use, the old one stops working.
+func (s *Store) Refresh(refreshToken string) (models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.findSession(refreshToken)
+	if !ok {
+		return models.Session{}, ErrSessionExpired
+	}
+	user, ok := s.users[sess.UserID]
+	if !ok {
+		delete(s.sessions, sess.ID)
+		return models.Session{}, ErrSessionExpired
+	}
+	return s.issue(sess, user.User)
+}
+
+// EndSession revokes the session of a refresh token, signing the device out
+func (s *Store) EndSession(refreshToken string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.findSession(refreshToken)
+	if !ok {
+		return nil
+	}
+	delete(s.sessions, sess.ID)
+	return s.save()
+}
+
+// issue rotates the refresh token of a session and signs a new access token,
+// the caller must hold the write lock
+func (s *Store) issue(sess *session, user models.User) (models.Session, error) {
+	now := time.Now()
+	refreshToken := randomToken()
+	sess.RefreshHash = tokenHash(refreshToken)
+	sess.ExpiresAt = now.Add(config.RefreshTokenTTL).UTC()
+
+	accessToken, err := signJWT(claims{
+		Subject:   user.ID,
+		SessionID: sess.ID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(config.AccessTokenTTL).Unix(),
+	}, s.secret)
+	if err != nil {
+		return models.Session{}, err
+	}
+	if err := s.save(); err != nil {
+		return models.Session{}, err
+	}
+	return models.Session{
+		TokenType:    "Bearer",
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(config.AccessTokenTTL.Seconds()),
+		User:         user,
+	}, nil
+}
+
+// Verify resolves an access token or personal API token to its user
+func (s *Store) Verify(token string) (models.User, error) {
+	if strings.HasPrefix(token, APITokenPrefix) {
+		return s.verifyAPIToken(token)
+	}
+
+	c, err := parseJWT(token, s.secret, time.Now())
+	if err != nil {
+		return models.User{}, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	// Tokens of signed out sessions stop working right away
+	if sess, ok := s.sessions[c.SessionID]; !ok || sess.UserID != c.Subject {
+		return models.User{}, ErrSessionExpired
+	}
+	user, ok := s.users[c.Subject]
+	if !ok {
+		return models.User{}, ErrInvalidToken
+	}
+	return user.User, nil
+}
+
+// CreateAPIToken creates a personal API token. The secret is only returned here.
+func (s *Store) CreateAPIToken(userID, name string) (models.APIToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return models.APIToken{}, errors.New("token name is required")
+	}
+	secret := APITokenPrefix + randomToken()
+	token := &apiToken{
+		APIToken:  models.APIToken{ID: randomID(), Name: name, CreatedAt: time.Now().UTC()},
+		UserID:    userID,
+		TokenHash: tokenHash(secret),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[token.ID] = token
+	if err := s.save(); err != nil {
+		return models.APIToken{}, err
+	}
+	created := token.APIToken
+	created.Token = secret
+	return created, nil
+}
+
+// ListAPITokens returns the personal API tokens of a user, oldest first
+func (s *Store) ListAPITokens(userID string) []models.APIToken {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tokens := []models.APIToken{}
+	for _, token := range s.tokens {
+		if token.UserID == userID {
+			tokens = append(tokens, token.APIToken)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].CreatedAt.Before(tokens[j].CreatedAt) })
+	return tokens
+}
+
+// DeleteAPIToken revokes a personal API token of a user
+func (s *Store) DeleteAPIToken(userID, tokenID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[tokenID]
+	if !ok || token.UserID != userID {
+		return ErrNotFound
+	}
+	delete(s.tokens, tokenID)
+	return s.save()
+}
+
+// verifyAPIToken resolves a personal API token to its user
+func (s *Store) verifyAPIToken(secret string) (models.User, error) {
+	hash := tokenHash(secret)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, token := range s.tokens {
+		if token.TokenHash != hash {
+			continue
+		}
+		user, ok := s.users[token.UserID]
+		if !ok {
+			return models.User{}, ErrInvalidToken
+		}
+		// Usage is tracked in memory and persisted with the next change
+		now := time.Now().UTC()
+		token.LastUsed = &now
+		return user.User, nil
+	}
+	return models.User{}, ErrInvalidToken
+}
+
+// findByEmail looks up a user by normalized email, the caller must hold the lock
+func (s *Store) findByEmail(email string) (*storedUser, bool) {
+	for _, user := range s.users {
+		if user.Email == email {
+			return user, true
+		}
+	}
+	return nil, false
+}
+
+// findSession looks up the unexpired session of a refresh token, the caller must hold the lock
+func (s *Store) findSession(refreshToken string) (*session, bool) {
+	hash := tokenHash(refreshToken)
+	for _, sess := range s.sessions {
+		if sess.RefreshHash == hash && sess.ExpiresAt.After(time.Now()) {
+			return sess, true
+		}
+	}
+	return nil, false
+}
+
+// normalizeEmail validates an email address and lower-cases it
+func normalizeEmail(email string) (string, error) {
+	address, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || address.Name != "" {
+		return "", ErrInvalidEmail
+	}
+	return strings.ToLower(address.Address), nil
+}
+
+// randomID returns a random 128-bit identifier
+func randomID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// randomToken returns a random 256-bit secret
+func randomToken() string {
+	token := make([]byte, 32)
+	rand.Read(token)
+	return base64.RawURLEncoding.EncodeToString(token)
+}
+
+// tokenHash hashes a secret token for storage
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,16 +1,75 @@
 // config/constants.go
 package config
 
+import "time"
+
 // Constants used throughout the application
 const (
 	DateFormat       = "2006-01-02"
 	TimeFormat       = "2006-01-02 15:04:05 -0700"
-	ICloudDirPath    = "/Users/saavedj/Library/Mobile Documents/iCloud~com~ifunography~HealthExport/Documents/Go Application"
-	CacheFilePath    = "/Users/saavedj/Projects/apple-fitness-health-app/go-server/data/cache.json"
 	DateRegexPattern = `\d{4}-\d{2}-\d{2}`
 )
 
-// var (
-// 	ICloudDirPath = ""
-// 	CacheFilePath = ""
-// )
+// Calendar settings used to bucket data into days, weeks and months
+var (
+	Timezone  = getEnv("FITNESS_TIMEZONE", "Local")    // IANA timezone name used for day boundaries
+	WeekStart = getEnv("FITNESS_WEEK_START", "monday") // First day of the week
+)
+
+// Authentication settings
+var (
+	UsersFilePath   = getEnv("FITNESS_USERS_FILE", "/Users/saavedj/Projects/apple-fitness-health-app/go-server/data/users.json")
+	JWTSecret       = getEnv("FITNESS_JWT_SECRET", "")                     // HMAC key for access tokens, random per run when empty
+	AllowSignup     = getEnvBool("FITNESS_ALLOW_SIGNUP", OwnerEmail == "") // Whether new accounts can register, off by default when an owner is set
+	AccessTokenTTL  = getEnvDuration("FITNESS_ACCESS_TOKEN_TTL", 15*time.Minute)
+	RefreshTokenTTL = getEnvDuration("FITNESS_REFRESH_TOKEN_TTL", 30*24*time.Hour)
+)
+
+// Storage settings. Every user's cache and edit history live in DataDir/users/<id>.
+// ICloudDirPath and CacheFilePath are the import directory and cache of the
+// single-user setup, which now belong to the account with the OwnerEmail address.
+// That account is created with the adduser command, signup stays closed until it exists.
+var (
+	DataDir       = getEnv("FITNESS_DATA_DIR", "/Users/saavedj/Projects/apple-fitness-health-app/go-server/data")
+	ICloudDirPath = getEnv("ICLOUD_DIR_PATH", "/Users/saavedj/Library/Mobile Documents/iCloud~com~ifunography~HealthExport/Documents/Go Application")
+	CacheFilePath = getEnv("CACHE_FILE_PATH", "/Users/saavedj/Projects/apple-fitness-health-app/go-server/data/cache.json")
+	OwnerEmail    = getEnv("FITNESS_OWNER_EMAIL", "")
+	ImportDirs    = parseImportDirs(getEnv("FITNESS_IMPORT_DIRS", "")) // Health Auto Export directory per user email
+)
+
+// Event stream settings
+var (
+	EventBufferSize = getEnvInt("FITNESS_EVENT_BUFFER", 256)                    // Events kept per user for Last-Event-ID resume
+	EventHeartbeat  = getEnvDuration("FITNESS_EVENT_HEARTBEAT", 15*time.Second) // Interval of keep-alive comments
+)
+
+// Server settings
+var (
+	ServerAddr      = getEnv("FITNESS_ADDR", ":8080")
+	ReadTimeout     = getEnvDuration("FITNESS_READ_TIMEOUT", 15*time.Second)
+	WriteTimeout    = getEnvDuration("FITNESS_WRITE_TIMEOUT", 60*time.Second) // Lifted for the event stream
+	IdleTimeout     = getEnvDuration("FITNESS_IDLE_TIMEOUT", 120*time.Second)
+	ShutdownTimeout = getEnvDuration("FITNESS_SHUTDOWN_TIMEOUT", 30*time.Second) // Time given to in-flight requests on shutdown
+	ImportInterval  = getEnvDuration("FITNESS_IMPORT_INTERVAL", 15*time.Minute)  // Interval between imports, zero imports only at startup
+)
+
+// Logging and cross-origin settings
+var (
+	LogFormat   = getEnv("FITNESS_LOG_FORMAT", "text")                                  // Either "text" or "json"
+	LogLevel    = getEnv("FITNESS_LOG_LEVEL", "info")                                   // One of debug, info, warn or error
+	CORSOrigins = getEnvList("FITNESS_CORS_ORIGINS", []string{"http://localhost:3000"}) // Origins of the Next.js client
+)
+
+// Prometheus settings
+var (
+	MetricsPath  = getEnv("FITNESS_METRICS_PATH", "/metrics/prometheus")
+	MetricsToken = getEnv("FITNESS_METRICS_TOKEN", "") // Bearer token required to scrape, open when empty
+)
+
+// Anomaly detection settings. AnomalySensitivity overrides the threshold per
+// metric name with "name=threshold" pairs, such as "resting_heart_rate=2.5".
+var (
+	AnomalyWindow      = getEnvInt("FITNESS_ANOMALY_WINDOW", 28)       // Days of data in the rolling baseline
+	AnomalyThreshold   = getEnvFloat("FITNESS_ANOMALY_THRESHOLD", 3.5) // Deviations from the baseline that flag a day
+	AnomalySensitivity = parseThresholds(getEnv("FITNESS_ANOMALY_SENSITIVITY", "resting_heart_rate=2.5,heart_rate_variability=2.5"))
+)
@@ -0,0 +1,88 @@
+// config/env.go
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// getEnv returns the environment variable value or the fallback when unset
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getEnvBool returns the environment variable parsed as a bool or the fallback
+func getEnvBool(key string, fallback bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return fallback
+}
+
+// getEnvInt returns the environment variable parsed as an int or the fallback
+func getEnvInt(key string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return fallback
+}
+
+// getEnvFloat returns the environment variable parsed as a float or the fallback
+func getEnvFloat(key string, fallback float64) float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return value
+	}
+	return fallback
+}
+
+// getEnvDuration returns the environment variable parsed as a duration or the fallback
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return value
+	}
+	return fallback
+}
+
+// getEnvList returns the comma separated values of the environment variable or the fallback
+func getEnvList(key string, fallback []string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return fallback
+	}
+	return values
+}
+
+// parseImportDirs parses "email=directory" pairs separated by semicolons
+func parseImportDirs(value string) map[string]string {
+	dirs := make(map[string]string)
+	for _, pair := range strings.Split(value, ";") {
+		email, dir, ok := strings.Cut(pair, "=")
+		if ok && strings.TrimSpace(email) != "" && strings.TrimSpace(dir) != "" {
+			dirs[strings.ToLower(strings.TrimSpace(email))] = strings.TrimSpace(dir)
+		}
+	}
+	return dirs
+}
+
+// parseThresholds parses "name=number" pairs separated by commas
+func parseThresholds(value string) map[string]float64 {
+	thresholds := make(map[string]float64)
+	for _, pair := range strings.Split(value, ",") {
+		name, number, ok := strings.Cut(pair, "=")
+		threshold, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if ok && err == nil && threshold > 0 && strings.TrimSpace(name) != "" {
+			thresholds[strings.ToLower(strings.TrimSpace(name))] = threshold
+		}
+	}
+	return thresholds
+}
@@ -0,0 +1,95 @@
+// data/anomalies.go
+// Anomalies found in the metrics of a user, rescanned from the changed days on every import
+
+package data
+
+import (
+	"fitness/config"
+	"fitness/models"
+	"fitness/utils"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// Days of data a metric needs before its days can be flagged
+const anomalyMinHistory = 7
+
+// anomalyRules returns the detection rules of a metric, with its threshold
+// taken from the sensitivity setting when it has one
+func anomalyRules(name string) utils.AnomalyRules {
+	threshold := config.AnomalyThreshold
+	if sensitivity, ok := config.AnomalySensitivity[strings.ToLower(name)]; ok {
+		threshold = sensitivity
+	}
+	return utils.AnomalyRules{Window: config.AnomalyWindow, MinHistory: anomalyMinHistory, Threshold: threshold}
+}
+
+// Anomalies returns the anomalies found in the user's metrics, ordered by date and metric
+func (s *Store) Anomalies() []models.Anomaly {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	anomalies := []models.Anomaly{}
+	for _, found := range s.anomalies {
+		anomalies = append(anomalies, found...)
+	}
+	sort.Slice(anomalies, func(i, j int) bool {
+		if anomalies[i].Date != anomalies[j].Date {
+			return anomalies[i].Date < anomalies[j].Date
+		}
+		return anomalies[i].Metric < anomalies[j].Metric
+	})
+	return anomalies
+}
+
+// scanAnomalies rescans each metric from the day given for it, an empty day
+// rescanning the whole metric, and returns the anomalies not found before.
+// The caller must hold the write lock.
+func (s *Store) scanAnomalies(from map[string]string) []models.Anomaly {
+	if s.anomalies == nil {
+		s.anomalies = make(map[string][]models.Anomaly)
+	}
+	var found []models.Anomaly
+	for _, metric := range s.metrics {
+		day, ok := from[metric.Name]
+		if !ok {
+			continue
+		}
+		known := make(map[string]bool)
+		var kept []models.Anomaly
+		for _, anomaly := range s.anomalies[metric.Name] {
+			known[anomaly.Date] = true
+			if anomaly.Date < day {
+				kept = append(kept, anomaly)
+			}
+		}
+		detected := utils.DetectAnomalies(metric.Name, utils.DailyMetricValues(metric), anomalyRules(metric.Name), day)
+		for _, anomaly := range detected {
+			if !known[anomaly.Date] {
+				found = append(found, anomaly)
+			}
+		}
+		s.anomalies[metric.Name] = append(kept, detected...)
+	}
+	return found
+}
+
+// publishAnomalies scans the metrics changed by an import and flags the new
+// anomalies, the caller must hold the write lock
+func (s *Store) publishAnomalies(source string, metrics map[string]*dateRange) {
+	from := make(map[string]string, len(metrics))
+	for name, days := range metrics {
+		from[name] = days.from
+	}
+	found := s.scanAnomalies(from)
+	if len(found) == 0 {
+		return
+	}
+	var days dateRange
+	for _, anomaly := range found {
+		slog.Info("anomaly detected", "user", s.userID, "metric", anomaly.Metric, "date", anomaly.Date,
+			"value", anomaly.Value, "baseline", anomaly.Baseline, "severity", anomaly.Severity)
+		days.include(anomaly.Date)
+	}
+	s.publish(models.Event{Type: models.EventAnomalies, Source: source, From: days.from, To: days.to, Anomalies: len(found)})
+}
@@ -0,0 +1,153 @@
+// data/edits.go
+// Edits made to workouts through the API, kept per user
+
+package data
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fitness/models"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrWorkoutNotFound is returned when a workout does not exist in the user's store
+var ErrWorkoutNotFound = errors.New("workout not found")
+
+// historyPath returns the path of the user's edit history file
+func (s *Store) historyPath() string {
+	return filepath.Join(s.dir, "history.json")
+}
+
+// Workout returns the workout with the given ID
+func (s *Store) Workout(id string) (models.Workout, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, workout := range s.workouts {
+		if workout.ID == id {
+			return workout, true
+		}
+	}
+	return models.Workout{}, false
+}
+
+// UpdateWorkout applies update to the workout with the given ID and records the edit.
+// The workout keeps its ID whatever update does.
+func (s *Store) UpdateWorkout(id string, update func(*models.Workout) error) (models.Workout, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.workouts {
+		if s.workouts[i].ID != id {
+			continue
+		}
+		before := s.workouts[i]
+		// update works on a deep copy so the recorded snapshot and the copies
+		// held by readers never see its writes through shared pointers
+		after, err := cloneWorkout(before)
+		if err != nil {
+			return models.Workout{}, err
+		}
+		if err := update(&after); err != nil {
+			return models.Workout{}, err
+		}
+		after.ID = id
+		s.workouts[i] = after
+		return after, s.recordEdit("update", id, &before, &after)
+	}
+	return models.Workout{}, ErrWorkoutNotFound
+}
+
+// cloneWorkout returns a copy of the workout sharing no pointers or slices with it
+func cloneWorkout(workout models.Workout) (models.Workout, error) {
+	encoded, err := json.Marshal(workout)
+	if err != nil {
+		return models.Workout{}, fmt.Errorf("error copying workout: %v", err)
+	}
+	var clone models.Workout
+	if err := json.Unmarshal(encoded, &clone); err != nil {
+		return models.Workout{}, fmt.Errorf("error copying workout: %v", err)
+	}
+	return clone, nil
+}
+
+// DeleteWorkout removes the workout with the given ID and records the edit
+func (s *Store) DeleteWorkout(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.workouts {
+		if s.workouts[i].ID != id {
+			continue
+		}
+		before := s.workouts[i]
+		s.workouts = append(s.workouts[:i], s.workouts[i+1:]...)
+		return s.recordEdit("delete", id, &before, nil)
+	}
+	return ErrWorkoutNotFound
+}
+
+// History returns the user's workout edits, oldest first
+func (s *Store) History() []models.WorkoutEdit {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]models.WorkoutEdit{}, s.history...)
+}
+
+// recordEdit appends an edit to the history and persists the data, the caller
+// must hold the write lock
+func (s *Store) recordEdit(action, workoutID string, before, after *models.Workout) error {
+	s.history = append(s.history, models.WorkoutEdit{
+		ID:        newID(),
+		WorkoutID: workoutID,
+		Action:    action,
+		Time:      time.Now().UTC(),
+		Before:    before,
+		After:     after,
+	})
+	s.markModified(time.Now())
+
+	event := models.Event{Type: models.EventWorkoutsUpdated, Source: "edit", WorkoutIDs: []string{workoutID}}
+	if after == nil {
+		event.Type = models.EventWorkoutsDeleted
+	}
+	var days dateRange
+	days.include(before.Start)
+	if after != nil {
+		days.include(after.Start)
+	}
+	event.From, event.To = days.from, days.to
+	s.publish(event)
+
+	content, err := json.MarshalIndent(s.history, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFile(s.historyPath(), content); err != nil {
+		return err
+	}
+	return s.writeCache()
+}
+
+// loadHistory reads the user's edit history file
+func (s *Store) loadHistory() error {
+	content, err := os.ReadFile(s.historyPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return json.Unmarshal(content, &s.history)
+}
+
+// newID returns a random identifier for edits and goals
+func newID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,224 @@
+// data/events.go
+// Change events published by the stores, buffered for clients that reconnect
+
+package data
+
+import (
+	"context"
+	"fitness/config"
+	"fitness/models"
+	"fitness/utils"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// subscriberQueue is the number of events queued for a client before it is
+// dropped, it then reconnects and resumes from the buffer
+const subscriberQueue = 64
+
+// eventLog keeps the recent events of a store and the channels of its subscribers
+type eventLog struct {
+	mu          sync.Mutex
+	last        uint64 // ID of the latest event
+	buffer      []models.Event
+	subscribers map[chan models.Event]struct{}
+}
+
+// changes collects what merges altered until the events are published
+type changes struct {
+	added, updated []string
+	addedRange     dateRange
+	updatedRange   dateRange
+	metrics        map[string]*dateRange
+	workouts       int
+	metricPoints   int
+}
+
+// dateRange is the span of days touched by a change
+type dateRange struct {
+	from, to string
+}
+
+// include extends the range to the day of the timestamp
+func (d *dateRange) include(timestamp string) {
+	day := timestamp
+	if len(day) > len(config.DateFormat) {
+		day = day[:len(config.DateFormat)]
+	}
+	if day == "" {
+		return
+	}
+	if d.from == "" || day < d.from {
+		d.from = day
+	}
+	if day > d.to {
+		d.to = day
+	}
+}
+
+// Subscribe streams the events of the store. When resume is set, the events
+// published after lastEventID are returned first, or a single resync event when
+// they are no longer buffered. cancel must be called once the client is gone.
+func (s *Store) Subscribe(lastEventID uint64, resume bool) ([]models.Event, <-chan models.Event, func()) {
+	version, _ := s.Version()
+
+	log := &s.events
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	var missed []models.Event
+	if resume && lastEventID != log.last {
+		oldest := log.last + 1
+		if len(log.buffer) > 0 {
+			oldest = log.buffer[0].ID
+		}
+		if lastEventID+1 < oldest || lastEventID > log.last {
+			missed = []models.Event{{ID: log.last, Type: models.EventResync, Version: version, Time: time.Now().UTC()}}
+		} else {
+			for _, event := range log.buffer {
+				if event.ID > lastEventID {
+					missed = append(missed, event)
+				}
+			}
+		}
+	}
+
+	events := make(chan models.Event, subscriberQueue)
+	if log.subscribers == nil {
+		log.subscribers = make(map[chan models.Event]struct{})
+	}
+	log.subscribers[events] = struct{}{}
+
+	cancel := func() {
+		log.mu.Lock()
+		defer log.mu.Unlock()
+		if _, ok := log.subscribers[events]; ok {
+			delete(log.subscribers, events)
+			close(events)
+		}
+	}
+	return missed, events, cancel
+}
+
+// publish sends an event to the subscribers and buffers it, the caller must hold the lock
+func (s *Store) publish(event models.Event) {
+	event.Version = s.version
+	event.Time = time.Now().UTC()
+
+	log := &s.events
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	log.last++
+	event.ID = log.last
+	log.buffer = append(log.buffer, event)
+	if overflow := len(log.buffer) - config.EventBufferSize; overflow > 0 {
+		log.buffer = append([]models.Event(nil), log.buffer[overflow:]...)
+	}
+
+	for subscriber := range log.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+			// A client that stopped reading resumes from the buffer when it reconnects
+			delete(log.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+}
+
+// publishChanges publishes the changes collected by merge followed by the end
+// of the import. Replay tells whether the import brought in the history of the
+// store rather than new activity. The caller must hold the write lock.
+func (s *Store) publishChanges(source string, replay bool) {
+	pending := s.pending
+	s.pending = changes{}
+
+	if len(pending.added) > 0 {
+		s.publish(models.Event{
+			Type: models.EventWorkoutsAdded, Source: source, WorkoutIDs: pending.added,
+			From: pending.addedRange.from, To: pending.addedRange.to,
+		})
+	}
+	if len(pending.updated) > 0 {
+		s.publish(models.Event{
+			Type: models.EventWorkoutsUpdated, Source: source, WorkoutIDs: pending.updated,
+			From: pending.updatedRange.from, To: pending.updatedRange.to,
+		})
+	}
+	if len(pending.added) > 0 || len(pending.updated) > 0 {
+		s.publishRecords(source, append(pending.added, pending.updated...), replay)
+	}
+	names := make([]string, 0, len(pending.metrics))
+	for name := range pending.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s.publish(models.Event{
+			Type: models.EventMetricsExtended, Source: source, Metric: name,
+			From: pending.metrics[name].from, To: pending.metrics[name].to,
+		})
+	}
+	if len(pending.metrics) > 0 {
+		s.publishAnomalies(source, pending.metrics)
+	}
+	s.publish(models.Event{
+		Type: models.EventImportFinished, Source: source,
+		Workouts: pending.workouts, MetricPoints: pending.metricPoints,
+	})
+}
+
+// publishRecords flags the personal records set by the imported workouts,
+// logging them at debug level when replayed. The caller must hold the lock.
+func (s *Store) publishRecords(source string, workoutIDs []string, replay bool) {
+	imported := make(map[string]bool, len(workoutIDs))
+	for _, id := range workoutIDs {
+		imported[id] = true
+	}
+	level := slog.LevelInfo
+	if replay {
+		level = slog.LevelDebug
+	}
+	var ids []string
+	count := 0
+	for _, record := range utils.Breakthroughs(utils.CalculateRecords(s.workouts).History) {
+		if !imported[record.WorkoutID] {
+			continue
+		}
+		slog.Log(context.Background(), level, "new personal record", "user", s.userID, "workout", record.WorkoutID,
+			"type", record.WorkoutType, "category", record.Category, "distance", record.Distance, "value", record.Value)
+		if len(ids) == 0 || ids[len(ids)-1] != record.WorkoutID {
+			ids = append(ids, record.WorkoutID)
+		}
+		count++
+	}
+	if count > 0 {
+		s.publish(models.Event{Type: models.EventRecordsSet, Source: source, WorkoutIDs: ids, Records: count})
+	}
+}
+
+// addWorkout records a workout merged into the store
+func (c *changes) addWorkout(workout models.Workout, replaced bool) {
+	c.workouts++
+	if replaced {
+		c.updated = append(c.updated, workout.ID)
+		c.updatedRange.include(workout.Start)
+	} else {
+		c.added = append(c.added, workout.ID)
+		c.addedRange.include(workout.Start)
+	}
+}
+
+// addMetricPoint records a data point merged into a metric
+func (c *changes) addMetricPoint(name string, point models.MetricData) {
+	c.metricPoints++
+	if c.metrics == nil {
+		c.metrics = make(map[string]*dateRange)
+	}
+	if c.metrics[name] == nil {
+		c.metrics[name] = &dateRange{}
+	}
+	c.metrics[name].include(point.Date)
+}
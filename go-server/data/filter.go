@@ -6,7 +6,7 @@ package data
 import (
 	"fitness/config"
 	"fitness/models"
-	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 )
@@ -66,7 +66,7 @@ func FilterDate(workouts []models.Workout, queryDate string, isStartDate bool) (
 	// Parse the queryDate string into a time.Time object
 	providedDate, err := time.Parse(config.DateFormat, queryDate)
 	if err != nil {
-		fmt.Println("Error parsing query queryDate:", err)
+		slog.Warn("invalid filter date", "date", queryDate, "error", err)
 		return nil, false
 	}
 
@@ -90,3 +90,68 @@ func FilterDate(workouts []models.Workout, queryDate string, isStartDate bool) (
 	// Return the filtered workouts and a boolean indicating if any were found
 	return filteredWorkouts, len(filteredWorkouts) > 0
 }
+
+func FilterMetricName(metrics []models.Metric, metricName string) ([]models.Metric, bool) {
+	// If metric name is empty, return all metrics
+	if metricName == "" {
+		return metrics, true
+	}
+
+	// Split the metric name into metric names if multiple present
+	targetNames := strings.Split(metricName, ",")
+	for i, name := range targetNames {
+		targetNames[i] = strings.TrimSpace(name)
+	}
+
+	// Filter the metric data based on the metric name
+	var filteredMetrics []models.Metric
+	for _, metric := range metrics {
+		for _, name := range targetNames {
+			if strings.EqualFold(metric.Name, name) {
+				filteredMetrics = append(filteredMetrics, metric)
+				break
+			}
+		}
+	}
+
+	// Return the filtered metrics and a boolean indicating if any were found
+	return filteredMetrics, len(filteredMetrics) > 0
+}
+
+func FilterMetricDate(metrics []models.Metric, queryDate string, isStartDate bool) ([]models.Metric, bool) {
+	var filteredMetrics []models.Metric
+	// If queryDate is empty, return all metrics
+	if queryDate == "" {
+		return metrics, true
+	}
+
+	// Parse the queryDate string into a time.Time object
+	providedDate, err := time.Parse(config.DateFormat, queryDate)
+	if err != nil {
+		slog.Warn("invalid filter date", "date", queryDate, "error", err)
+		return nil, false
+	}
+
+	for _, metric := range metrics {
+		// Keep only the data points within the queryDate bound, without modifying the original metric
+		filtered := metric
+		filtered.Data = nil
+		for _, point := range metric.Data {
+			pointDate, err := time.Parse(config.TimeFormat, point.Date)
+			if err != nil {
+				continue
+			}
+			if isStartDate && !pointDate.Before(providedDate) {
+				filtered.Data = append(filtered.Data, point)
+			} else if !isStartDate && !pointDate.After(providedDate) {
+				filtered.Data = append(filtered.Data, point)
+			}
+		}
+		if len(filtered.Data) > 0 {
+			filteredMetrics = append(filteredMetrics, filtered)
+		}
+	}
+
+	// Return the filtered metrics and a boolean indicating if any were found
+	return filteredMetrics, len(filteredMetrics) > 0
+}
@@ -0,0 +1,107 @@
+// data/goals.go
+// Goals set by a user, kept per user
+
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"fitness/models"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrGoalNotFound is returned when a goal does not exist in the user's store
+var ErrGoalNotFound = errors.New("goal not found")
+
+// goalsPath returns the path of the user's goals file
+func (s *Store) goalsPath() string {
+	return filepath.Join(s.dir, "goals.json")
+}
+
+// Goals returns the user's goals, oldest first
+func (s *Store) Goals() []models.Goal {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]models.Goal{}, s.goals...)
+}
+
+// Goal returns the goal with the given ID
+func (s *Store) Goal(id string) (models.Goal, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, goal := range s.goals {
+		if goal.ID == id {
+			return goal, true
+		}
+	}
+	return models.Goal{}, false
+}
+
+// CreateGoal stores a new goal, assigning its ID and creation time
+func (s *Store) CreateGoal(goal models.Goal) (models.Goal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	goal.ID = newID()
+	created := time.Now().UTC()
+	goal.CreatedAt = &created
+	s.goals = append(s.goals, goal)
+	return goal, s.writeGoals()
+}
+
+// UpdateGoal applies update to the goal with the given ID. The goal keeps its
+// ID and creation time whatever update does.
+func (s *Store) UpdateGoal(id string, update func(*models.Goal) error) (models.Goal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.goals {
+		if s.goals[i].ID != id {
+			continue
+		}
+		goal := s.goals[i]
+		if err := update(&goal); err != nil {
+			return models.Goal{}, err
+		}
+		goal.ID, goal.CreatedAt = id, s.goals[i].CreatedAt
+		s.goals[i] = goal
+		return goal, s.writeGoals()
+	}
+	return models.Goal{}, ErrGoalNotFound
+}
+
+// DeleteGoal removes the goal with the given ID
+func (s *Store) DeleteGoal(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.goals {
+		if s.goals[i].ID == id {
+			s.goals = append(s.goals[:i], s.goals[i+1:]...)
+			return s.writeGoals()
+		}
+	}
+	return ErrGoalNotFound
+}
+
+// writeGoals persists the goals, the caller must hold the write lock
+func (s *Store) writeGoals() error {
+	content, err := json.MarshalIndent(s.goals, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(s.goalsPath(), content)
+}
+
+// loadGoals reads the user's goals file
+func (s *Store) loadGoals() error {
+	content, err := os.ReadFile(s.goalsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return json.Unmarshal(content, &s.goals)
+}
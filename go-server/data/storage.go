@@ -2,44 +2,82 @@
 package data
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"regexp"
+	"runtime/debug"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"fitness/config"
 	"fitness/models"
+	"fitness/telemetry"
 )
 
-// AllWorkouts and AllMetrics are global variables that store all workout and metric data
-var (
-	AllWorkouts []models.Workout
-	AllMetrics  []models.Metric
-)
+// cachePath returns the path of the user's cache file
+func (s *Store) cachePath() string {
+	return filepath.Join(s.dir, "cache.json")
+}
+
+// LoadCache reads the user's cache file and loads the data into the store. A
+// user without a cache starts empty, or from the single-user cache for the owner.
+func (s *Store) LoadCache() error {
+	filename := s.cachePath()
+	if _, err := os.Stat(filename); errors.Is(err, os.ErrNotExist) {
+		if s.legacyCache == "" {
+			return nil
+		}
+		filename = s.legacyCache
+	}
 
-// LoadCache reads the cache file and loads the data into the program
-func LoadCache(filename string) (*models.HealthData, error) {
 	// Read the cache file
 	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
 	if err != nil {
-		return nil, err
+		return err
 	}
 	// Unmarshal the JSON data into a HealthData struct
 	var cache models.HealthData
 	if err := json.Unmarshal(data, &cache); err != nil {
-		return nil, err
+		return err
 	}
-	AllWorkouts = append(AllWorkouts, cache.Data.Workouts...)
-	AllMetrics = append(AllMetrics, cache.Data.Metrics...)
 
-	return &cache, nil // Return a pointer to the HealthData struct
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.workouts = append(s.workouts, cache.Data.Workouts...)
+	s.metrics = append(s.metrics, cache.Data.Metrics...)
+	if cache.LastUpdated != nil {
+		s.lastUpdated = *cache.LastUpdated
+	}
+
+	// Anomalies are not cached, scan every metric once it is loaded
+	all := make(map[string]string, len(s.metrics))
+	for _, metric := range s.metrics {
+		all[metric.Name] = ""
+	}
+	s.scanAnomalies(all)
+
+	// The cache was last written by the most recent import
+	modified := time.Now()
+	if info, err := os.Stat(filename); err == nil {
+		modified = info.ModTime()
+	}
+	s.markModified(modified)
+
+	return nil
 }
 
-// Load the new directory files into the program data
-func LoadDirectory(directoryPath string, cacheLastUpdated string) (bool, string, error) {
+// Load the new directory files into the store, stopping early when ctx is cancelled
+func (s *Store) LoadDirectory(ctx context.Context, directoryPath string, cacheLastUpdated string) (bool, string, error) {
 	// Read the directory
 	files, err := os.ReadDir(directoryPath)
 	if err != nil {
@@ -54,13 +92,20 @@ func LoadDirectory(directoryPath string, cacheLastUpdated string) (bool, string,
 	// Prepare variables to track data updates
 	dataWasUpdated := false
 	latestFileDate := cacheLastUpdated
-	cacheDate, err := time.Parse(config.DateFormat, cacheLastUpdated)
-	if err != nil {
-		return false, cacheLastUpdated, err
+	var cacheDate time.Time
+	if cacheLastUpdated != "" {
+		cacheDate, err = time.Parse(config.DateFormat, cacheLastUpdated)
+		if err != nil {
+			return false, cacheLastUpdated, err
+		}
 	}
 
 	// Iterate over files in the directory
 	for _, file := range files {
+		// Keep what was loaded so far, the remaining files are picked up by the next import
+		if ctx.Err() != nil {
+			break
+		}
 		if !strings.HasSuffix(file.Name(), ".json") {
 			continue
 		}
@@ -76,36 +121,36 @@ func LoadDirectory(directoryPath string, cacheLastUpdated string) (bool, string,
 		// Parse and compare dates
 		currentFileDate, err := time.Parse(config.DateFormat, fileDate)
 		if err != nil {
-			fmt.Printf("Error parsing date for file %s: %v\n", file.Name(), err)
+			slog.Warn("skipping file with an invalid date", "user", s.userID, "file", file.Name(), "error", err)
 			continue
 		}
 
 		// Only process files newer than our cache
 		if currentFileDate.After(cacheDate) {
-			fmt.Printf("Processing new data from: %s\n", fileDate)
+			slog.Info("processing new data", "user", s.userID, "date", fileDate)
 
 			// Read and parse file
-			filePath := directoryPath + "/" + file.Name()
+			filePath := filepath.Join(directoryPath, file.Name())
 			content, err := os.ReadFile(filePath)
 			if err != nil {
+				slog.Error("skipping unreadable file", "user", s.userID, "file", file.Name(), "error", err)
+				telemetry.ImportFilesFailed.Inc()
 				continue
 			}
 
 			// Unmarshal JSON data into HealthData struct
 			var fileData models.HealthData
 			if err := json.Unmarshal(content, &fileData); err != nil {
-				fmt.Printf("Error unmarshaling file %s: %v\n", file.Name(), err)
+				slog.Error("skipping unparsable file", "user", s.userID, "file", file.Name(), "error", err)
+				telemetry.ImportFilesFailed.Inc()
 				continue
 			}
 
-			// Sort data before adding to collections
-			sort.Slice(fileData.Data.Workouts, func(i, j int) bool {
-				return fileData.Data.Workouts[i].Start < fileData.Data.Workouts[j].Start
-			})
-
 			// Update our data collections
-			AllWorkouts = append(AllWorkouts, fileData.Data.Workouts...)
-			AllMetrics = append(AllMetrics, fileData.Data.Metrics...)
+			s.mu.Lock()
+			s.merge(fileData.Data)
+			s.mu.Unlock()
+			telemetry.ImportFilesProcessed.Inc()
 			dataWasUpdated = true
 
 			// Keep track of the latest file date
@@ -118,46 +163,109 @@ func LoadDirectory(directoryPath string, cacheLastUpdated string) (bool, string,
 	return dataWasUpdated, latestFileDate, nil
 }
 
-// Reads the cache file, loads the directory data, writes to the cache if new data is found
-func ImportData() {
-	// Get environment variables
-	// config.ICloudDirPath = os.Getenv("ICLOUD_DIR_PATH")
-	// config.CacheFilePath = os.Getenv("CACHE_FILE_PATH")
-
-	// Load cache file
-	cache, err := LoadCache(config.CacheFilePath)
-	if err != nil {
-
-		panic(fmt.Sprintf("Failed to load cache: %v", err))
+// Import loads new files from the user's import directory, writing to the cache if new data is found
+func (s *Store) Import(ctx context.Context) error {
+	if s.importDir == "" {
+		return nil
 	}
 
 	// Process directory and get update status
-	wasUpdated, latestUpdate, err := LoadDirectory(config.ICloudDirPath, *cache.LastUpdated)
+	s.mu.Lock()
+	lastUpdated := s.lastUpdated
+	s.publish(models.Event{Type: models.EventImportStarted, Source: "import"})
+	s.mu.Unlock()
+	wasUpdated, latestUpdate, err := s.LoadDirectory(ctx, s.importDir, lastUpdated)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Tell clients the import is over even when it failed or found nothing new
+	// Without a cache the whole directory is read again, replaying the history
+	defer s.publishChanges("import", lastUpdated == "")
 	if err != nil {
-		panic(fmt.Sprintf("Failed to load directory: %v", err))
+		return fmt.Errorf("failed to load directory: %v", err)
 	}
 
 	// Only write to cache if we found new data
 	if wasUpdated {
-		if err := WriteToCache(AllWorkouts, AllMetrics, &latestUpdate); err != nil {
-			panic(fmt.Sprintf("Failed to write cache: %v", err))
+		s.lastUpdated = latestUpdate
+		s.markModified(time.Now())
+		if err := s.writeCache(); err != nil {
+			return fmt.Errorf("failed to write cache: %v", err)
 		}
-		fmt.Printf("Cache updated with data through: %s\n", latestUpdate)
-	} else {
-		// fmt.Println("No new data found, cache remains current")
+		slog.Info("cache updated", "user", s.userID, "through", latestUpdate)
 	}
-	fmt.Println()
+	return nil
 }
 
-// WriteToCache writes the data to the cache file
-func WriteToCache(AllWorkouts []models.Workout, AllMetrics []models.Metric, lastUpdated *string) error {
+// ImportData loads every user's cache and imports new files from their import
+// directories, until ctx is cancelled
+func ImportData(ctx context.Context, users []models.User) {
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return
+		}
+		importUser(ctx, user)
+	}
+}
+
+// importUser imports a single user's data, a failure never stops the other users' imports
+func importUser(ctx context.Context, user models.User) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("import panicked", "user", user.ID, "panic", r, "stack", string(debug.Stack()))
+			recordImport("import", fmt.Errorf("import panicked: %v", r))
+		}
+	}()
+	store := ForUser(user)
+	if store.importDir == "" {
+		return
+	}
+	err := store.Import(ctx)
+	recordImport("import", err)
+	if err != nil {
+		slog.Error("import failed", "user", user.ID, "error", err)
+	}
+}
+
+// lastImport is the Unix time in nanoseconds of the last successful import, zero before the first
+var lastImport atomic.Int64
+
+// LastImport returns the time of the last successful import or ingest
+func LastImport() (time.Time, bool) {
+	nanos := lastImport.Load()
+	return time.Unix(0, nanos), nanos != 0
+}
+
+// recordImport counts an import run from the source and remembers when the last one succeeded
+func recordImport(source string, err error) {
+	if err != nil {
+		telemetry.ImportRuns.Inc(source, "failure")
+		return
+	}
+	telemetry.ImportRuns.Inc(source, "success")
+	now := time.Now()
+	lastImport.Store(now.UnixNano())
+	telemetry.LastImportSuccess.Set(float64(now.Unix()))
+}
+
+// WriteToCache writes the store's data to the user's cache file
+func (s *Store) WriteToCache() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.writeCache()
+}
+
+// writeCache writes the data to the cache file, the caller must hold the lock
+func (s *Store) writeCache() error {
+	start := time.Now()
 	// Create the HealthData structure to match the original format
+	lastUpdated := s.lastUpdated
 	healthData := models.HealthData{
 		Data: models.DataCollection{
-			Workouts: AllWorkouts,
-			Metrics:  AllMetrics,
+			Workouts: s.workouts,
+			Metrics:  s.metrics,
 		},
-		LastUpdated: lastUpdated,
+		LastUpdated: &lastUpdated,
 	}
 
 	// Marshal the HealthData structure into JSON
@@ -166,11 +274,27 @@ func WriteToCache(AllWorkouts []models.Workout, AllMetrics []models.Metric, last
 		return fmt.Errorf("error marshaling data: %v", err)
 	}
 
-	// Write the JSON data to cache.json
-	err = os.WriteFile(config.CacheFilePath, data, 0644)
-	if err != nil {
+	// Write the JSON data to the user's cache.json
+	if err := writeFile(s.cachePath(), data); err != nil {
+		return err
+	}
+	telemetry.CacheWriteDuration.Observe(time.Since(start).Seconds())
+	telemetry.CacheWriteBytes.Observe(float64(len(data)))
+	slog.Debug("cache written", "user", s.userID, "path", s.cachePath())
+	return nil
+}
+
+// writeFile writes through a temporary file so readers never see partial content
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating directory: %v", err)
+	}
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, data, 0644); err != nil {
+		return fmt.Errorf("error writing to file: %v", err)
+	}
+	if err := os.Rename(temp, path); err != nil {
 		return fmt.Errorf("error writing to file: %v", err)
 	}
-	fmt.Printf("Data written to %s\n", config.CacheFilePath)
 	return nil
 }
@@ -0,0 +1,239 @@
+// data/store.go
+package data
+
+import (
+	"fitness/config"
+	"fitness/models"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store holds the workout and metric data of a single user. All access goes
+// through its methods, which return copies so callers cannot modify the data.
+type Store struct {
+	mu           sync.RWMutex
+	userID       string
+	dir          string // Directory holding the user's cache, edit history and goals
+	importDir    string // Health Auto Export directory, empty when data is only ingested
+	legacyCache  string // Cache of the single-user setup, read when the user has no cache yet
+	workouts     []models.Workout
+	metrics      []models.Metric
+	lastUpdated  string // Date of the newest imported file
+	history      []models.WorkoutEdit
+	goals        []models.Goal
+	anomalies    map[string][]models.Anomaly // Anomalies found per metric name
+	version      uint64
+	lastModified time.Time
+	pending      changes  // Changes merged since the last published events
+	events       eventLog // Recent events and subscribers of the event stream
+}
+
+// stores holds the store of every user that has been used since startup
+var (
+	storesMu sync.Mutex
+	stores   = make(map[string]*Store)
+)
+
+// ForUser returns the store of a user, loading its cache on first use
+func ForUser(user models.User) *Store {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	if store, ok := stores[user.ID]; ok {
+		return store
+	}
+
+	store := &Store{
+		userID:    user.ID,
+		dir:       filepath.Join(config.DataDir, "users", user.ID),
+		importDir: config.ImportDirs[strings.ToLower(user.Email)],
+	}
+	// Event IDs continue from the clock so clients of an earlier run are told to resync
+	store.events.last = uint64(time.Now().UnixMilli())
+	// The owner keeps the import directory and cache of the single-user setup
+	if config.OwnerEmail != "" && strings.EqualFold(user.Email, config.OwnerEmail) {
+		if store.importDir == "" {
+			store.importDir = config.ICloudDirPath
+		}
+		store.legacyCache = config.CacheFilePath
+	}
+	if err := store.LoadCache(); err != nil {
+		slog.Error("loading cache", "user", user.ID, "error", err)
+	}
+	if err := store.loadHistory(); err != nil {
+		slog.Error("loading edit history", "user", user.ID, "error", err)
+	}
+	if err := store.loadGoals(); err != nil {
+		slog.Error("loading goals", "user", user.ID, "error", err)
+	}
+	stores[user.ID] = store
+	return store
+}
+
+// UserID returns the ID of the user owning the store
+func (s *Store) UserID() string {
+	return s.userID
+}
+
+// Workouts returns a copy of the user's workouts, ordered by start time
+func (s *Store) Workouts() []models.Workout {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]models.Workout(nil), s.workouts...)
+}
+
+// Metrics returns a copy of the user's metrics
+func (s *Store) Metrics() []models.Metric {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	metrics := make([]models.Metric, len(s.metrics))
+	for i, metric := range s.metrics {
+		metrics[i] = metric
+		metrics[i].Data = append([]models.MetricData(nil), metric.Data...)
+	}
+	return metrics
+}
+
+// Version returns the data version, which increases every time the stored data
+// changes, and the time of the last change
+func (s *Store) Version() (uint64, time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.version, s.lastModified
+}
+
+// markModified increases the data version, the caller must hold the write lock
+func (s *Store) markModified(modified time.Time) {
+	s.version++
+	s.lastModified = modified.UTC().Truncate(time.Second)
+}
+
+// Ingest merges pushed Health Auto Export data into the store and writes the cache
+func (s *Store) Ingest(healthData models.HealthData) (models.IngestResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.publish(models.Event{Type: models.EventImportStarted, Source: "ingest"})
+	// Data pushed into an empty store is its history rather than new activity
+	replay := len(s.workouts) == 0
+	result := s.merge(healthData.Data)
+	if result.Workouts == 0 && result.MetricPoints == 0 {
+		s.publishChanges("ingest", replay)
+		recordImport("ingest", nil)
+		result.Version = s.version
+		return result, nil
+	}
+	s.markModified(time.Now())
+	result.Version = s.version
+	err := s.writeCache()
+	s.publishChanges("ingest", replay)
+	recordImport("ingest", err)
+	return result, err
+}
+
+// MemoryTotals returns the number of workouts and metric data points held in memory across all users
+func MemoryTotals() (workouts, metricPoints int) {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	for _, store := range stores {
+		store.mu.RLock()
+		workouts += len(store.workouts)
+		for _, metric := range store.metrics {
+			metricPoints += len(metric.Data)
+		}
+		store.mu.RUnlock()
+	}
+	return workouts, metricPoints
+}
+
+// merge adds workouts and metric data points, replacing entries already stored,
+// and collects the changes for publishing. Workouts edited or deleted through
+// the API keep their edited state. The caller must hold the write lock.
+func (s *Store) merge(collection models.DataCollection) models.IngestResult {
+	var result models.IngestResult
+
+	// Index stored workouts so re-imported workouts replace their earlier copy
+	edited := make(map[string]bool)
+	for _, edit := range s.history {
+		edited[edit.WorkoutID] = true
+	}
+	index := make(map[string]int, len(s.workouts))
+	for i, workout := range s.workouts {
+		index[workoutKey(workout)] = i
+	}
+	for _, workout := range collection.Workouts {
+		if edited[workout.ID] {
+			continue
+		}
+		i, replaced := index[workoutKey(workout)]
+		if replaced {
+			s.workouts[i] = workout
+		} else {
+			index[workoutKey(workout)] = len(s.workouts)
+			s.workouts = append(s.workouts, workout)
+		}
+		s.pending.addWorkout(workout, replaced)
+		result.Workouts++
+	}
+	sort.SliceStable(s.workouts, func(i, j int) bool {
+		return s.workouts[i].Start < s.workouts[j].Start
+	})
+
+	// Merge metric data points by metric name and date
+	for _, metric := range collection.Metrics {
+		position := -1
+		for i := range s.metrics {
+			if s.metrics[i].Name == metric.Name {
+				position = i
+				break
+			}
+		}
+		if position < 0 {
+			s.metrics = append(s.metrics, models.Metric{Name: metric.Name, Units: metric.Units})
+			position = len(s.metrics) - 1
+		}
+		stored := &s.metrics[position]
+		dates := make(map[string]int, len(stored.Data))
+		for i, point := range stored.Data {
+			dates[metricPointKey(point)] = i
+		}
+		for _, point := range metric.Data {
+			// Unaggregated sleep stages are dated by their start
+			if point.Date == "" && point.SleepData != nil {
+				point.Date = point.StartDate
+			}
+			key := metricPointKey(point)
+			if i, ok := dates[key]; ok {
+				stored.Data[i] = point
+			} else {
+				dates[key] = len(stored.Data)
+				stored.Data = append(stored.Data, point)
+			}
+			s.pending.addMetricPoint(metric.Name, point)
+			result.MetricPoints++
+		}
+		sort.SliceStable(stored.Data, func(i, j int) bool {
+			return stored.Data[i].Date < stored.Data[j].Date
+		})
+	}
+	return result
+}
+
+// metricPointKey identifies a metric data point by its date, and by its stage for
+// sleep stages, which can start at the same time
+func metricPointKey(point models.MetricData) string {
+	if point.SleepData != nil && point.Value != "" {
+		return point.Date + "|" + point.Value
+	}
+	return point.Date
+}
+
+// workoutKey identifies a workout, falling back to its name and start time when it has no ID
+func workoutKey(workout models.Workout) string {
+	if workout.ID != "" {
+		return workout.ID
+	}
+	return workout.Name + "|" + workout.Start
+}
@@ -2,7 +2,11 @@ module fitness
 
 go 1.22.6
 
-require github.com/stretchr/testify v1.10.0
+require (
+	github.com/andybalholm/brotli v1.1.1
+	github.com/stretchr/testify v1.10.0
+	golang.org/x/crypto v0.31.0
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
@@ -1,10 +1,32 @@
 // main.go
 package main
 
-import "fitness/api"
+import (
+	"fitness/api"
+	"io"
+	"log/slog"
+	"os"
+)
 
 func main() {
-	// Start the server
-	api.StartServer()
+	// The report and review commands write summaries of the cached data and
+	// adduser creates accounts, instead of serving the API
+	if len(os.Args) > 1 {
+		commands := map[string]func([]string, io.Writer) error{
+			"report": api.RunReport, "review": api.RunReview, "adduser": api.RunAddUser,
+		}
+		if command, ok := commands[os.Args[1]]; ok {
+			if err := command(os.Args[2:], os.Stdout); err != nil {
+				slog.Error("running command", "command", os.Args[1], "error", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
 
+	// Start the server
+	if err := api.StartServer(); err != nil {
+		slog.Error("server stopped", "error", err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,23 @@
+// models/compare.go
+package models
+
+// ComparisonPeriod is one of the two periods of a comparison
+type ComparisonPeriod struct {
+	Key   string `json:"key"`   // Label of the period, see the calendar keys
+	Start string `json:"start"` // First day included
+	End   string `json:"end"`   // Last day included, cut to the days elapsed in the current period
+	Days  int    `json:"days"`  // Number of days included
+}
+
+// Comparison compares the totals and averages of the period in progress with
+// the same days of an earlier period
+type Comparison struct {
+	Period   string           `json:"period"`           // Period compared: day, week, isoweek, month or year
+	Offset   int              `json:"offset"`           // Periods between the current and the previous period
+	Metric   string           `json:"metric,omitempty"` // Health metric compared, workouts when empty
+	Units    string           `json:"units,omitempty"`  // Units of the metric
+	Current  ComparisonPeriod `json:"current"`          // Period in progress, up to today
+	Previous ComparisonPeriod `json:"previous"`         // Earlier period, aligned to the current one
+	Totals   []ReportChange   `json:"totals"`           // Totals of both periods and their deltas
+	Averages []ReportChange   `json:"averages"`         // Averages per workout, or per day for metrics, and their deltas
+}
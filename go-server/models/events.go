@@ -0,0 +1,34 @@
+// models/events.go
+package models
+
+import "time"
+
+// Types of the events sent on the event stream
+const (
+	EventImportStarted   = "import.started"   // An import or ingest began
+	EventImportFinished  = "import.finished"  // An import or ingest completed, with its counts
+	EventWorkoutsAdded   = "workouts.added"   // New workouts were stored
+	EventWorkoutsUpdated = "workouts.updated" // Stored workouts were replaced or edited
+	EventWorkoutsDeleted = "workouts.deleted" // Workouts were deleted
+	EventMetricsExtended = "metrics.extended" // Data points were added to a metric
+	EventRecordsSet      = "records.set"      // Imported workouts set personal records
+	EventAnomalies       = "anomalies.found"  // Imported metric data points were flagged as anomalies
+	EventResync          = "resync"           // Missed events are no longer buffered, reload everything
+)
+
+// Event describes a change to a user's data
+type Event struct {
+	ID           uint64    `json:"id"`                     // Sequence number of the event, per user
+	Type         string    `json:"type"`                   // One of the Event* types
+	Version      uint64    `json:"version"`                // Data version after the change
+	Time         time.Time `json:"time"`                   // Time the event was published
+	Source       string    `json:"source,omitempty"`       // Either "import", "ingest" or "edit"
+	WorkoutIDs   []string  `json:"workoutIds,omitempty"`   // Workouts affected by the change
+	Metric       string    `json:"metric,omitempty"`       // Metric affected by the change
+	From         string    `json:"from,omitempty"`         // Earliest date affected by the change
+	To           string    `json:"to,omitempty"`           // Latest date affected by the change
+	Workouts     int       `json:"workouts,omitempty"`     // Workouts stored by a finished import
+	MetricPoints int       `json:"metricPoints,omitempty"` // Metric data points stored by a finished import
+	Records      int       `json:"records,omitempty"`      // Personal records set by the imported workouts
+	Anomalies    int       `json:"anomalies,omitempty"`    // Anomalies found in the imported metric data
+}
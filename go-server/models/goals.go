@@ -0,0 +1,48 @@
+// models/goals.go
+package models
+
+import "time"
+
+// Workout fields goals and trends can total
+const (
+	GoalWorkouts = "workouts" // Number of workouts
+	GoalDistance = "distance" // Distance in the goal's units
+	GoalDuration = "duration" // Minutes of exercise
+	GoalEnergy   = "energy"   // Active kilocalories
+)
+
+// Goal is a target for a workout field or health metric over every period
+type Goal struct {
+	ID        string     `json:"id,omitempty"`        // Unique identifier for the goal, assigned when it is created
+	Name      string     `json:"name,omitempty"`      // Label of the goal, such as "Weekly running"
+	Period    string     `json:"period"`              // Period the target applies to: day, week, isoweek, month or year
+	Field     string     `json:"field,omitempty"`     // Workout field totaled: workouts, distance, duration or energy
+	Metric    string     `json:"metric,omitempty"`    // Health metric totaled instead of a workout field, such as step_count
+	Workout   string     `json:"workout,omitempty"`   // Comma separated workout names counted, all when empty
+	Target    float64    `json:"target"`              // Total to reach in every period
+	Units     string     `json:"units,omitempty"`     // Units of a distance target: km, mi or m, km by default
+	CreatedAt *time.Time `json:"createdAt,omitempty"` // Time the goal was created
+}
+
+// GoalPeriod is the progress of a goal in a single period
+type GoalPeriod struct {
+	Period  string  `json:"period"`          // Label of the period, see the calendar keys
+	Start   string  `json:"start"`           // First day of the period
+	End     string  `json:"end"`             // Last day of the period
+	Value   float64 `json:"value"`           // Total reached in the period
+	Percent float64 `json:"percent"`         // Total as a percentage of the target
+	Met     bool    `json:"met"`             // Whether the target was reached
+	MetOn   string  `json:"metOn,omitempty"` // Day the target was reached
+}
+
+// GoalProgress is the progress of a goal in the current period and the ones before it
+type GoalProgress struct {
+	Goal                Goal         `json:"goal"`                          // Goal the progress is for
+	Current             GoalPeriod   `json:"current"`                       // Progress in the current period
+	Projected           float64      `json:"projected"`                     // Total expected by the end of the current period at the current pace
+	ProjectedCompletion string       `json:"projectedCompletion,omitempty"` // Day the target is expected to be reached, unset when not within the period
+	OnTrack             bool         `json:"onTrack"`                       // Whether the target is met or expected to be met
+	History             []GoalPeriod `json:"history"`                       // Completed periods, newest first
+	Met                 int          `json:"met"`                           // Number of completed periods that met the target
+	Missed              int          `json:"missed"`                        // Number of completed periods that missed the target
+}
@@ -0,0 +1,67 @@
+// models/reports.go
+package models
+
+// ReportTotals are the workout totals of a report period
+type ReportTotals struct {
+	Workouts   int     `json:"workouts"`   // Number of workouts
+	ActiveDays int     `json:"activeDays"` // Days with at least one workout
+	Duration   float64 `json:"duration"`   // Total duration in seconds
+	Distance   float64 `json:"distance"`   // Total distance in kilometers
+	Energy     float64 `json:"energy"`     // Total active energy in kilocalories
+}
+
+// ReportChange compares a total with the one of the previous period
+type ReportChange struct {
+	Field    string   `json:"field"`             // Field of the totals: workouts, activeDays, duration, distance or energy
+	Current  float64  `json:"current"`           // Value in the period
+	Previous float64  `json:"previous"`          // Value in the previous period
+	Delta    float64  `json:"delta"`             // Current minus previous value
+	Percent  *float64 `json:"percent,omitempty"` // Change as a percent of the previous value, unset when it is zero
+}
+
+// ReportBucket holds the totals of a day or week of the period, used for sparklines
+type ReportBucket struct {
+	Key      string  `json:"key"`      // Label of the day or week, see the calendar keys
+	Workouts int     `json:"workouts"` // Number of workouts
+	Duration float64 `json:"duration"` // Total duration in seconds
+	Distance float64 `json:"distance"` // Total distance in kilometers
+	Energy   float64 `json:"energy"`   // Total active energy in kilocalories
+}
+
+// ReportWorkout is one of the top workouts of a period
+type ReportWorkout struct {
+	WorkoutID string  `json:"workoutId"` // ID of the workout
+	Name      string  `json:"name"`      // Name of the workout type
+	Start     string  `json:"start"`     // Start of the workout
+	Duration  float64 `json:"duration"`  // Duration in seconds
+	Distance  float64 `json:"distance"`  // Distance in kilometers, 0 without one
+	Energy    float64 `json:"energy"`    // Active energy in kilocalories, 0 without one
+}
+
+// ReportMetric is a health metric whose daily average changed notably from the previous period
+type ReportMetric struct {
+	Name     string    `json:"name"`     // Name of the metric
+	Units    string    `json:"units"`    // Units of the metric
+	Average  float64   `json:"average"`  // Average daily value in the period
+	Previous float64   `json:"previous"` // Average daily value in the previous period
+	Percent  float64   `json:"percent"`  // Change as a percent of the previous average
+	Daily    []float64 `json:"daily"`    // Daily values of the period, oldest first
+}
+
+// Report summarizes the workouts and metrics of a week, month or year
+type Report struct {
+	Period      string           `json:"period"`      // Either week, month or year
+	Key         string           `json:"key"`         // Label of the period, see the calendar keys
+	Start       string           `json:"start"`       // First day of the period
+	End         string           `json:"end"`         // Last day of the period
+	Partial     bool             `json:"partial"`     // Whether the period is still in progress
+	Previous    string           `json:"previous"`    // Label of the previous period
+	Totals      ReportTotals     `json:"totals"`      // Totals of the period
+	Changes     []ReportChange   `json:"changes"`     // Totals compared with the previous period
+	Buckets     []ReportBucket   `json:"buckets"`     // Totals per day, or per week for yearly reports
+	Records     []PersonalRecord `json:"records"`     // Personal records set in the period
+	Streaks     StreakSummary    `json:"streaks"`     // Daily streaks at the end of the period
+	WeekStreaks StreakSummary    `json:"weekStreaks"` // Weekly streaks at the end of the period
+	TopWorkouts []ReportWorkout  `json:"topWorkouts"` // Workouts with the most active energy, then the longest
+	Metrics     []ReportMetric   `json:"metrics"`     // Metrics that changed notably, largest change first
+}
@@ -0,0 +1,39 @@
+// models/review.go
+package models
+
+// ReviewType holds the totals of one workout type over a year
+type ReviewType struct {
+	Name     string  `json:"name"`     // Name of the workout type
+	Workouts int     `json:"workouts"` // Number of workouts
+	Duration float64 `json:"duration"` // Total duration in seconds
+	Distance float64 `json:"distance"` // Total distance in kilometers
+	Energy   float64 `json:"energy"`   // Total active energy in kilocalories
+}
+
+// ReviewEquivalence puts a yearly total in everyday terms
+type ReviewEquivalence struct {
+	Name        string  `json:"name"`        // Short name of the equivalence, such as "marathons"
+	Value       float64 `json:"value"`       // How many of them the total amounts to
+	Description string  `json:"description"` // Sentence describing the equivalence
+}
+
+// YearInReview summarizes a year of workouts
+type YearInReview struct {
+	Year              int                 `json:"year"`                      // Calendar year of the review
+	Partial           bool                `json:"partial"`                   // Whether the year is still in progress
+	Totals            ReportTotals        `json:"totals"`                    // Totals of the year
+	Changes           []ReportChange      `json:"changes"`                   // Totals compared with the previous year, cut to the same length while in progress
+	Types             []ReviewType        `json:"types"`                     // Totals per workout type, longest total duration first
+	BiggestMonth      *ReportBucket       `json:"biggestMonth,omitempty"`    // Month with the longest total duration
+	BiggestWeek       *ReportBucket       `json:"biggestWeek,omitempty"`     // Week with the longest total duration
+	Weeks             []ReportBucket      `json:"weeks"`                     // Totals of every week of the year
+	LongestStreak     Streak              `json:"longestStreak"`             // Longest run of active days
+	LongestWeekStreak Streak              `json:"longestWeekStreak"`         // Longest run of active weeks
+	FavoriteTime      string              `json:"favoriteTime,omitempty"`    // Part of the day most workouts started in: morning, afternoon, evening or night
+	FavoriteHour      *int                `json:"favoriteHour,omitempty"`    // Hour of the day most workouts started in
+	FavoriteWeekday   string              `json:"favoriteWeekday,omitempty"` // Weekday with the most workouts
+	Records           []PersonalRecord    `json:"records"`                   // Best personal record of every type and category set during the year
+	FirstWorkout      *ReportWorkout      `json:"firstWorkout,omitempty"`    // First workout of the year
+	LastWorkout       *ReportWorkout      `json:"lastWorkout,omitempty"`     // Last workout of the year so far
+	Equivalences      []ReviewEquivalence `json:"equivalences"`              // Totals in everyday terms
+}
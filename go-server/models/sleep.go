@@ -0,0 +1,75 @@
+// models/sleep.go
+package models
+
+// SleepData holds the fields of sleep_analysis data points, in hours. Aggregated
+// data points summarize a night, unaggregated ones cover a single stage.
+type SleepData struct {
+	TotalSleep float64 `json:"totalSleep,omitempty"` // Hours asleep in the night
+	Asleep     float64 `json:"asleep,omitempty"`     // Hours asleep without a recorded stage
+	Core       float64 `json:"core,omitempty"`       // Hours of core sleep
+	Deep       float64 `json:"deep,omitempty"`       // Hours of deep sleep
+	REM        float64 `json:"rem,omitempty"`        // Hours of REM sleep
+	Awake      float64 `json:"awake,omitempty"`      // Hours awake between falling asleep and waking up
+	InBed      float64 `json:"inBed,omitempty"`      // Hours in bed
+	SleepStart string  `json:"sleepStart,omitempty"` // Time the night's sleep started
+	SleepEnd   string  `json:"sleepEnd,omitempty"`   // Time the night's sleep ended
+	InBedStart string  `json:"inBedStart,omitempty"` // Time the user went to bed
+	InBedEnd   string  `json:"inBedEnd,omitempty"`   // Time the user got up
+
+	StartDate string `json:"startDate,omitempty"` // Start of an unaggregated stage
+	EndDate   string `json:"endDate,omitempty"`   // End of an unaggregated stage
+	Value     string `json:"value,omitempty"`     // Stage: "In Bed", "Asleep", "Core", "Deep", "REM" or "Awake"
+	Source    string `json:"source,omitempty"`    // Device or app that recorded the sleep
+}
+
+// SleepStages are the shares of the time asleep spent in every stage, in percent
+type SleepStages struct {
+	Core        float64 `json:"core"`        // Core or light sleep
+	Deep        float64 `json:"deep"`        // Deep sleep
+	REM         float64 `json:"rem"`         // REM sleep
+	Unspecified float64 `json:"unspecified"` // Sleep without a recorded stage
+}
+
+// SleepNight is the sleep of one night, in hours
+type SleepNight struct {
+	Night      string       `json:"night"`                // Day the night ends on, as recorded
+	BedTime    string       `json:"bedTime,omitempty"`    // Time the user went to bed, or fell asleep when unknown
+	WakeTime   string       `json:"wakeTime,omitempty"`   // Time the user got up, or woke up when unknown
+	InBed      float64      `json:"inBed"`                // Hours in bed
+	Asleep     float64      `json:"asleep"`               // Hours asleep
+	Awake      float64      `json:"awake"`                // Hours awake in bed
+	Efficiency *float64     `json:"efficiency,omitempty"` // Time asleep as a percent of the time in bed, unset without it
+	Stages     *SleepStages `json:"stages,omitempty"`     // Shares of the sleep stages, unset without stages
+	Debt       float64      `json:"debt"`                 // Hours below the target over the last 14 nights, at least 0
+	Source     string       `json:"source,omitempty"`     // Devices or apps that recorded the night
+}
+
+// SleepConsistency measures how regular bed and wake times are
+type SleepConsistency struct {
+	BedTime        string  `json:"bedTime,omitempty"`  // Average bed time as hh:mm
+	WakeTime       string  `json:"wakeTime,omitempty"` // Average wake time as hh:mm
+	BedTimeStdDev  float64 `json:"bedTimeStdDev"`      // Standard deviation of the bed times in minutes
+	WakeTimeStdDev float64 `json:"wakeTimeStdDev"`     // Standard deviation of the wake times in minutes
+}
+
+// SleepReport holds the nights of a range and their averages
+type SleepReport struct {
+	Target      float64          `json:"target"`      // Hours of sleep a night the debt is measured against
+	Nights      []SleepNight     `json:"nights"`      // Every night with sleep data, oldest first
+	Asleep      float64          `json:"asleep"`      // Average hours asleep
+	Efficiency  float64          `json:"efficiency"`  // Average efficiency of the nights with one
+	Debt        float64          `json:"debt"`        // Sleep debt after the last night
+	Consistency SleepConsistency `json:"consistency"` // Regularity of bed and wake times
+}
+
+// SleepWeek summarizes the nights of a week
+type SleepWeek struct {
+	Week        string           `json:"week"`        // First day of the week
+	Nights      int              `json:"nights"`      // Nights with sleep data
+	Asleep      float64          `json:"asleep"`      // Average hours asleep
+	InBed       float64          `json:"inBed"`       // Average hours in bed
+	Efficiency  float64          `json:"efficiency"`  // Average efficiency of the nights with one
+	Stages      SleepStages      `json:"stages"`      // Average shares of the sleep stages
+	Consistency SleepConsistency `json:"consistency"` // Regularity of bed and wake times
+	Debt        float64          `json:"debt"`        // Sleep debt after the last night of the week
+}
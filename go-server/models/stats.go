@@ -0,0 +1,277 @@
+// models/stats.go
+package models
+
+// Streak is a run of consecutive active days or weeks
+type Streak struct {
+	Length int    `json:"length"`          // Active days or weeks in the streak
+	Start  string `json:"start,omitempty"` // First active day or week, empty when there is no streak
+	End    string `json:"end,omitempty"`   // Last active day or week
+}
+
+// StreakSummary holds the current and longest streak of one kind
+type StreakSummary struct {
+	Current Streak `json:"current"` // Streak still alive today, zero length when broken
+	Longest Streak `json:"longest"` // Longest streak ever
+}
+
+// WeekConsistency is the number of active days in a week
+type WeekConsistency struct {
+	Week       string  `json:"week"`       // First day of the week
+	ActiveDays int     `json:"activeDays"` // Days meeting the streak rules
+	Score      float64 `json:"score"`      // Active days as a fraction of the week
+}
+
+// Streaks reports daily and weekly streaks and the consistency of every week
+type Streaks struct {
+	Daily       StreakSummary     `json:"daily"`       // Streaks of active days, allowing the configured rest days
+	Weekly      StreakSummary     `json:"weekly"`      // Streaks of weeks with enough active days
+	Weeks       []WeekConsistency `json:"weeks"`       // Consistency of every week since the first workout
+	Consistency float64           `json:"consistency"` // Average score of the completed weeks
+}
+
+// WorkoutLoad is the training load of a single workout
+type WorkoutLoad struct {
+	WorkoutID string  `json:"workoutId"` // Workout the load was computed for
+	Name      string  `json:"name"`      // Name of the workout type
+	Start     string  `json:"start"`     // Start of the workout
+	Load      float64 `json:"load"`      // Training load score
+	Method    string  `json:"method"`    // Either "trimp" from heart rate or "mets" from intensity
+}
+
+// TrainingLoadDay is the training load of a day and the averages derived from it
+type TrainingLoadDay struct {
+	Date string   `json:"date"`           // Day in the user's timezone
+	Load float64  `json:"load"`           // Total load of the day's workouts
+	ATL  float64  `json:"atl"`            // Acute training load, 7 day exponentially weighted average
+	CTL  float64  `json:"ctl"`            // Chronic training load, 42 day exponentially weighted average
+	TSB  float64  `json:"tsb"`            // Training stress balance, yesterday's CTL minus ATL
+	ACWR *float64 `json:"acwr,omitempty"` // Acute to chronic workload ratio, unset until the chronic window is covered
+	Risk string   `json:"risk,omitempty"` // Injury risk band of the ratio: undertraining, optimal, elevated or high
+}
+
+// TrainingLoad holds the daily training load series and the load of every workout
+type TrainingLoad struct {
+	Days     []TrainingLoadDay `json:"days"`     // Every day from the first workout to today
+	Workouts []WorkoutLoad     `json:"workouts"` // Load of every workout that could be scored
+}
+
+// TrendPoint is a period of an aggregated series and its moving averages
+type TrendPoint struct {
+	Period string   `json:"period"`        // Label of the period, see the calendar keys
+	Value  float64  `json:"value"`         // Aggregated value of the period
+	SMA    *float64 `json:"sma,omitempty"` // Simple moving average, unset until the window is full
+	EMA    float64  `json:"ema"`           // Exponential moving average
+}
+
+// Regression is a least squares line through a series, with the slope per period
+type Regression struct {
+	Slope     float64 `json:"slope"`     // Change per period
+	Intercept float64 `json:"intercept"` // Fitted value of the first period
+	StdErr    float64 `json:"stdErr"`    // Standard error of the slope
+	Lower     float64 `json:"lower"`     // Lower bound of the 95% confidence interval of the slope
+	Upper     float64 `json:"upper"`     // Upper bound of the 95% confidence interval of the slope
+	RSquared  float64 `json:"rSquared"`  // Share of the variance explained by the line
+	PValue    float64 `json:"pValue"`    // Probability of a slope this steep without a trend
+	Direction string  `json:"direction"` // Either "up", "down" or "flat" when the interval includes zero
+}
+
+// ForecastPoint is a forecast value of a future period with its 95% prediction interval
+type ForecastPoint struct {
+	Period string  `json:"period"` // Label of the period, see the calendar keys
+	Value  float64 `json:"value"`  // Forecast value
+	Lower  float64 `json:"lower"`  // Lower bound of the prediction interval
+	Upper  float64 `json:"upper"`  // Upper bound of the prediction interval
+}
+
+// Trends describes how an aggregated series develops and where it is heading
+type Trends struct {
+	Series     string          `json:"series"`     // Workout field or health metric of the series
+	Bucket     string          `json:"bucket"`     // Period size of the series
+	Window     int             `json:"window"`     // Periods in the moving averages
+	Points     []TrendPoint    `json:"points"`     // Completed periods, oldest first
+	Regression *Regression     `json:"regression"` // Linear trend, null with fewer than three periods
+	Method     string          `json:"method"`     // Forecast method: holt-winters, or holt without two full seasons
+	Season     int             `json:"season"`     // Periods in a season of the Holt-Winters forecast
+	Forecast   []ForecastPoint `json:"forecast"`   // Forecast of the following periods
+}
+
+// Anomaly is a day on which a health metric strayed from its rolling baseline
+type Anomaly struct {
+	Metric      string  `json:"metric"`      // Name of the metric
+	Date        string  `json:"date"`        // Day of the value
+	Value       float64 `json:"value"`       // Average value of the day
+	Baseline    float64 `json:"baseline"`    // Median of the rolling baseline
+	ZScore      float64 `json:"zScore"`      // Standard deviations from the baseline mean
+	RobustScore float64 `json:"robustScore"` // Modified z-score from the median absolute deviation, 0 when it is zero
+	Direction   string  `json:"direction"`   // Either "high" or "low"
+	Severity    string  `json:"severity"`    // Either "mild", "moderate" or "severe"
+	Threshold   float64 `json:"threshold"`   // Score that flags a day for the metric
+}
+
+// Correlation relates two daily series, the second shifted by a number of days
+type Correlation struct {
+	X           string  `json:"x"`           // First series
+	Y           string  `json:"y"`           // Second series
+	Lag         int     `json:"lag"`         // Days the second series is taken after the first
+	N           int     `json:"n"`           // Days on which both series have a value
+	Pearson     float64 `json:"pearson"`     // Pearson correlation coefficient
+	PearsonP    float64 `json:"pearsonP"`    // Two-sided p-value of the Pearson coefficient
+	Spearman    float64 `json:"spearman"`    // Spearman rank correlation coefficient
+	SpearmanP   float64 `json:"spearmanP"`   // Two-sided p-value of the Spearman coefficient
+	Significant bool    `json:"significant"` // Whether both p-values are below 0.05
+}
+
+// CorrelationMatrix holds the correlations between every pair of a set of series
+type CorrelationMatrix struct {
+	Series []string      `json:"series"` // Series in the order of the rows and columns
+	Lag    int           `json:"lag"`    // Days the column series is taken after the row series
+	Method string        `json:"method"` // Coefficient in the matrix: pearson or spearman
+	Matrix [][]float64   `json:"matrix"` // Coefficients with the row series first
+	Pairs  []Correlation `json:"pairs"`  // Every pair of series with sample sizes and significance
+}
+
+// HeartRateZone is a heart rate range of a zone model
+type HeartRateZone struct {
+	Zone int      `json:"zone"`           // Number of the zone, from 1
+	Name string   `json:"name"`           // Name of the zone, such as "Endurance"
+	Low  float64  `json:"low"`            // Heart rate the zone starts at in bpm, zone 1 also holds the time below it
+	High *float64 `json:"high,omitempty"` // Heart rate the next zone starts at, unset for the top zone
+}
+
+// ZoneTime is the time spent in a heart rate zone
+type ZoneTime struct {
+	Zone    int     `json:"zone"`    // Number of the zone, from 1
+	Name    string  `json:"name"`    // Name of the zone
+	Seconds float64 `json:"seconds"` // Time spent in the zone
+	Percent float64 `json:"percent"` // Share of the time with a heart rate
+}
+
+// IntensityDistribution splits the time in zones into the low, moderate and
+// high intensity domains of the three zone model of polarized training
+type IntensityDistribution struct {
+	Low               float64  `json:"low"`                         // Percent of the time in zones 1 and 2
+	Moderate          float64  `json:"moderate"`                    // Percent of the time in zone 3
+	High              float64  `json:"high"`                        // Percent of the time in zones 4 and 5
+	PolarizationIndex *float64 `json:"polarizationIndex,omitempty"` // Polarization index of Treff et al., unset without time
+	Pattern           string   `json:"pattern,omitempty"`           // Either "polarized", "pyramidal", "threshold" or "high-intensity"
+}
+
+// WorkoutZones is the time a workout spent in every heart rate zone
+type WorkoutZones struct {
+	WorkoutID    string                `json:"workoutId"`        // Workout the zones belong to
+	Model        string                `json:"model"`            // Zone model: max, reserve or threshold
+	Source       string                `json:"source,omitempty"` // Either "samples" or "average", empty without heart rate
+	Zones        []HeartRateZone       `json:"zones"`            // Heart rate ranges of the model
+	Time         []ZoneTime            `json:"time"`             // Time spent in every zone
+	Distribution IntensityDistribution `json:"distribution"`     // Time per intensity domain
+}
+
+// ZonePeriod is the time spent in every heart rate zone during a period
+type ZonePeriod struct {
+	Period       string                `json:"period"`       // Label of the period, see the calendar keys
+	Workouts     int                   `json:"workouts"`     // Workouts with a heart rate in the period
+	Zone1        float64               `json:"zone1"`        // Seconds in zone 1
+	Zone2        float64               `json:"zone2"`        // Seconds in zone 2
+	Zone3        float64               `json:"zone3"`        // Seconds in zone 3
+	Zone4        float64               `json:"zone4"`        // Seconds in zone 4
+	Zone5        float64               `json:"zone5"`        // Seconds in zone 5
+	Total        float64               `json:"total"`        // Seconds with a heart rate
+	Distribution IntensityDistribution `json:"distribution"` // Time per intensity domain
+}
+
+// ZoneTrends holds the time in zones of every period
+type ZoneTrends struct {
+	Model   string          `json:"model"`   // Zone model: max, reserve or threshold
+	Bucket  string          `json:"bucket"`  // Period of the rollups
+	Zones   []HeartRateZone `json:"zones"`   // Heart rate ranges of the model
+	Periods []ZonePeriod    `json:"periods"` // Every period from the first to the last workout with a heart rate
+}
+
+// ZoneDistribution reports how training time is distributed over the zones
+type ZoneDistribution struct {
+	Model        string                `json:"model"`        // Zone model: max, reserve or threshold
+	Zones        []HeartRateZone       `json:"zones"`        // Heart rate ranges of the model
+	Workouts     int                   `json:"workouts"`     // Workouts with a heart rate
+	Samples      int                   `json:"samples"`      // Workouts whose zones come from heart rate samples
+	Time         []ZoneTime            `json:"time"`         // Time spent in every zone
+	Distribution IntensityDistribution `json:"distribution"` // Time per intensity domain
+}
+
+// RaceEffort is a running effort race times can be predicted from
+type RaceEffort struct {
+	WorkoutID string  `json:"workoutId"` // Workout the effort was run in
+	Name      string  `json:"name"`      // Name of the workout type
+	Date      string  `json:"date"`      // Start of the workout
+	Distance  string  `json:"distance"`  // Standard distance of a best effort, or "workout" for the whole workout
+	Meters    float64 `json:"meters"`    // Distance of the effort in meters
+	Seconds   float64 `json:"seconds"`   // Time taken for the distance
+	Time      string  `json:"time"`      // Time formatted as mm:ss or h:mm:ss
+	VDOT      float64 `json:"vdot"`      // Daniels VDOT of the performance, the effort with the highest one is used
+	Used      bool    `json:"used"`      // Whether the Riegel and Cameron predictions start from the effort
+}
+
+// VO2Max is the maximal oxygen uptake, in ml/kg/min, VDOT predictions use
+type VO2Max struct {
+	Value    float64  `json:"value"`              // Maximal oxygen uptake
+	Source   string   `json:"source"`             // Either "metric", "heart rate" or "effort"
+	Date     string   `json:"date,omitempty"`     // Date of the metric data point
+	Workouts []string `json:"workouts,omitempty"` // Workouts the value was estimated from
+}
+
+// RacePrediction is the predicted finish time of a race distance
+type RacePrediction struct {
+	Distance    string   `json:"distance"`              // Name of the distance, such as "10k"
+	Meters      float64  `json:"meters"`                // Distance in meters
+	Riegel      *float64 `json:"riegel,omitempty"`      // Seconds predicted by Riegel's formula, unset without efforts
+	RiegelTime  string   `json:"riegelTime,omitempty"`  // Riegel prediction formatted as mm:ss or h:mm:ss
+	Cameron     *float64 `json:"cameron,omitempty"`     // Seconds predicted by Cameron's formula, unset without efforts
+	CameronTime string   `json:"cameronTime,omitempty"` // Cameron prediction formatted as mm:ss or h:mm:ss
+	VDOT        *float64 `json:"vdot,omitempty"`        // Seconds predicted from the VO2max, unset without one
+	VDOTTime    string   `json:"vdotTime,omitempty"`    // VDOT prediction formatted as mm:ss or h:mm:ss
+}
+
+// RacePredictions holds the race predictions and what they were based on
+type RacePredictions struct {
+	Since       string           `json:"since"`            // First day efforts were taken from
+	Efforts     []RaceEffort     `json:"efforts"`          // Efforts considered, the used one first
+	VO2Max      *VO2Max          `json:"vo2Max,omitempty"` // Maximal oxygen uptake, unset without metric, heart rate or effort
+	Predictions []RacePrediction `json:"predictions"`      // Predictions of the common race distances, empty without efforts or VO2max
+	Notes       []string         `json:"notes"`            // How the predictions were made
+}
+
+// EnergyBalanceDay is the energy eaten and burned on a day, in kcal
+type EnergyBalanceDay struct {
+	Date         string   `json:"date"`                   // Day of the data
+	Intake       *float64 `json:"intake,omitempty"`       // Dietary energy, unset when nothing was logged
+	Basal        *float64 `json:"basal,omitempty"`        // Basal energy burned, unset without data
+	Active       float64  `json:"active"`                 // Active energy burned, workouts included
+	ActiveSource string   `json:"activeSource,omitempty"` // Either "metric" for active_energy or "workouts" when the metric is missing
+	Expenditure  *float64 `json:"expenditure,omitempty"`  // Basal plus active energy, unset without basal energy
+	Balance      *float64 `json:"balance,omitempty"`      // Intake minus expenditure, unset without both
+	Rolling      *float64 `json:"rolling,omitempty"`      // Average balance of the days with one in the window ending on the day
+	Projected    float64  `json:"projected"`              // Weight change in kg the balances add up to so far
+	Weight       *float64 `json:"weight,omitempty"`       // Average body weight of the day in kg
+}
+
+// WeightCheck compares the weight change the energy balance projects with the
+// change of the weight metric
+type WeightCheck struct {
+	From        string  `json:"from"`        // First weigh-in
+	To          string  `json:"to"`          // Last weigh-in
+	WeighIns    int     `json:"weighIns"`    // Days with a weight
+	Actual      float64 `json:"actual"`      // Change in kg of the line fitted through the weights
+	Projected   float64 `json:"projected"`   // Change in kg projected from the average balance over the same days
+	Discrepancy float64 `json:"discrepancy"` // Daily kcal the balance misses to explain the actual change
+	Agrees      bool    `json:"agrees"`      // Whether the discrepancy is within 250 kcal a day
+}
+
+// EnergyBalance holds the daily energy balance and the weight change it implies
+type EnergyBalance struct {
+	Window          int                `json:"window"`           // Days in the rolling average
+	Days            []EnergyBalanceDay `json:"days"`             // Every day with energy or weight data
+	CompleteDays    int                `json:"completeDays"`     // Days with both intake and expenditure
+	AverageBalance  float64            `json:"averageBalance"`   // Average daily balance of the complete days
+	ProjectedChange float64            `json:"projectedChange"`  // Weight change in kg the balances add up to
+	WeeklyChange    float64            `json:"weeklyChange"`     // Weight change in kg a week at the average balance
+	Weight          *WeightCheck       `json:"weight,omitempty"` // Check against the weight metric, unset without enough weigh-ins
+}
@@ -1,6 +1,8 @@
 // models/types.go
 package models
 
+import "time"
+
 // HealthData is the top-level struct that contains all health data
 type HealthData struct {
 	Data        DataCollection `json:"data"`        // Collection of workout and metric data
@@ -36,12 +38,88 @@ type Workout struct {
 	} `json:"humidity,omitempty"`
 	Temperature *Measurement `json:"temperature,omitempty"` // Temperature during the workout
 	LapLength   *Measurement `json:"lapLength,omitempty"`   // Length of each lap during the workout
+	Route       []RoutePoint `json:"route,omitempty"`       // GPS route recorded during the workout
+	HeartRate   *HeartRate   `json:"heartRate,omitempty"`   // Heart rate summary of the workout
+
+	HeartRateData             []HeartRateSample `json:"heartRateData,omitempty"`             // Heart rate measured per interval
+	WalkingAndRunningDistance []QuantitySample  `json:"walkingAndRunningDistance,omitempty"` // Distance covered per interval
+
+	Pace *Pace `json:"pace,omitempty"` // Average pace and speed, derived when the workout is served and never stored
+}
+
+// Pace is the average pace and speed of a workout in a unit system
+type Pace struct {
+	Units         string  `json:"units"`                   // Unit system: metric or imperial
+	Distance      float64 `json:"distance"`                // Distance in kilometers or miles
+	Pace          float64 `json:"pace"`                    // Average seconds per kilometer or mile
+	PaceText      string  `json:"paceText"`                // Average pace formatted as mm:ss
+	Speed         float64 `json:"speed"`                   // Average speed in km/h or mph
+	NegativeSplit *bool   `json:"negativeSplit,omitempty"` // Whether the second half was faster, unset without route or samples
+}
+
+// Split is the time taken for one kilometer or mile of a workout
+type Split struct {
+	Split    int     `json:"split"`    // Number of the split, from 1
+	Distance float64 `json:"distance"` // Length of the split in kilometers or miles, shorter for the last one
+	Elapsed  float64 `json:"elapsed"`  // Seconds since the start at the end of the split
+	Duration float64 `json:"duration"` // Seconds taken for the split
+	Pace     float64 `json:"pace"`     // Seconds per kilometer or mile
+	PaceText string  `json:"paceText"` // Pace formatted as mm:ss
+	Speed    float64 `json:"speed"`    // Speed in km/h or mph
+}
+
+// WorkoutSplits are the splits of a workout and how its halves compare
+type WorkoutSplits struct {
+	WorkoutID     string  `json:"workoutId"`     // Workout the splits belong to
+	Units         string  `json:"units"`         // Unit system: metric or imperial
+	Pace          *Pace   `json:"pace"`          // Average pace and speed, null without a distance
+	Splits        []Split `json:"splits"`        // Splits in order, empty without route or samples
+	FirstHalf     float64 `json:"firstHalf"`     // Seconds taken for the first half of the distance
+	SecondHalf    float64 `json:"secondHalf"`    // Seconds taken for the second half of the distance
+	NegativeSplit bool    `json:"negativeSplit"` // Whether the second half was faster than the first
+}
+
+// HeartRate summarizes the heart rate of a workout, in bpm
+type HeartRate struct {
+	Min *Measurement `json:"min,omitempty"` // Lowest heart rate
+	Avg *Measurement `json:"avg,omitempty"` // Average heart rate
+	Max *Measurement `json:"max,omitempty"` // Highest heart rate
+}
+
+// HeartRateSample is the heart rate measured over the interval starting at Date,
+// in bpm. Health Auto Export capitalizes the statistics.
+type HeartRateSample struct {
+	Date   string  `json:"date"`             // Start of the interval
+	Min    float64 `json:"Min,omitempty"`    // Lowest heart rate of the interval
+	Avg    float64 `json:"Avg"`              // Average heart rate of the interval
+	Max    float64 `json:"Max,omitempty"`    // Highest heart rate of the interval
+	Units  string  `json:"units,omitempty"`  // Units of the heart rate, count/min
+	Source string  `json:"source,omitempty"` // Device or app that recorded the sample
+}
+
+// RoutePoint is a GPS location recorded during a workout
+type RoutePoint struct {
+	Latitude  float64 `json:"latitude"`           // Latitude in degrees
+	Longitude float64 `json:"longitude"`          // Longitude in degrees
+	Altitude  float64 `json:"altitude,omitempty"` // Altitude in meters
+	Speed     float64 `json:"speed,omitempty"`    // Speed in meters per second
+	Timestamp string  `json:"timestamp"`          // Time the location was recorded
+}
+
+// QuantitySample is a quantity measured over the interval starting at Date
+type QuantitySample struct {
+	Date   string  `json:"date"`             // Start of the interval
+	Qty    float64 `json:"qty"`              // Quantity measured over the interval
+	Units  string  `json:"units"`            // Units of the quantity
+	Source string  `json:"source,omitempty"` // Device or app that recorded the sample
 }
 
 // MetricData represents a single data point for a metric
 type MetricData struct {
-	Date string  `json:"date"` // Date of the data point
-	Qty  float64 `json:"qty"`  // Quantity of the data point
+	Date string  `json:"date" openapi:"optional"` // Date of the data point, the start of the stage for sleep stages
+	Qty  float64 `json:"qty" openapi:"optional"`  // Quantity of the data point, absent from nightly sleep summaries
+
+	*SleepData // Sleep fields, only set on sleep_analysis data points
 }
 
 // Metric represents a single metric entry
@@ -50,3 +128,53 @@ type Metric struct {
 	Data  []MetricData `json:"data"`  // Collection of data points for the metric
 	Units string       `json:"units"` // Units of the metric
 }
+
+// WorkoutEdit records a change made to a workout through the API
+type WorkoutEdit struct {
+	ID        string    `json:"id"`               // Unique identifier for the edit
+	WorkoutID string    `json:"workoutId"`        // Workout that was changed
+	Action    string    `json:"action"`           // Either "update" or "delete"
+	Time      time.Time `json:"time"`             // Time the edit was made
+	Before    *Workout  `json:"before,omitempty"` // Workout before the edit
+	After     *Workout  `json:"after,omitempty"`  // Workout after the edit, nil when deleted
+}
+
+// IngestResult summarizes the data merged into a user's store by an import
+type IngestResult struct {
+	Workouts     int    `json:"workouts"`     // Number of workouts added or replaced
+	MetricPoints int    `json:"metricPoints"` // Number of metric data points added or replaced
+	Version      uint64 `json:"version"`      // Data version after the import
+}
+
+// HealthStatus is the response of the liveness and readiness checks
+type HealthStatus struct {
+	Status string `json:"status"` // Either "ok", "importing" or "shutting down"
+}
+
+// Categories of personal records
+const (
+	RecordLongestDistance = "longestDistance" // Longest distance in a single workout, in km
+	RecordLongestDuration = "longestDuration" // Longest workout, in seconds
+	RecordMostEnergy      = "mostEnergy"      // Most active energy burned in a workout, in kcal
+	RecordFastestPace     = "fastestPace"     // Fastest average pace of a workout, in seconds per km
+	RecordBestEffort      = "bestEffort"      // Fastest time over a standard distance, in seconds
+)
+
+// PersonalRecord is a best value for a workout type, set by a single workout
+type PersonalRecord struct {
+	WorkoutType string   `json:"workoutType"`        // Name of the workout type, such as "Outdoor Run"
+	Category    string   `json:"category"`           // One of the Record* categories
+	Distance    string   `json:"distance,omitempty"` // Standard distance of a best effort, such as "5k"
+	Value       float64  `json:"value"`              // Value of the record
+	Units       string   `json:"units"`              // Units of the value
+	WorkoutID   string   `json:"workoutId"`          // Workout that set the record
+	Date        string   `json:"date"`               // Start of the workout that set the record
+	Previous    *float64 `json:"previous,omitempty"` // Value of the record it replaced
+	Current     bool     `json:"current"`            // Whether the record still stands
+}
+
+// Records lists the standing personal records and every record set over time
+type Records struct {
+	Current []PersonalRecord `json:"current"` // Records that still stand
+	History []PersonalRecord `json:"history"` // Every record set, oldest first
+}
@@ -0,0 +1,68 @@
+// models/users.go
+package models
+
+import "time"
+
+// User is a registered account
+type User struct {
+	ID        string    `json:"id"`        // Unique identifier for the user
+	Email     string    `json:"email"`     // Email address used to sign in
+	CreatedAt time.Time `json:"createdAt"` // Time the account was created
+	Profile   Profile   `json:"profile"`   // Personal settings of the user
+}
+
+// Profile holds the personal settings used when analyzing a user's data
+type Profile struct {
+	Name      string `json:"name,omitempty"`      // Display name of the user
+	Timezone  string `json:"timezone,omitempty"`  // IANA timezone used for day boundaries
+	WeekStart string `json:"weekStart,omitempty"` // First day of the week, such as "monday"
+
+	RestingHeartRate float64 `json:"restingHeartRate,omitempty"` // Resting heart rate in bpm, from the resting_heart_rate metric when unset
+	MaxHeartRate     float64 `json:"maxHeartRate,omitempty"`     // Maximum heart rate in bpm, from the highest workout heart rate when unset
+	Sex              string  `json:"sex,omitempty"`              // Either "male" or "female", weights the training impulse
+	Units            string  `json:"units,omitempty"`            // Either "metric" or "imperial", metric by default
+
+	LactateThresholdHeartRate float64 `json:"lactateThresholdHeartRate,omitempty"` // Lactate threshold heart rate in bpm, 90% of the maximum when unset
+	ZoneModel                 string  `json:"zoneModel,omitempty"`                 // Heart rate zones: "max", "reserve" or "threshold", max by default
+}
+
+// Signup is the request body used to register an account
+type Signup struct {
+	Email    string `json:"email"`          // Email address used to sign in
+	Password string `json:"password"`       // Password of 8 characters to 72 bytes
+	Name     string `json:"name,omitempty"` // Display name of the user
+}
+
+// Credentials is the request body used to sign in
+type Credentials struct {
+	Email    string `json:"email"`    // Email address used to sign in
+	Password string `json:"password"` // Password of the account
+}
+
+// RefreshRequest carries a refresh token when it is not sent as a cookie
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken,omitempty"` // Refresh token issued at sign in
+}
+
+// Session is returned when signing in or refreshing a session
+type Session struct {
+	TokenType    string `json:"tokenType"`    // Always "Bearer"
+	AccessToken  string `json:"accessToken"`  // Short-lived JWT sent as a bearer token
+	RefreshToken string `json:"refreshToken"` // Long-lived token used to obtain a new access token
+	ExpiresIn    int    `json:"expiresIn"`    // Lifetime of the access token in seconds
+	User         User   `json:"user"`         // The signed in user
+}
+
+// APIToken describes a long-lived personal API token
+type APIToken struct {
+	ID        string     `json:"id"`                 // Unique identifier for the token
+	Name      string     `json:"name"`               // Name given to the token, such as "ml-server"
+	CreatedAt time.Time  `json:"createdAt"`          // Time the token was created
+	LastUsed  *time.Time `json:"lastUsed,omitempty"` // Time the token was last used
+	Token     string     `json:"token,omitempty"`    // The secret token, only returned when created
+}
+
+// NewAPIToken is the request body used to create a personal API token
+type NewAPIToken struct {
+	Name string `json:"name"` // Name given to the token
+}
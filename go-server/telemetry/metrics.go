@@ -0,0 +1,42 @@
+// telemetry/metrics.go
+// Metrics recorded by the server
+
+package telemetry
+
+// HTTP requests, labelled by the route pattern so path parameters do not create new series
+var (
+	HTTPRequests = NewCounter("fitness_http_requests_total",
+		"HTTP requests served, by method, route and status code", "method", "route", "status")
+	HTTPRequestDuration = NewHistogram("fitness_http_request_duration_seconds",
+		"Time taken to serve HTTP requests, by method and route", DurationBuckets, "method", "route")
+)
+
+// Imports from Health Auto Export directories and pushes to /ingest
+var (
+	ImportRuns = NewCounter("fitness_import_runs_total",
+		"Imports run per user, by source (import or ingest) and result (success or failure)", "source", "result")
+	ImportFilesProcessed = NewCounter("fitness_import_files_processed_total",
+		"Health Auto Export files merged into a user's data")
+	ImportFilesFailed = NewCounter("fitness_import_files_failed_total",
+		"Health Auto Export files skipped because they could not be read or parsed")
+	LastImportSuccess = NewGauge("fitness_last_import_success_timestamp_seconds",
+		"Unix time of the last successful import")
+	SecondsSinceLastImport = NewGauge("fitness_seconds_since_last_import",
+		"Seconds since the last successful import, set when scraped")
+)
+
+// Data held in memory, set when scraped
+var (
+	WorkoutsInMemory = NewGauge("fitness_workouts",
+		"Workouts held in memory across all users")
+	MetricPointsInMemory = NewGauge("fitness_metric_points",
+		"Metric data points held in memory across all users")
+)
+
+// Cache writes
+var (
+	CacheWriteDuration = NewHistogram("fitness_cache_write_duration_seconds",
+		"Time taken to marshal and write a user's cache file", DurationBuckets)
+	CacheWriteBytes = NewHistogram("fitness_cache_write_bytes",
+		"Size of the cache files written", SizeBuckets)
+)
@@ -0,0 +1,81 @@
+// test/anomalies_test.go
+
+package test
+
+import (
+	"encoding/json"
+	"fitness/models"
+	"fitness/utils"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// restingHeartRate returns a day of resting heart rate, varying a little from day to day
+func restingHeartRate(day int, bpm float64) models.MetricData {
+	date := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, day)
+	return models.MetricData{Date: date.Format("2006-01-02 15:04:05 -0700"), Qty: bpm + float64(day%3)}
+}
+
+func TestDetectAnomalies(t *testing.T) {
+	metric := models.Metric{Name: "resting_heart_rate"}
+	for day := 0; day < 20; day++ {
+		metric.Data = append(metric.Data, restingHeartRate(day, 54))
+	}
+	metric.Data = append(metric.Data, restingHeartRate(20, 64), restingHeartRate(21, 46))
+	rules := utils.AnomalyRules{Window: 28, MinHistory: 7, Threshold: 2.5}
+
+	anomalies := utils.DetectAnomalies(metric.Name, utils.DailyMetricValues(metric), rules, "")
+	require.Len(t, anomalies, 2)
+	assert.Equal(t, "2024-05-21", anomalies[0].Date)
+	assert.Equal(t, "high", anomalies[0].Direction)
+	assert.Equal(t, "severe", anomalies[0].Severity)
+	assert.Equal(t, 55.0, anomalies[0].Baseline)
+	assert.Equal(t, "low", anomalies[1].Direction)
+
+	// Scanning from a day only reports the days after it
+	anomalies = utils.DetectAnomalies(metric.Name, utils.DailyMetricValues(metric), rules, "2024-05-22")
+	require.Len(t, anomalies, 1)
+	assert.Equal(t, "2024-05-22", anomalies[0].Date)
+
+	// A less sensitive threshold only flags the spike
+	rules.Threshold = 4
+	anomalies = utils.DetectAnomalies(metric.Name, utils.DailyMetricValues(metric), rules, "")
+	require.Len(t, anomalies, 1)
+	assert.Equal(t, "high", anomalies[0].Direction)
+}
+
+func TestAnomaliesScannedOnIngest(t *testing.T) {
+	sleeper := signup(t, "restless@example.com")
+	points := func(data ...models.MetricData) string {
+		encoded, err := json.Marshal(data)
+		require.NoError(t, err)
+		return fmt.Sprintf(`{"data": {"workouts": [], "metrics": [{"name": "resting_heart_rate", "units": "count/min", "data": %s}]}}`, encoded)
+	}
+	var baseline []models.MetricData
+	for day := 0; day < 20; day++ {
+		baseline = append(baseline, restingHeartRate(day, 54))
+	}
+	ingest(t, sleeper.AccessToken, points(baseline...))
+	assert.Equal(t, "[]\n", serve(http.MethodGet, "/anomalies", "", sleeper.AccessToken).Body.String())
+
+	// The next import only rescans from the new day
+	ingest(t, sleeper.AccessToken, points(restingHeartRate(20, 66)))
+	response := serve(http.MethodGet, "/anomalies?metric=resting_heart_rate&start=2024-05-01", "", sleeper.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code)
+	var anomalies []models.Anomaly
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &anomalies))
+	require.Len(t, anomalies, 1)
+	assert.Equal(t, "2024-05-21", anomalies[0].Date)
+	assert.Equal(t, 2.5, anomalies[0].Threshold)
+
+	for _, target := range []string{"/anomalies?metric=step_count", "/anomalies?end=2024-05-20"} {
+		response = serve(http.MethodGet, target, "", sleeper.AccessToken)
+		assert.Equal(t, "[]", strings.TrimSpace(response.Body.String()), target)
+	}
+}
@@ -0,0 +1,156 @@
+// test/auth_test.go
+
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fitness/api"
+	"fitness/config"
+	"fitness/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testSessionOnce sync.Once
+	testSession     models.Session
+)
+
+// serve sends a request through the registered routes
+func serve(method, target, body, token string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	response := httptest.NewRecorder()
+	serveAPI().ServeHTTP(response, request)
+	return response
+}
+
+// signup registers an account and returns its session
+func signup(t *testing.T, email string) models.Session {
+	response := serve(http.MethodPost, "/auth/signup", `{"email": "`+email+`", "password": "correct horse battery"}`, "")
+	require.Equal(t, http.StatusCreated, response.Code, response.Body.String())
+
+	var session models.Session
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &session))
+	return session
+}
+
+// sharedSession returns the session of a shared test user
+func sharedSession(t *testing.T) models.Session {
+	testSessionOnce.Do(func() {
+		testSession = signup(t, "runner@example.com")
+	})
+	return testSession
+}
+
+// authorize signs the request in as the shared test user
+func authorize(t *testing.T, request *http.Request) {
+	request.Header.Set("Authorization", "Bearer "+sharedSession(t).AccessToken)
+}
+
+func TestDataRoutesRequireAuthentication(t *testing.T) {
+	response := serve(http.MethodGet, "/workouts", "", "")
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+	assert.NotEmpty(t, response.Header().Get("WWW-Authenticate"))
+
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/workouts", "", "not-a-token").Code)
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/openapi.json", "", "").Code)
+}
+
+func TestLoginRefreshAndLogout(t *testing.T) {
+	signup(t, "swimmer@example.com")
+	assert.Equal(t, http.StatusConflict, serve(http.MethodPost, "/auth/signup", `{"email": "Swimmer@example.com", "password": "another password"}`, "").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/auth/signup", `{"email": "diver@example.com", "password": "short"}`, "").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/auth/signup", `{"email": "diver@example.com", "password": "`+strings.Repeat("x", 73)+`"}`, "").Code)
+
+	wrong := serve(http.MethodPost, "/auth/login", `{"email": "swimmer@example.com", "password": "wrong password"}`, "")
+	assert.Equal(t, http.StatusUnauthorized, wrong.Code)
+
+	login := serve(http.MethodPost, "/auth/login", `{"email": "swimmer@example.com", "password": "correct horse battery"}`, "")
+	require.Equal(t, http.StatusOK, login.Code)
+	var session models.Session
+	require.NoError(t, json.Unmarshal(login.Body.Bytes(), &session))
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/auth/me", "", session.AccessToken).Code)
+
+	// Refresh tokens are single use
+	refresh := serve(http.MethodPost, "/auth/refresh", `{"refreshToken": "`+session.RefreshToken+`"}`, "")
+	require.Equal(t, http.StatusOK, refresh.Code)
+	var refreshed models.Session
+	require.NoError(t, json.Unmarshal(refresh.Body.Bytes(), &refreshed))
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodPost, "/auth/refresh", `{"refreshToken": "`+session.RefreshToken+`"}`, "").Code)
+
+	// Signing out revokes the access token right away
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodPost, "/auth/logout", `{"refreshToken": "`+refreshed.RefreshToken+`"}`, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/auth/me", "", refreshed.AccessToken).Code)
+}
+
+func TestPersonalAPITokens(t *testing.T) {
+	session := signup(t, "cyclist@example.com")
+
+	create := serve(http.MethodPost, "/auth/tokens", `{"name": "ml-server"}`, session.AccessToken)
+	require.Equal(t, http.StatusCreated, create.Code)
+	var token models.APIToken
+	require.NoError(t, json.Unmarshal(create.Body.Bytes(), &token))
+	require.NotEmpty(t, token.Token)
+
+	me := serve(http.MethodGet, "/auth/me", "", token.Token)
+	require.Equal(t, http.StatusOK, me.Code)
+	var user models.User
+	require.NoError(t, json.Unmarshal(me.Body.Bytes(), &user))
+	assert.Equal(t, "cyclist@example.com", user.Email)
+
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodDelete, "/auth/tokens/"+token.ID, "", session.AccessToken).Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/auth/me", "", token.Token).Code)
+}
+
+func TestProfileUpdatesArePartial(t *testing.T) {
+	session := signup(t, "partial@example.com")
+	require.Equal(t, http.StatusOK, serve(http.MethodPatch, "/auth/me",
+		`{"name": "Sam", "timezone": "Europe/Paris", "restingHeartRate": 50, "maxHeartRate": 190, "sex": "female"}`, session.AccessToken).Code)
+
+	response := serve(http.MethodPatch, "/auth/me", `{"units": "imperial"}`, session.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	var user models.User
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &user))
+	assert.Equal(t, models.Profile{
+		Name: "Sam", Timezone: "Europe/Paris", RestingHeartRate: 50, MaxHeartRate: 190, Sex: "female", Units: "imperial",
+	}, user.Profile, "Fields missing from the body are kept.")
+
+	// The merged profile is validated, so a resting rate above the stored maximum is rejected
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPatch, "/auth/me", `{"restingHeartRate": 195}`, session.AccessToken).Code)
+	me := serve(http.MethodGet, "/auth/me", "", session.AccessToken)
+	require.NoError(t, json.Unmarshal(me.Body.Bytes(), &user))
+	assert.Equal(t, 50.0, user.Profile.RestingHeartRate)
+}
+
+func TestSignupWaitsForOwnerAccount(t *testing.T) {
+	config.OwnerEmail = "owner@example.com"
+	defer func() { config.OwnerEmail = "" }()
+
+	// Nobody can claim the owner address, or any other, before the owner account exists
+	claim := `{"email": "owner@example.com", "password": "correct horse battery"}`
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodPost, "/auth/signup", claim, "").Code)
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodPost, "/auth/signup", `{"email": "guest@example.com", "password": "correct horse battery"}`, "").Code)
+
+	var output bytes.Buffer
+	api.CLIInput = strings.NewReader("owner password\n")
+	require.NoError(t, api.RunAddUser([]string{"-name", "Owner", "owner@example.com"}, &output))
+	assert.Contains(t, output.String(), "Created user owner@example.com")
+
+	login := serve(http.MethodPost, "/auth/login", `{"email": "owner@example.com", "password": "owner password"}`, "")
+	assert.Equal(t, http.StatusOK, login.Code)
+	assert.Equal(t, http.StatusConflict, serve(http.MethodPost, "/auth/signup", claim, "").Code)
+	signup(t, "guest@example.com")
+}
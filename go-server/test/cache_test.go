@@ -0,0 +1,80 @@
+// test/cache_test.go
+
+package test
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fitness/data"
+	"fitness/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cachedRequest(t *testing.T, headers map[string]string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, "/workouts?workout=Outdoor%20Run", nil)
+	authorize(t, request)
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+	response := httptest.NewRecorder()
+	serveAPI().ServeHTTP(response, request)
+	return response
+}
+
+// ingestRun stores a run for the shared test user
+func ingestRun(t *testing.T, id string) {
+	_, err := data.ForUser(sharedSession(t).User).Ingest(models.HealthData{Data: models.DataCollection{
+		Workouts: []models.Workout{{ID: id, Name: "Outdoor Run", Start: "2024-03-04 07:00:00 +0000"}},
+	}})
+	require.NoError(t, err)
+}
+
+func TestConditionalRequestsFollowDataVersion(t *testing.T) {
+	ingestRun(t, "1")
+	first := cachedRequest(t, nil)
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.NotEmpty(t, first.Header().Get("Last-Modified"))
+	assert.Equal(t, "private, no-cache", first.Header().Get("Cache-Control"), "Responses are per user and kept out of shared caches.")
+
+	// Unchanged data answers both kinds of conditional request with 304
+	assert.Equal(t, http.StatusNotModified, cachedRequest(t, map[string]string{"If-None-Match": etag}).Code)
+	assert.Equal(t, http.StatusNotModified, cachedRequest(t, map[string]string{"If-Modified-Since": first.Header().Get("Last-Modified")}).Code)
+
+	// A new import changes the version and invalidates the tag
+	ingestRun(t, "2")
+	changed := cachedRequest(t, map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusOK, changed.Code)
+	assert.NotEqual(t, etag, changed.Header().Get("ETag"))
+}
+
+func TestResponsesAreCompressed(t *testing.T) {
+	ingestRun(t, "1")
+
+	response := cachedRequest(t, map[string]string{"Accept-Encoding": "br;q=0.5, gzip;q=0.8"})
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "gzip", response.Header().Get("Content-Encoding"))
+	reader, err := gzip.NewReader(response.Body)
+	require.NoError(t, err)
+	var workouts []models.Workout
+	require.NoError(t, json.NewDecoder(reader).Decode(&workouts))
+	assert.NotEmpty(t, workouts)
+
+	// Brotli wins ties
+	response = cachedRequest(t, map[string]string{"Accept-Encoding": "gzip, deflate, br"})
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "br", response.Header().Get("Content-Encoding"))
+	workouts = nil
+	require.NoError(t, json.NewDecoder(brotli.NewReader(response.Body)).Decode(&workouts))
+	assert.NotEmpty(t, workouts)
+
+	response = cachedRequest(t, map[string]string{"Accept-Encoding": "identity"})
+	assert.Empty(t, response.Header().Get("Content-Encoding"))
+}
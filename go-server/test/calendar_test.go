@@ -0,0 +1,79 @@
+// test/calendar_test.go
+
+package test
+
+import (
+	"fitness/models"
+	"fitness/utils"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustCalendar(t *testing.T, timezone string, weekStart time.Weekday) utils.Calendar {
+	calendar, err := utils.NewCalendar(timezone, weekStart)
+	require.NoError(t, err)
+	return calendar
+}
+
+func TestCalendarSundayBelongsToPreviousWeek(t *testing.T) {
+	calendar := mustCalendar(t, "UTC", time.Monday)
+	sunday := time.Date(2024, time.March, 10, 9, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "2024-03-04", calendar.Key(sunday, utils.Week))
+	assert.Equal(t, "2024-W10", calendar.Key(sunday, utils.ISOWeek))
+
+	// Weeks starting on Sunday put the same workout in the next week
+	sundayStart := mustCalendar(t, "UTC", time.Sunday)
+	assert.Equal(t, "2024-03-10", sundayStart.Key(sunday, utils.Week))
+}
+
+func TestCalendarISOWeekAcrossYearBoundary(t *testing.T) {
+	calendar := mustCalendar(t, "UTC", time.Sunday)
+
+	// ISO weeks start on Monday regardless of the configured week start
+	assert.Equal(t, "2025-W01", calendar.Key(time.Date(2024, time.December, 30, 12, 0, 0, 0, time.UTC), utils.ISOWeek))
+	assert.Equal(t, "2020-W53", calendar.Key(time.Date(2021, time.January, 3, 12, 0, 0, 0, time.UTC), utils.ISOWeek))
+}
+
+func TestCalendarUsesTimezone(t *testing.T) {
+	calendar := mustCalendar(t, "America/Los_Angeles", time.Monday)
+
+	// Monday 01:00 UTC is still Sunday evening in Los Angeles
+	instant := time.Date(2024, time.March, 4, 1, 0, 0, 0, time.UTC)
+	assert.Equal(t, "2024-03-03", calendar.Key(instant, utils.Day))
+	assert.Equal(t, "2024-02-26", calendar.Key(instant, utils.Week))
+}
+
+func TestCalendarRangeAcrossDST(t *testing.T) {
+	calendar := mustCalendar(t, "America/Los_Angeles", time.Monday)
+	from := time.Date(2024, time.March, 9, 12, 0, 0, 0, calendar.Location)
+	to := time.Date(2024, time.March, 12, 12, 0, 0, 0, calendar.Location)
+
+	days := calendar.Range(from, to, utils.Day)
+	require.Len(t, days, 4)
+	for _, day := range days {
+		assert.Equal(t, 0, day.Hour(), "Expected every day to start at local midnight.")
+	}
+	// The day of the spring-forward change is only 23 hours long
+	assert.Equal(t, 23*time.Hour, days[2].Sub(days[1]))
+}
+
+func TestAggregateFillsEmptyWeeks(t *testing.T) {
+	calendar := mustCalendar(t, "UTC", time.Monday)
+	workouts := []models.Workout{
+		{Name: "Outdoor Run", Start: "2024-03-10 07:00:00 +0000", Distance: &models.Measurement{Units: "mi", Qty: 10}},
+		{Name: "Outdoor Run", Start: "2024-03-25 07:00:00 +0000", Distance: &models.Measurement{Units: "mi", Qty: 3}},
+	}
+
+	distance := utils.CalculateDistancePerWeek(workouts, calendar)
+	assert.Equal(t, map[string]float64{
+		"2024-03-04": 10,
+		"2024-03-11": 0,
+		"2024-03-18": 0,
+		"2024-03-25": 3,
+	}, distance)
+}
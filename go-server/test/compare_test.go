@@ -0,0 +1,115 @@
+// test/compare_test.go
+
+package test
+
+import (
+	"encoding/json"
+	"fitness/config"
+	"fitness/models"
+	"fitness/utils"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComparisonPeriods(t *testing.T) {
+	calendar := mustCalendar(t, "UTC", time.Monday)
+
+	// Month to date is compared with the same days of the month before
+	current, previous := utils.ComparisonPeriods(calendar, utils.Month, 1, time.Date(2024, time.March, 10, 15, 0, 0, 0, time.UTC))
+	assert.Equal(t, models.ComparisonPeriod{Key: "2024-03", Start: "2024-03-01", End: "2024-03-10", Days: 10}, current)
+	assert.Equal(t, models.ComparisonPeriod{Key: "2024-02", Start: "2024-02-01", End: "2024-02-10", Days: 10}, previous)
+
+	// A shorter earlier month is not overrun
+	_, previous = utils.ComparisonPeriods(calendar, utils.Month, 1, time.Date(2024, time.March, 31, 12, 0, 0, 0, time.UTC))
+	assert.Equal(t, "2024-02-29", previous.End)
+	assert.Equal(t, 29, previous.Days)
+
+	// The offset reaches further back, here to the same week two weeks before
+	current, previous = utils.ComparisonPeriods(calendar, utils.Week, 2, time.Date(2024, time.March, 6, 12, 0, 0, 0, time.UTC))
+	assert.Equal(t, "2024-03-04", current.Start)
+	assert.Equal(t, models.ComparisonPeriod{Key: "2024-02-19", Start: "2024-02-19", End: "2024-02-21", Days: 3}, previous)
+
+	_, previous = utils.ComparisonPeriods(calendar, utils.Year, 1, time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, "2023-01-01", previous.Start)
+	assert.Equal(t, "2023-02-01", previous.End)
+}
+
+func TestCompareWorkouts(t *testing.T) {
+	workouts := reportWorkouts()
+	totals, averages := utils.CompareWorkouts(workouts[2:5], workouts[:2])
+
+	assert.Equal(t, "workouts", totals[0].Field)
+	assert.Equal(t, 3.0, totals[0].Current)
+	assert.Equal(t, 1.0, totals[0].Delta)
+	assert.Equal(t, 50.0, *totals[0].Percent)
+	assert.Equal(t, "distance", totals[2].Field)
+	assert.Equal(t, 10.0, totals[2].Delta)
+	assert.Equal(t, 200.0, *totals[2].Percent)
+
+	// Averages are per workout, a period without workouts averaging zero
+	assert.Equal(t, "duration", averages[0].Field)
+	assert.Equal(t, 2700.0, averages[0].Current)
+	assert.Equal(t, 1500.0, averages[0].Previous)
+	_, averages = utils.CompareWorkouts(workouts[2:5], nil)
+	assert.Zero(t, averages[0].Previous)
+	assert.Nil(t, averages[0].Percent)
+}
+
+func TestComparisonEndpoint(t *testing.T) {
+	// The current period follows the server's default calendar, which is local time unless pinned
+	timezone := config.Timezone
+	config.Timezone = "UTC"
+	t.Cleanup(func() { config.Timezone = timezone })
+
+	user := signup(t, "compare@example.com")
+	now := time.Now().UTC()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 30, 0, 0, time.UTC)
+	lastMonth := thisMonth.AddDate(0, -1, 0)
+	stamp := func(t time.Time) string { return t.Format("2006-01-02 15:04:05 -0700") }
+	workouts := []models.Workout{
+		{ID: "now-run", Name: "Outdoor Run", Start: stamp(thisMonth), Duration: 1800, Distance: &models.Measurement{Units: "km", Qty: 6}},
+		{ID: "now-walk", Name: "Walk", Start: stamp(thisMonth), Duration: 1200},
+		{ID: "then-run", Name: "Outdoor Run", Start: stamp(lastMonth), Duration: 1800, Distance: &models.Measurement{Units: "km", Qty: 4}},
+		{ID: "old-run", Name: "Outdoor Run", Start: stamp(lastMonth.AddDate(0, -1, 0)), Duration: 3600},
+	}
+	metrics := []models.Metric{{Name: "step_count", Units: "count", Data: []models.MetricData{
+		{Date: stamp(thisMonth), Qty: 9000}, {Date: stamp(lastMonth), Qty: 6000},
+	}}}
+	body, err := json.Marshal(map[string]any{"data": map[string]any{"workouts": workouts, "metrics": metrics}})
+	require.NoError(t, err)
+	ingest(t, user.AccessToken, string(body))
+
+	response := serve(http.MethodGet, "/compare?period=month&offset=1&workout=Outdoor%20Run", "", user.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	var comparison models.Comparison
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &comparison))
+	assert.Equal(t, "month", comparison.Period)
+	assert.Equal(t, comparison.Current.Days, comparison.Previous.Days)
+	assert.Equal(t, 1.0, comparison.Totals[0].Current)
+	assert.Equal(t, 1.0, comparison.Totals[0].Previous)
+	assert.Equal(t, 2.0, comparison.Totals[2].Delta)
+	assert.Equal(t, 50.0, *comparison.Totals[2].Percent)
+
+	// A filter matching nothing compares two empty periods
+	response = serve(http.MethodGet, "/compare?workout=Swim", "", user.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code)
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &comparison))
+	assert.Zero(t, comparison.Totals[0].Current)
+
+	response = serve(http.MethodGet, "/compare?metric=step_count", "", user.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	comparison = models.Comparison{}
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &comparison))
+	assert.Equal(t, "count", comparison.Units)
+	assert.Equal(t, "step_count", comparison.Averages[0].Field)
+	assert.Equal(t, 3000.0, comparison.Averages[0].Delta)
+	assert.Equal(t, 50.0, *comparison.Averages[0].Percent)
+
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/compare?metric=unknown", "", user.AccessToken).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, "/compare?offset=0", "", user.AccessToken).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, "/compare?period=decade", "", user.AccessToken).Code)
+}
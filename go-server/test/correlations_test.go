@@ -0,0 +1,137 @@
+// test/correlations_test.go
+
+package test
+
+import (
+	"encoding/json"
+	"fitness/models"
+	"fitness/utils"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorrelationCoefficients(t *testing.T) {
+	r, ok := utils.PearsonCorrelation([]float64{1, 2, 3, 4, 5}, []float64{2, 4, 6, 8, 10})
+	require.True(t, ok)
+	assert.InDelta(t, 1, r, 1e-9)
+
+	// Spearman only looks at the order, so a monotonic curve is a perfect fit
+	xs := []float64{1, 2, 3, 4, 5, 6}
+	ys := []float64{1, 4, 9, 16, 25, 100}
+	rho, ok := utils.SpearmanCorrelation(xs, ys)
+	require.True(t, ok)
+	assert.InDelta(t, 1, rho, 1e-9)
+	r, _ = utils.PearsonCorrelation(xs, ys)
+	assert.Less(t, r, 0.9)
+
+	// Ties share their average rank
+	rho, _ = utils.SpearmanCorrelation([]float64{1, 2, 2, 3}, []float64{1, 2, 3, 4})
+	assert.InDelta(t, 0.9487, rho, 0.001)
+
+	_, ok = utils.PearsonCorrelation([]float64{1, 1, 1}, []float64{1, 2, 3})
+	assert.False(t, ok)
+}
+
+func TestCorrelateWithLag(t *testing.T) {
+	// Hard days are followed by a higher resting heart rate the next morning
+	x := map[string]float64{}
+	y := map[string]float64{}
+	start := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	for day := 0; day < 30; day++ {
+		load := float64((day * 7) % 11)
+		x[start.AddDate(0, 0, day).Format("2006-01-02")] = load
+		y[start.AddDate(0, 0, day+1).Format("2006-01-02")] = 50 + load
+	}
+
+	sameDay := utils.Correlate("duration", "resting_heart_rate", x, y, 0)
+	nextDay := utils.Correlate("duration", "resting_heart_rate", x, y, 1)
+	assert.Equal(t, 29, sameDay.N)
+	assert.Equal(t, 30, nextDay.N)
+	assert.Equal(t, 1.0, nextDay.Pearson)
+	assert.Equal(t, 1.0, nextDay.Spearman)
+	assert.True(t, nextDay.Significant)
+	assert.Less(t, sameDay.Pearson, 0.5)
+
+	matrix := utils.CalculateCorrelationMatrix([]string{"a", "b"}, map[string]map[string]float64{"a": x, "b": y}, 0, utils.Spearman)
+	assert.Equal(t, 1.0, matrix.Matrix[0][0])
+	assert.Equal(t, matrix.Matrix[0][1], matrix.Matrix[1][0])
+	assert.Len(t, matrix.Pairs, 1)
+}
+
+func TestSleepSeriesCorrelatesWithWorkouts(t *testing.T) {
+	// More sleep the night before goes with more energy burned that day
+	sleep := models.Metric{Name: utils.SleepMetric, Units: "hr"}
+	var workouts []models.Workout
+	start := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	for day := 0; day < 10; day++ {
+		date := start.AddDate(0, 0, day)
+		hours := 6 + float64(day%4)*0.5
+		sleep.Data = append(sleep.Data, models.MetricData{
+			Date: date.Format("2006-01-02 15:04:05 -0700"), SleepData: &models.SleepData{TotalSleep: hours, InBed: hours + 0.5},
+		})
+		workouts = append(workouts, models.Workout{
+			Name: "Outdoor Run", Start: date.Add(18 * time.Hour).Format("2006-01-02 15:04:05 -0700"), Duration: 1800,
+			ActiveEnergyBurned: &models.Measurement{Units: "kcal", Qty: 100 * hours},
+		})
+	}
+
+	calendar := mustCalendar(t, "UTC", time.Monday)
+	sleepSeries := utils.DailySeries(utils.SleepMetric, nil, []models.Metric{sleep}, calendar)
+	assert.Equal(t, 6.0, sleepSeries["2024-06-01"], "Nights are valued in hours asleep.")
+	correlation := utils.Correlate(utils.SleepMetric, "energy", sleepSeries, utils.DailySeries("energy", workouts, nil, calendar), 0)
+	assert.Equal(t, 10, correlation.N)
+	assert.Equal(t, 1.0, correlation.Pearson)
+}
+
+func TestCorrelationEndpoints(t *testing.T) {
+	runner := signup(t, "correlated@example.com")
+	start := time.Date(2024, time.June, 1, 7, 0, 0, 0, time.UTC)
+	var workouts []models.Workout
+	restingHeartRate := models.Metric{Name: "resting_heart_rate", Units: "count/min"}
+	for day := 0; day < 20; day++ {
+		date := start.AddDate(0, 0, day)
+		minutes := float64(20 + (day*7)%11*5)
+		workouts = append(workouts, models.Workout{
+			ID: date.Format("run-20060102"), Name: "Outdoor Run", Duration: minutes * 60,
+			Start: date.Format("2006-01-02 15:04:05 -0700"), End: date.Add(time.Hour).Format("2006-01-02 15:04:05 -0700"),
+		})
+		restingHeartRate.Data = append(restingHeartRate.Data, models.MetricData{
+			Date: date.AddDate(0, 0, 1).Format("2006-01-02 15:04:05 -0700"), Qty: 45 + minutes/5,
+		})
+	}
+	body, err := json.Marshal(map[string]any{"data": map[string]any{"workouts": workouts, "metrics": []models.Metric{restingHeartRate}}})
+	require.NoError(t, err)
+	ingest(t, runner.AccessToken, string(body))
+
+	response := serve(http.MethodGet, "/stats/correlations?x=duration&y=resting_heart_rate&lags=0,1", "", runner.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	var correlations []models.Correlation
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &correlations))
+	require.Len(t, correlations, 2)
+	assert.Equal(t, 1, correlations[1].Lag)
+	assert.Equal(t, 20, correlations[1].N)
+	assert.Equal(t, 1.0, correlations[1].Pearson)
+	assert.True(t, correlations[1].Significant)
+
+	response = serve(http.MethodGet, "/stats/correlations/matrix?series=duration,resting_heart_rate,workouts&lag=1&method=spearman", "", runner.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	var matrix models.CorrelationMatrix
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &matrix))
+	assert.Equal(t, utils.Spearman, matrix.Method)
+	require.Len(t, matrix.Matrix, 3)
+	assert.Equal(t, 1.0, matrix.Matrix[0][1])
+	assert.Len(t, matrix.Pairs, 6)
+
+	for _, target := range []string{
+		"/stats/correlations?x=duration",
+		"/stats/correlations?x=duration&y=distance&lags=365",
+		"/stats/correlations/matrix?series=duration",
+		"/stats/correlations/matrix?series=duration,distance&method=kendall",
+	} {
+		assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, target, "", runner.AccessToken).Code, target)
+	}
+}
@@ -0,0 +1,94 @@
+// test/energy_test.go
+
+package test
+
+import (
+	"encoding/json"
+	"fitness/models"
+	"fitness/utils"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// energyMetrics returns two weeks of intake, basal and active energy from
+// February 1st, active energy only being exported from the third day
+func energyMetrics(weights ...float64) []models.Metric {
+	intake := models.Metric{Name: utils.DietaryEnergyMetric, Units: "kcal"}
+	basal := models.Metric{Name: utils.BasalEnergyMetric, Units: "kJ"}
+	active := models.Metric{Name: utils.ActiveEnergyMetric, Units: "kcal"}
+	weight := models.Metric{Name: utils.WeightMetric, Units: "kg"}
+	for day := 0; day < 14; day++ {
+		midnight := time.Date(2024, time.February, 1+day, 0, 0, 0, 0, time.UTC)
+		date := midnight.Format("2006-01-02 15:04:05 -0700")
+		// Intake is logged per meal
+		intake.Data = append(intake.Data,
+			models.MetricData{Date: midnight.Add(8 * time.Hour).Format("2006-01-02 15:04:05 -0700"), Qty: 800},
+			models.MetricData{Date: midnight.Add(19 * time.Hour).Format("2006-01-02 15:04:05 -0700"), Qty: 1200})
+		basal.Data = append(basal.Data, models.MetricData{Date: date, Qty: 1600 * 4.184})
+		if day >= 2 {
+			active.Data = append(active.Data, models.MetricData{Date: date, Qty: 500})
+		}
+		if day%4 == 0 && day/4 < len(weights) {
+			weight.Data = append(weight.Data, models.MetricData{Date: date, Qty: weights[day/4]})
+		}
+	}
+	return []models.Metric{intake, basal, active, weight}
+}
+
+func TestEnergyBalance(t *testing.T) {
+	workouts := []models.Workout{
+		// Recorded late on the first day, already the second day in UTC
+		{Start: "2024-02-01 23:30:00 -0800", ActiveEnergyBurned: &models.Measurement{Units: "kcal", Qty: 300}},
+		// Already counted by the active_energy metric
+		{Start: "2024-02-06 07:00:00 +0000", ActiveEnergyBurned: &models.Measurement{Units: "kcal", Qty: 400}},
+	}
+	balance := utils.CalculateEnergyBalance(workouts, energyMetrics(80, 79.95, 79.9), 7, "", "")
+	require.Len(t, balance.Days, 14)
+	first := balance.Days[0]
+	assert.Equal(t, "workouts", first.ActiveSource)
+	assert.Equal(t, 300.0, first.Active)
+	assert.Equal(t, 100.0, *first.Balance)
+	assert.Equal(t, 2000.0, *first.Intake)
+	assert.Equal(t, 1600.0, *first.Basal)
+	assert.Equal(t, 400.0, *balance.Days[1].Balance, "No active energy or workouts on the second day")
+	assert.Equal(t, "metric", balance.Days[5].ActiveSource)
+	assert.Equal(t, -100.0, *balance.Days[5].Balance)
+
+	assert.Equal(t, 14, balance.CompleteDays)
+	assert.Equal(t, -0.09, balance.ProjectedChange)
+	assert.Equal(t, 250.0, *balance.Days[1].Rolling)
+	require.NotNil(t, balance.Weight)
+	assert.Equal(t, 3, balance.Weight.WeighIns)
+	assert.Equal(t, -0.1, balance.Weight.Actual)
+	assert.True(t, balance.Weight.Agrees)
+
+	// Losing two kilograms is far more than the balance explains
+	balance = utils.CalculateEnergyBalance(nil, energyMetrics(80, 79, 78, 77), 7, "2024-02-03", "")
+	require.Len(t, balance.Days, 12)
+	assert.Equal(t, -0.01, balance.Days[0].Projected)
+	require.NotNil(t, balance.Weight)
+	assert.False(t, balance.Weight.Agrees)
+	assert.Less(t, balance.Weight.Discrepancy, -250.0)
+}
+
+func TestEnergyBalanceEndpoint(t *testing.T) {
+	eater := signup(t, "balanced@example.com")
+	body, err := json.Marshal(map[string]any{"data": map[string]any{"workouts": []models.Workout{}, "metrics": energyMetrics(80, 79.95, 79.9)}})
+	require.NoError(t, err)
+	ingest(t, eater.AccessToken, string(body))
+
+	response := serve(http.MethodGet, "/stats/energy-balance?start=2024-02-05&window=3", "", eater.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	var balance models.EnergyBalance
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &balance))
+	assert.Equal(t, 3, balance.Window)
+	assert.Len(t, balance.Days, 10)
+	assert.Equal(t, -100.0, balance.AverageBalance)
+	assert.InDelta(t, -0.09, balance.WeeklyChange, 0.001)
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, "/stats/energy-balance?window=0", "", eater.AccessToken).Code)
+}
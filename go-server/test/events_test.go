@@ -0,0 +1,108 @@
+// test/events_test.go
+
+package test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fitness/models"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openEvents connects to the event stream, resuming after lastEventID when it is not empty
+func openEvents(t *testing.T, server *httptest.Server, token, lastEventID string) *bufio.Reader {
+	request, err := http.NewRequest(http.MethodGet, server.URL+"/events", nil)
+	require.NoError(t, err)
+	request.Header.Set("Authorization", "Bearer "+token)
+	if lastEventID != "" {
+		request.Header.Set("Last-Event-ID", lastEventID)
+	}
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	t.Cleanup(func() { response.Body.Close() })
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+	return bufio.NewReader(response.Body)
+}
+
+// readEvents reads count events from the stream, skipping comments and retry hints
+func readEvents(t *testing.T, stream *bufio.Reader, count int) []models.Event {
+	done := make(chan []models.Event)
+	go func() {
+		var events []models.Event
+		for len(events) < count {
+			line, err := stream.ReadString('\n')
+			if err != nil {
+				break
+			}
+			if payload, ok := strings.CutPrefix(strings.TrimSpace(line), "data: "); ok {
+				var event models.Event
+				if json.Unmarshal([]byte(payload), &event) == nil {
+					events = append(events, event)
+				}
+			}
+		}
+		done <- events
+	}()
+	select {
+	case events := <-done:
+		require.Len(t, events, count)
+		return events
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for %d events", count)
+		return nil
+	}
+}
+
+func TestEventsFollowIngestsAndResume(t *testing.T) {
+	server := httptest.NewServer(serveAPI())
+	// Registered first so the streams are closed before the server waits for them
+	t.Cleanup(server.Close)
+	hiker := signup(t, "hiker@example.com")
+	other := signup(t, "climber@example.com")
+
+	stream := openEvents(t, server, hiker.AccessToken, "")
+	// Changes to other users never reach the stream
+	ingest(t, other.AccessToken, `{"data": {"workouts": [{"id": "climb-1", "name": "Climbing", "start": "2024-03-01 07:00:00 +0000", "end": "2024-03-01 08:00:00 +0000", "duration": 3600}], "metrics": []}}`)
+	ingest(t, hiker.AccessToken, `{"data": {
+		"workouts": [
+			{"id": "hike-0", "name": "Hiking", "start": "2024-03-02 07:00:00 +0000", "end": "2024-03-02 08:00:00 +0000", "duration": 3600},
+			{"id": "hike-1", "name": "Hiking", "start": "2024-03-04 07:00:00 +0000", "end": "2024-03-04 09:00:00 +0000", "duration": 7200}
+		],
+		"metrics": [{"name": "step_count", "units": "count", "data": [{"date": "2024-03-03 00:00:00 +0000", "qty": 9000}, {"date": "2024-03-04 00:00:00 +0000", "qty": 21000}]}]
+	}}`)
+
+	events := readEvents(t, stream, 5)
+	var types []string
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []string{
+		models.EventImportStarted, models.EventWorkoutsAdded, models.EventRecordsSet,
+		models.EventMetricsExtended, models.EventImportFinished,
+	}, types)
+	assert.Equal(t, []string{"hike-0", "hike-1"}, events[1].WorkoutIDs)
+	// The first hike only sets a baseline, the longer one sets the record
+	assert.Equal(t, []string{"hike-1"}, events[2].WorkoutIDs)
+	assert.Equal(t, "2024-03-03", events[3].From)
+	assert.Equal(t, "2024-03-04", events[3].To)
+	assert.Equal(t, 2, events[4].MetricPoints)
+	assert.NotZero(t, events[4].Version)
+
+	// Reconnecting replays the events after the last one received
+	resumed := readEvents(t, openEvents(t, server, hiker.AccessToken, strconv.FormatUint(events[2].ID, 10)), 2)
+	assert.Equal(t, events[3:], resumed)
+
+	// Events older than the buffer ask the client to reload
+	resync := readEvents(t, openEvents(t, server, hiker.AccessToken, "1"), 1)
+	assert.Equal(t, models.EventResync, resync[0].Type)
+	assert.Equal(t, events[4].ID, resync[0].ID)
+}
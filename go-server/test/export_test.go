@@ -0,0 +1,86 @@
+// test/export_test.go
+
+package test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"fitness/api"
+	"fitness/auth"
+	"fitness/data"
+	"fitness/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exportUser owns the data served by the export tests
+var exportUser = models.User{ID: "export-user", Email: "export@example.com"}
+
+func exportRequest(t *testing.T, handler http.HandlerFunc, target, accept string) *httptest.ResponseRecorder {
+	_, err := data.ForUser(exportUser).Ingest(models.HealthData{Data: models.DataCollection{
+		Workouts: []models.Workout{
+			{ID: "1", Name: "Outdoor Run", Start: "2024-03-04 07:00:00 +0000", Duration: 1800, Distance: &models.Measurement{Units: "mi", Qty: 3.1}},
+			{ID: "2", Name: "Pool Swim", Start: "2024-03-05 07:00:00 +0000", Duration: 2400, ActiveEnergyBurned: &models.Measurement{Units: "kcal", Qty: 400}},
+		},
+		Metrics: []models.Metric{
+			{Name: "step_count", Units: "count", Data: []models.MetricData{{Date: "2024-03-04 00:00:00 +0000", Qty: 9000}}},
+			{Name: "resting_heart_rate", Units: "count/min", Data: []models.MetricData{{Date: "2024-03-04 00:00:00 +0000", Qty: 52}}},
+		},
+	}})
+	require.NoError(t, err)
+
+	request := httptest.NewRequest(http.MethodGet, target, nil)
+	request = request.WithContext(auth.WithUser(request.Context(), exportUser))
+	if accept != "" {
+		request.Header.Set("Accept", accept)
+	}
+	recorder := httptest.NewRecorder()
+	handler(recorder, request)
+	return recorder
+}
+
+func TestExportCSVFlattensMeasurements(t *testing.T) {
+	response := exportRequest(t, api.GetWorkoutData, "/workouts?workout=Outdoor%20Run", "text/csv")
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Header().Get("Content-Type"), "text/csv")
+
+	records, err := csv.NewReader(response.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2, "Expected a header and one filtered workout.")
+	assert.Contains(t, records[0], "distance.qty")
+	assert.Contains(t, records[0], "distance.units")
+	assert.Contains(t, records[1], "3.1")
+}
+
+func TestExportNDJSONFormatParameter(t *testing.T) {
+	response := exportRequest(t, api.GetWorkoutData, "/workouts?format=ndjson", "application/json")
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "application/x-ndjson", response.Header().Get("Content-Type"))
+	assert.Len(t, strings.Split(strings.TrimSpace(response.Body.String()), "\n"), 2)
+}
+
+func TestExportXLSXOneSheetPerMetric(t *testing.T) {
+	response := exportRequest(t, api.GetMetricData, "/metrics?format=xlsx", "")
+	require.Equal(t, http.StatusOK, response.Code)
+
+	archive, err := zip.NewReader(bytes.NewReader(response.Body.Bytes()), int64(response.Body.Len()))
+	require.NoError(t, err)
+	sheets := 0
+	for _, file := range archive.File {
+		if strings.HasPrefix(file.Name, "xl/worksheets/") {
+			sheets++
+		}
+	}
+	assert.Equal(t, 2, sheets)
+}
+
+func TestExportUnsupportedFormat(t *testing.T) {
+	response := exportRequest(t, api.GetWorkoutData, "/workouts", "image/png")
+	assert.Equal(t, http.StatusNotAcceptable, response.Code)
+}
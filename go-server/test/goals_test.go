@@ -0,0 +1,113 @@
+// test/goals_test.go
+
+package test
+
+import (
+	"encoding/json"
+	"fitness/models"
+	"fitness/utils"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoalProgressAndProjection(t *testing.T) {
+	calendar := mustCalendar(t, "UTC", time.Monday)
+	goal := models.Goal{Period: "week", Field: models.GoalDistance, Target: 20, Units: "mi"}
+	require.NoError(t, utils.ValidateGoal(&goal))
+	run := func(day int, km float64) models.Workout {
+		start := time.Date(2024, time.March, day, 7, 0, 0, 0, time.UTC)
+		return models.Workout{Name: "Outdoor Run", Start: start.Format("2006-01-02 15:04:05 -0700"), Distance: &models.Measurement{Units: "km", Qty: km}}
+	}
+	workouts := []models.Workout{
+		// The week of March 4th reaches 20 miles on Saturday
+		run(4, 10), run(6, 10), run(9, 13),
+		// The week of March 11th falls short
+		run(12, 16),
+		// The week of March 18th is under way
+		run(18, 10), run(19, 6.1),
+	}
+	// Wednesday morning of the current week
+	now := time.Date(2024, time.March, 20, 0, 0, 0, 0, time.UTC)
+
+	progress := utils.CalculateGoalProgress(goal, workouts, nil, calendar, now, 12)
+	assert.Equal(t, "2024-03-18", progress.Current.Start)
+	assert.Equal(t, "2024-03-24", progress.Current.End)
+	assert.Equal(t, 10.0, progress.Current.Value)
+	assert.False(t, progress.Current.Met)
+	// Ten miles in two days projects to 35 over the week, reaching 20 on Friday
+	assert.Equal(t, 35.0, progress.Projected)
+	assert.Equal(t, "2024-03-22", progress.ProjectedCompletion)
+	assert.True(t, progress.OnTrack)
+
+	require.Len(t, progress.History, 2)
+	assert.Equal(t, "2024-03-11", progress.History[0].Period)
+	assert.False(t, progress.History[0].Met)
+	assert.True(t, progress.History[1].Met)
+	assert.Equal(t, "2024-03-09", progress.History[1].MetOn)
+	assert.Equal(t, 1, progress.Met)
+	assert.Equal(t, 1, progress.Missed)
+
+	// A goal set this week has no completed periods, whatever the data before it
+	created := time.Date(2024, time.March, 19, 12, 0, 0, 0, time.UTC)
+	goal.CreatedAt = &created
+	progress = utils.CalculateGoalProgress(goal, workouts, nil, calendar, now, 12)
+	assert.Equal(t, "2024-03-18", progress.Current.Start)
+	assert.Equal(t, 10.0, progress.Current.Value, "The current period counts its data from before the goal was created.")
+	assert.Empty(t, progress.History)
+	assert.Zero(t, progress.Met)
+	assert.Zero(t, progress.Missed)
+
+	bad := models.Goal{Period: "fortnight", Field: models.GoalWorkouts, Target: 4}
+	assert.Error(t, utils.ValidateGoal(&bad))
+	bad = models.Goal{Period: "month", Field: models.GoalWorkouts, Metric: "step_count", Target: 4}
+	assert.Error(t, utils.ValidateGoal(&bad))
+}
+
+func TestGoalsCRUD(t *testing.T) {
+	swimmer := signup(t, "lapswimmer@example.com")
+	other := signup(t, "diver@example.com")
+	today := time.Now().Format("2006-01-02 15:04:05 -0700")
+	ingest(t, swimmer.AccessToken, `{"data": {"workouts": [
+		{"id": "swim-1", "name": "Pool Swim", "start": "`+today+`", "end": "`+today+`", "duration": 1800},
+		{"id": "run-1", "name": "Outdoor Run", "start": "`+today+`", "end": "`+today+`", "duration": 1800}
+	], "metrics": []}}`)
+
+	response := serve(http.MethodPost, "/goals", `{"name": "Swims", "period": "month", "field": "workouts", "workout": "Pool Swim", "target": 4}`, swimmer.AccessToken)
+	require.Equal(t, http.StatusCreated, response.Code)
+	var goal models.Goal
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &goal))
+	assert.NotEmpty(t, goal.ID)
+	assert.NotNil(t, goal.CreatedAt)
+
+	response = serve(http.MethodGet, "/goals/"+goal.ID+"/progress", "", swimmer.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code)
+	var progress models.GoalProgress
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &progress))
+	assert.Equal(t, 1.0, progress.Current.Value)
+	assert.Empty(t, progress.History)
+
+	// Goals belong to their user
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/goals/"+goal.ID+"/progress", "", other.AccessToken).Code)
+	assert.Equal(t, "[]\n", serve(http.MethodGet, "/goals", "", other.AccessToken).Body.String())
+
+	response = serve(http.MethodPatch, "/goals/"+goal.ID, `{"target": 2}`, swimmer.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code)
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &goal))
+	assert.Equal(t, 2.0, goal.Target)
+	assert.Equal(t, "Pool Swim", goal.Workout)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPatch, "/goals/"+goal.ID, `{"target": -1}`, swimmer.AccessToken).Code)
+
+	response = serve(http.MethodGet, "/goals/progress", "", swimmer.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code)
+	var all []models.GoalProgress
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &all))
+	require.Len(t, all, 1)
+	assert.Equal(t, 50.0, all[0].Current.Percent)
+
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodDelete, "/goals/"+goal.ID, "", swimmer.AccessToken).Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodDelete, "/goals/"+goal.ID, "", swimmer.AccessToken).Code)
+}
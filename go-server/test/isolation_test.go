@@ -0,0 +1,107 @@
+// test/isolation_test.go
+
+package test
+
+import (
+	"encoding/json"
+	"fitness/models"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ingest pushes Health Auto Export data for the signed in user
+func ingest(t *testing.T, token string, body string) {
+	response := serve(http.MethodPost, "/ingest", body, token)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+}
+
+func TestUsersOnlySeeTheirOwnData(t *testing.T) {
+	rider := signup(t, "rider@example.com")
+	rower := signup(t, "rower@example.com")
+	ingest(t, rider.AccessToken, `{"data": {
+		"workouts": [{"id": "ride-1", "name": "Outdoor Cycle", "start": "2024-03-04 07:00:00 +0000", "end": "2024-03-04 08:00:00 +0000", "duration": 3600}],
+		"metrics": [{"name": "step_count", "units": "count", "data": [{"date": "2024-03-04 00:00:00 +0000", "qty": 4000}]}]
+	}}`)
+	ingest(t, rower.AccessToken, `{"data": {
+		"workouts": [{"id": "row-1", "name": "Rowing", "start": "2024-03-05 07:00:00 +0000", "end": "2024-03-05 07:30:00 +0000", "duration": 1800}],
+		"metrics": []
+	}}`)
+
+	var workouts []models.Workout
+	response := serve(http.MethodGet, "/workouts", "", rower.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code)
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &workouts))
+	require.Len(t, workouts, 1)
+	assert.Equal(t, "row-1", workouts[0].ID)
+
+	var metrics []models.Metric
+	response = serve(http.MethodGet, "/metrics", "", rower.AccessToken)
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &metrics))
+	assert.Empty(t, metrics)
+
+	var perMonth map[string]int
+	response = serve(http.MethodGet, "/stats/workouts-per-month", "", rider.AccessToken)
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &perMonth))
+	assert.Equal(t, map[string]int{"2024-03": 1}, perMonth)
+
+	// Another user's workout IDs are unknown
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodPatch, "/workouts/ride-1", `{"name": "Indoor Cycle"}`, rower.AccessToken).Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodDelete, "/workouts/ride-1", "", rower.AccessToken).Code)
+}
+
+func TestWorkoutEditsAreRecorded(t *testing.T) {
+	walker := signup(t, "walker@example.com")
+	ingest(t, walker.AccessToken, `{"data": {"workouts": [
+		{"id": "walk-1", "name": "Outdoor Walk", "start": "2024-03-04 07:00:00 +0000", "end": "2024-03-04 07:20:00 +0000", "duration": 1200},
+		{"id": "walk-2", "name": "Outdoor Walk", "start": "2024-03-05 07:00:00 +0000", "end": "2024-03-05 07:25:00 +0000", "duration": 1500}
+	], "metrics": []}}`)
+
+	response := serve(http.MethodPatch, "/workouts/walk-1", `{"name": "Hiking"}`, walker.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	var updated models.Workout
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &updated))
+	assert.Equal(t, "Hiking", updated.Name)
+	assert.Equal(t, 1200.0, updated.Duration, "Fields missing from the body are kept.")
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPatch, "/workouts/walk-1", `{"name": `, walker.AccessToken).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPatch, "/workouts/walk-1", `{"duration": "long"}`, walker.AccessToken).Code)
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodDelete, "/workouts/walk-2", "", walker.AccessToken).Code)
+
+	// Re-imported workouts do not undo the edits
+	ingest(t, walker.AccessToken, `{"data": {"workouts": [
+		{"id": "walk-1", "name": "Outdoor Walk", "start": "2024-03-04 07:00:00 +0000", "end": "2024-03-04 07:20:00 +0000", "duration": 1200},
+		{"id": "walk-2", "name": "Outdoor Walk", "start": "2024-03-05 07:00:00 +0000", "end": "2024-03-05 07:25:00 +0000", "duration": 1500}
+	], "metrics": []}}`)
+	var workouts []models.Workout
+	response = serve(http.MethodGet, "/workouts", "", walker.AccessToken)
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &workouts))
+	require.Len(t, workouts, 1)
+	assert.Equal(t, "Hiking", workouts[0].Name)
+
+	var history []models.WorkoutEdit
+	response = serve(http.MethodGet, "/workouts/history", "", walker.AccessToken)
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &history))
+	require.Len(t, history, 2)
+	assert.Equal(t, "update", history[0].Action)
+	assert.Equal(t, "delete", history[1].Action)
+}
+
+func TestWorkoutEditKeepsPreviousMeasurements(t *testing.T) {
+	runner := signup(t, "patcher@example.com")
+	ingest(t, runner.AccessToken, `{"data": {"workouts": [
+		{"id": "run-1", "name": "Outdoor Run", "start": "2024-03-04 07:00:00 +0000", "end": "2024-03-04 07:30:00 +0000", "duration": 1800,
+		 "distance": {"units": "km", "qty": 5}}
+	], "metrics": []}}`)
+
+	response := serve(http.MethodPatch, "/workouts/run-1", `{"distance": {"units": "km", "qty": 9}}`, runner.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	var history []models.WorkoutEdit
+	response = serve(http.MethodGet, "/workouts/history", "", runner.AccessToken)
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &history))
+	require.Len(t, history, 1)
+	assert.Equal(t, 5.0, history[0].Before.Distance.Qty, "The snapshot before the edit is not overwritten.")
+	assert.Equal(t, 9.0, history[0].After.Distance.Qty)
+}
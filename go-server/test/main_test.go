@@ -0,0 +1,25 @@
+// test/main_test.go
+
+package test
+
+import (
+	"fitness/config"
+	"os"
+	"testing"
+)
+
+// TestMain keeps user data written by the tests out of the repository
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "fitness-test")
+	if err != nil {
+		panic(err)
+	}
+	config.DataDir = dir
+	config.UsersFilePath = ""
+	config.ICloudDirPath = ""
+	config.OwnerEmail = ""
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
@@ -0,0 +1,79 @@
+// test/middleware_test.go
+
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fitness/api"
+	"fitness/utils"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureLogs sends the default logger's JSON records to a buffer for the rest of the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buffer bytes.Buffer
+	logger, err := utils.NewLogger(&buffer, "json", "debug")
+	require.NoError(t, err)
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buffer
+}
+
+func TestPanicsAreRecoveredAndLogged(t *testing.T) {
+	logs := captureLogs(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /boom", func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+
+	request := httptest.NewRequest(http.MethodGet, "/boom", nil)
+	request.Header.Set("X-Request-ID", "trace-42")
+	response := httptest.NewRecorder()
+	api.NewHandler(mux).ServeHTTP(response, request)
+
+	assert.Equal(t, http.StatusInternalServerError, response.Code)
+	assert.Equal(t, "trace-42", response.Header().Get("X-Request-ID"))
+
+	// Both the panic and the access log carry the request ID
+	var records []map[string]any
+	decoder := json.NewDecoder(logs)
+	for decoder.More() {
+		var record map[string]any
+		require.NoError(t, decoder.Decode(&record))
+		records = append(records, record)
+	}
+	require.Len(t, records, 2)
+	assert.Equal(t, "handler panicked", records[0]["msg"])
+	assert.Equal(t, "request", records[1]["msg"])
+	assert.Equal(t, float64(http.StatusInternalServerError), records[1]["status"])
+	for _, record := range records {
+		assert.Equal(t, "trace-42", record["request_id"])
+	}
+}
+
+func TestCORSAllowsTheClientOrigin(t *testing.T) {
+	preflight := httptest.NewRequest(http.MethodOptions, "/workouts", nil)
+	preflight.Header.Set("Origin", "http://localhost:3000")
+	preflight.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	response := httptest.NewRecorder()
+	serveAPI().ServeHTTP(response, preflight)
+	assert.Equal(t, http.StatusNoContent, response.Code)
+	assert.Equal(t, "http://localhost:3000", response.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", response.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Contains(t, response.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+
+	// Other origins get no CORS headers
+	request := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	request.Header.Set("Origin", "https://evil.example.com")
+	response = httptest.NewRecorder()
+	serveAPI().ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Empty(t, response.Header().Get("Access-Control-Allow-Origin"))
+	assert.NotEmpty(t, response.Header().Get("X-Request-ID"))
+}
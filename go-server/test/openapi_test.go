@@ -0,0 +1,240 @@
+// test/openapi_test.go
+
+package test
+
+import (
+	"encoding/json"
+	"fitness/api"
+	"fitness/models"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type openAPIDocument struct {
+	Paths map[string]map[string]struct {
+		OperationID string `json:"operationId"`
+		Parameters  []struct {
+			Name string `json:"name"`
+			In   string `json:"in"`
+		} `json:"parameters"`
+	} `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+var registerRoutes sync.Once
+
+// serveMux returns the default mux with the API routes registered
+func serveMux() *http.ServeMux {
+	registerRoutes.Do(api.RegisterRoutes)
+	return http.DefaultServeMux
+}
+
+// serveAPI returns the routes wrapped in the middleware of the server
+func serveAPI() http.Handler {
+	return api.NewHandler(serveMux())
+}
+
+func loadOpenAPI(t *testing.T) openAPIDocument {
+	response := httptest.NewRecorder()
+	api.GetOpenAPI(response, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, response.Code)
+
+	var document openAPIDocument
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &document))
+	return document
+}
+
+// handlerParams parses the api package and returns, for every function, the
+// parameters it reads through r.URL.Query().Get and r.PathValue, including the
+// ones read by functions it calls
+func handlerParams(t *testing.T) map[string][]string {
+	fileSet := token.NewFileSet()
+	files, err := filepath.Glob("../api/*.go")
+	require.NoError(t, err)
+
+	reads := make(map[string]map[string]bool)
+	calls := make(map[string][]string)
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		parsed, err := parser.ParseFile(fileSet, file, nil, 0)
+		require.NoError(t, err)
+		for _, decl := range parsed.Decls {
+			function, ok := decl.(*ast.FuncDecl)
+			if !ok || function.Body == nil {
+				continue
+			}
+			name := function.Name.Name
+			reads[name] = make(map[string]bool)
+			ast.Inspect(function.Body, func(node ast.Node) bool {
+				call, ok := node.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				switch fun := call.Fun.(type) {
+				case *ast.Ident:
+					calls[name] = append(calls[name], fun.Name)
+				case *ast.SelectorExpr:
+					if param, ok := paramRead(fun, call); ok {
+						reads[name][param] = true
+					}
+				}
+				return true
+			})
+		}
+	}
+
+	// Follow calls within the package so shared helpers count for every handler
+	result := make(map[string][]string)
+	for name := range reads {
+		seen := map[string]bool{}
+		params := map[string]bool{}
+		var visit func(string)
+		visit = func(function string) {
+			if seen[function] {
+				return
+			}
+			seen[function] = true
+			for param := range reads[function] {
+				params[param] = true
+			}
+			for _, callee := range calls[function] {
+				visit(callee)
+			}
+		}
+		visit(name)
+		result[name] = nil
+		for param := range params {
+			result[name] = append(result[name], param)
+		}
+		sort.Strings(result[name])
+	}
+	return result
+}
+
+// paramRead matches r.URL.Query().Get("name") and r.PathValue("name")
+func paramRead(fun *ast.SelectorExpr, call *ast.CallExpr) (string, bool) {
+	if len(call.Args) != 1 {
+		return "", false
+	}
+	literal, ok := call.Args[0].(*ast.BasicLit)
+	if !ok || literal.Kind != token.STRING {
+		return "", false
+	}
+	isQuery := false
+	if inner, ok := fun.X.(*ast.CallExpr); ok {
+		if selector, ok := inner.Fun.(*ast.SelectorExpr); ok && selector.Sel.Name == "Query" {
+			isQuery = true
+		}
+	}
+	if (fun.Sel.Name == "Get" && isQuery) || fun.Sel.Name == "PathValue" {
+		value, err := strconv.Unquote(literal.Value)
+		return value, err == nil
+	}
+	return "", false
+}
+
+func TestOpenAPIMatchesHandlers(t *testing.T) {
+	document := loadOpenAPI(t)
+	params := handlerParams(t)
+	require.NotEmpty(t, document.Paths)
+
+	for path, operations := range document.Paths {
+		for method, operation := range operations {
+			read, ok := params[operation.OperationID]
+			require.True(t, ok, "Expected handler %s for %s %s", operation.OperationID, method, path)
+
+			var declared []string
+			for _, p := range operation.Parameters {
+				// Headers are also read by shared helpers for content negotiation and caching
+				if p.In != "header" {
+					declared = append(declared, p.Name)
+				}
+			}
+			sort.Strings(declared)
+			assert.Equal(t, read, declared, "Parameters of %s %s drifted from the handler %s", method, path, operation.OperationID)
+		}
+	}
+}
+
+func TestOpenAPIMatchesRegisteredRoutes(t *testing.T) {
+	document := loadOpenAPI(t)
+	mux := serveMux()
+
+	for path, operations := range document.Paths {
+		for method := range operations {
+			target := strings.NewReplacer("{", "", "}", "").Replace(path)
+			request := httptest.NewRequest(strings.ToUpper(method), target, nil)
+			_, pattern := mux.Handler(request)
+			assert.Equal(t, strings.ToUpper(method)+" "+path, pattern, "Expected %s %s to be registered", method, path)
+		}
+	}
+}
+
+func TestOpenAPISchemasMatchModels(t *testing.T) {
+	document := loadOpenAPI(t)
+
+	for _, model := range []any{models.Workout{}, models.Metric{}, models.MetricData{}, models.Measurement{}} {
+		modelType := reflect.TypeOf(model)
+		component, ok := document.Components.Schemas[modelType.Name()]
+		require.True(t, ok, "Expected a schema for %s", modelType.Name())
+
+		var fields, properties []string
+		for _, field := range reflect.VisibleFields(modelType) {
+			// Embedded structs are encoded inline
+			if field.Anonymous {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			fields = append(fields, name)
+		}
+		for property := range component.Properties {
+			properties = append(properties, property)
+		}
+		sort.Strings(fields)
+		sort.Strings(properties)
+		assert.Equal(t, fields, properties, "Schema of %s drifted from the model", modelType.Name())
+	}
+}
+
+func TestOpenAPIValidatesRequests(t *testing.T) {
+	mux := serveMux()
+
+	cases := map[string]string{
+		"/workouts?calories=lots":   "invalid number",
+		"/workouts?start=yesterday": "invalid date",
+		"/workouts?format=pdf":      "value outside the enum",
+		"/workouts?colour=red":      "undeclared parameter",
+	}
+	for target, reason := range cases {
+		request := httptest.NewRequest(http.MethodGet, target, nil)
+		authorize(t, request)
+		response := httptest.NewRecorder()
+		mux.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusBadRequest, response.Code, "Expected %s to be rejected for its %s", target, reason)
+	}
+
+	request := httptest.NewRequest(http.MethodPatch, "/workouts/1", strings.NewReader(`{"duration": "long"}`))
+	authorize(t, request)
+	response := httptest.NewRecorder()
+	mux.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusBadRequest, response.Code, "Expected a body with a mistyped field to be rejected")
+}
@@ -0,0 +1,89 @@
+// test/predictions_test.go
+
+package test
+
+import (
+	"encoding/json"
+	"fitness/models"
+	"fitness/utils"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRaceTimeFormulas(t *testing.T) {
+	// A 20 minute 5k
+	assert.InDelta(t, 2501.9, utils.RiegelTime(1200, 5000, 10000), 0.1)
+	assert.InDelta(t, 3*3600+15*60+11, utils.CameronTime(1200, 5000, 42195), 1)
+	assert.InDelta(t, 49.8, utils.VDOT(5000, 1200), 0.1)
+
+	// Daniels' tables put a VDOT of 50 at a 3:10:49 marathon
+	seconds, ok := utils.VDOTTime(50, 42195)
+	require.True(t, ok)
+	assert.InDelta(t, 3*3600+10*60+49, seconds, 30)
+	seconds, _ = utils.VDOTTime(utils.VDOT(10000, 2700), 10000)
+	assert.InDelta(t, 2700, seconds, 0.01)
+}
+
+func TestRacePredictionsEndpoint(t *testing.T) {
+	runner := signup(t, "predicted@example.com")
+	require.Equal(t, http.StatusOK, serve(http.MethodPatch, "/auth/me", `{"restingHeartRate": 50, "maxHeartRate": 190}`, runner.AccessToken).Code)
+
+	recent := time.Now().AddDate(0, 0, -10)
+	old := time.Now().AddDate(0, 0, -200)
+	run := func(id string, start time.Time, km, minutes, bpm float64) models.Workout {
+		return models.Workout{
+			ID: id, Name: "Outdoor Run", Duration: minutes * 60,
+			Start: start.Format("2006-01-02 15:04:05 -0700"), End: start.Add(time.Duration(minutes) * time.Minute).Format("2006-01-02 15:04:05 -0700"),
+			Distance:  &models.Measurement{Units: "km", Qty: km},
+			HeartRate: &models.HeartRate{Avg: &models.Measurement{Units: "count/min", Qty: bpm}},
+		}
+	}
+	workouts := []models.Workout{
+		run("tempo-10k", recent, 10, 45, 170),
+		run("easy", recent.AddDate(0, 0, 2), 8, 48, 120),
+		run("old-race", old, 10, 38, 180),
+		{ID: "ride", Name: "Outdoor Cycling", Duration: 3600, Start: recent.Format("2006-01-02 15:04:05 -0700"), End: recent.Format("2006-01-02 15:04:05 -0700"), Distance: &models.Measurement{Units: "km", Qty: 30}},
+	}
+	body, err := json.Marshal(map[string]any{"data": map[string]any{"workouts": workouts, "metrics": []models.Metric{}}})
+	require.NoError(t, err)
+	ingest(t, runner.AccessToken, string(body))
+
+	response := serve(http.MethodGet, "/predictions/race", "", runner.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	var predictions models.RacePredictions
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &predictions))
+	require.Len(t, predictions.Efforts, 2)
+	assert.Equal(t, "tempo-10k", predictions.Efforts[0].WorkoutID)
+	assert.True(t, predictions.Efforts[0].Used)
+	assert.False(t, predictions.Efforts[1].Used)
+	require.NotNil(t, predictions.VO2Max)
+	assert.Equal(t, utils.VO2MaxHeartRate, predictions.VO2Max.Source)
+	assert.Equal(t, []string{"tempo-10k"}, predictions.VO2Max.Workouts)
+	require.Len(t, predictions.Predictions, len(utils.RaceDistances))
+	tenK := predictions.Predictions[2]
+	assert.Equal(t, "10k", tenK.Distance)
+	require.NotNil(t, tenK.Riegel)
+	assert.Equal(t, 2700.0, *tenK.Riegel)
+	assert.Equal(t, "45:00", tenK.RiegelTime)
+	assert.NotNil(t, tenK.VDOT)
+	assert.Len(t, predictions.Notes, 2)
+
+	// A longer window reaches the old race, which becomes the best effort
+	response = serve(http.MethodGet, "/predictions/race?days=365", "", runner.AccessToken)
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &predictions))
+	assert.Equal(t, "old-race", predictions.Efforts[0].WorkoutID)
+
+	// The VO2 Max metric takes precedence over the estimate
+	ingest(t, runner.AccessToken, `{"data": {"workouts": [], "metrics": [{"name": "vo2_max", "units": "ml/(kg·min)", "data": [{"date": "`+recent.Format("2006-01-02 15:04:05 -0700")+`", "qty": 52}]}]}}`)
+	response = serve(http.MethodGet, "/predictions/race", "", runner.AccessToken)
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &predictions))
+	require.NotNil(t, predictions.VO2Max)
+	assert.Equal(t, utils.VO2MaxMetric, predictions.VO2Max.Source)
+	assert.Equal(t, 52.0, predictions.VO2Max.Value)
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, "/predictions/race?days=0", "", runner.AccessToken).Code)
+}
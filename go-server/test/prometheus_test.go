@@ -0,0 +1,45 @@
+// test/prometheus_test.go
+
+package test
+
+import (
+	"fitness/config"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	runner := signup(t, "sprinter@example.com")
+	ingest(t, runner.AccessToken, `{"data": {"workouts": [{"id": "sprint-1", "name": "Outdoor Run", "start": "2024-03-04 07:00:00 +0000", "end": "2024-03-04 07:10:00 +0000", "duration": 600}], "metrics": []}}`)
+	serve(http.MethodPatch, "/workouts/unknown", `{"name": "Hiking"}`, runner.AccessToken)
+
+	response := serve(http.MethodGet, config.MetricsPath, "", "")
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Header().Get("Content-Type"), "text/plain")
+	body := response.Body.String()
+
+	// Requests are labelled by their route pattern, not the workout ID
+	assert.Contains(t, body, "# TYPE fitness_http_requests_total counter\n")
+	assert.Contains(t, body, `fitness_http_requests_total{method="PATCH",route="/workouts/{id}",status="404"}`)
+	assert.Contains(t, body, `fitness_http_request_duration_seconds_bucket{method="POST",route="/ingest",le="+Inf"}`)
+	assert.NotContains(t, body, "/workouts/unknown")
+
+	assert.Contains(t, body, `fitness_import_runs_total{source="ingest",result="success"}`)
+	assert.Contains(t, body, "fitness_cache_write_bytes_count")
+	assert.Contains(t, body, "fitness_seconds_since_last_import ")
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "fitness_workouts ") {
+			assert.NotEqual(t, "fitness_workouts 0", line)
+		}
+	}
+
+	// A configured token guards the endpoint
+	config.MetricsToken = "scrape-secret"
+	defer func() { config.MetricsToken = "" }()
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, config.MetricsPath, "", "").Code)
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, config.MetricsPath, "", "scrape-secret").Code)
+}
@@ -0,0 +1,101 @@
+// test/records_test.go
+
+package test
+
+import (
+	"encoding/json"
+	"fitness/models"
+	"fitness/utils"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordsKeepHistory(t *testing.T) {
+	workouts := []models.Workout{
+		{ID: "b", Name: "Outdoor Run", Start: "2024-03-10 07:00:00 +0000", Duration: 1980, Distance: &models.Measurement{Units: "km", Qty: 6}},
+		{ID: "a", Name: "Outdoor Run", Start: "2024-03-03 07:00:00 +0000", Duration: 1800, Distance: &models.Measurement{Units: "km", Qty: 5}},
+		{ID: "c", Name: "Outdoor Run", Start: "2024-03-17 07:00:00 +0000", Duration: 1500, Distance: &models.Measurement{Units: "mi", Qty: 3}},
+	}
+	records := utils.CalculateRecords(workouts)
+
+	var distance []models.PersonalRecord
+	for _, record := range records.History {
+		if record.Category == models.RecordLongestDistance {
+			distance = append(distance, record)
+		}
+	}
+	require.Len(t, distance, 2, "The 3 mi run is shorter than 6 km and sets no distance record.")
+	assert.Equal(t, "a", distance[0].WorkoutID)
+	assert.Equal(t, "b", distance[1].WorkoutID)
+	assert.Equal(t, 5.0, *distance[1].Previous)
+	assert.True(t, distance[1].Current)
+	assert.False(t, distance[0].Current)
+
+	// 1500 s over 4.83 km beats 330 s/km
+	achievements := utils.WorkoutAchievements(records, "c")
+	require.Len(t, achievements, 1)
+	assert.Equal(t, models.RecordFastestPace, achievements[0].Category)
+	assert.InDelta(t, 310.7, achievements[0].Value, 0.1)
+
+	// The first run only sets a baseline
+	for _, record := range utils.Breakthroughs(records.History) {
+		assert.NotEqual(t, "a", record.WorkoutID)
+	}
+	assert.Len(t, utils.Breakthroughs(records.History), len(records.History)-len(utils.WorkoutAchievements(records, "a")))
+
+	// A first distance after workouts without one is a baseline too
+	walks := []models.Workout{
+		{ID: "indoor", Name: "Walk", Start: "2024-03-01 07:00:00 +0000", Duration: 1200},
+		{ID: "outdoor", Name: "Walk", Start: "2024-03-02 07:00:00 +0000", Duration: 900, Distance: &models.Measurement{Units: "km", Qty: 1}},
+	}
+	assert.Empty(t, utils.Breakthroughs(utils.CalculateRecords(walks).History))
+}
+
+func TestBestEffortsFromDistanceSamples(t *testing.T) {
+	// 1.2 km in the first minute, then 0.2 km per minute
+	workout := models.Workout{
+		ID: "samples", Name: "Outdoor Run", Start: "2024-03-04 07:00:00 +0000", End: "2024-03-04 07:04:00 +0000",
+		WalkingAndRunningDistance: []models.QuantitySample{
+			{Date: "2024-03-04 07:00:00 +0000", Qty: 1.2, Units: "km"},
+			{Date: "2024-03-04 07:01:00 +0000", Qty: 0.2, Units: "km"},
+			{Date: "2024-03-04 07:02:00 +0000", Qty: 0.2, Units: "km"},
+			{Date: "2024-03-04 07:03:00 +0000", Qty: 0.2, Units: "km"},
+		},
+	}
+	series := utils.DistanceSeries(workout)
+	require.Len(t, series, 5)
+
+	seconds, ok := utils.BestEffort(series, 1000)
+	require.True(t, ok)
+	assert.InDelta(t, 50, seconds, 0.001)
+	_, ok = utils.BestEffort(series, 5000)
+	assert.False(t, ok)
+}
+
+func TestAchievementsEndpoint(t *testing.T) {
+	racer := signup(t, "racer@example.com")
+	ingest(t, racer.AccessToken, `{"data": {"workouts": [
+		{"id": "race-1", "name": "Outdoor Run", "start": "2024-03-04 07:00:00 +0000", "end": "2024-03-04 07:30:00 +0000", "duration": 1800, "distance": {"units": "km", "qty": 5}},
+		{"id": "race-2", "name": "Outdoor Run", "start": "2024-03-11 07:00:00 +0000", "end": "2024-03-11 07:40:00 +0000", "duration": 2400, "distance": {"units": "km", "qty": 6}}
+	], "metrics": []}}`)
+
+	response := serve(http.MethodGet, "/workouts/race-1/achievements", "", racer.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code)
+	var achievements []models.PersonalRecord
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &achievements))
+	assert.Empty(t, achievements, "A first workout only sets baselines.")
+
+	response = serve(http.MethodGet, "/workouts/race-2/achievements", "", racer.AccessToken)
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &achievements))
+	assert.Len(t, achievements, 2, "The longer and slower run beats distance and duration, not pace.")
+
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/workouts/unknown/achievements", "", racer.AccessToken).Code)
+
+	var records models.Records
+	response = serve(http.MethodGet, "/records?workout=Outdoor%20Run", "", racer.AccessToken)
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &records))
+	assert.Len(t, records.Current, 3)
+}
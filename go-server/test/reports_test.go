@@ -0,0 +1,150 @@
+// test/reports_test.go
+
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fitness/api"
+	"fitness/models"
+	"fitness/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reportWorkouts are two workouts in the week of February 26th, three in the
+// week of March 4th and a longer run the week after
+func reportWorkouts() []models.Workout {
+	run := func(id, start string, km, seconds, kcal float64) models.Workout {
+		return models.Workout{
+			ID: id, Name: "Outdoor Run", Start: start, Duration: seconds,
+			Distance:           &models.Measurement{Units: "km", Qty: km},
+			ActiveEnergyBurned: &models.Measurement{Units: "kcal", Qty: kcal},
+		}
+	}
+	return []models.Workout{
+		run("a", "2024-02-27 07:00:00 +0000", 5, 1800, 300),
+		{ID: "b", Name: "Walk", Start: "2024-03-01 07:00:00 +0000", Duration: 1200},
+		run("c", "2024-03-05 07:00:00 +0000", 10, 3000, 600),
+		{ID: "d", Name: "Yoga", Start: "2024-03-06 07:00:00 +0000", Duration: 3600, ActiveEnergyBurned: &models.Measurement{Units: "kcal", Qty: 200}},
+		run("e", "2024-03-06 18:00:00 +0000", 5, 1500, 350),
+		run("f", "2024-03-12 07:00:00 +0000", 20, 7000, 1400),
+	}
+}
+
+// reportMetrics has a resting heart rate dropping from 60 to 50 and a step
+// count rising by 5 percent from one week to the next
+func reportMetrics() []models.Metric {
+	heartRate := models.Metric{Name: "resting_heart_rate", Units: "count/min"}
+	steps := models.Metric{Name: "step_count", Units: "count"}
+	for day := 0; day < 14; day++ {
+		date := time.Date(2024, time.February, 26+day, 0, 0, 0, 0, time.UTC).Format("2006-01-02 15:04:05 -0700")
+		rate, count := 60.0, 10000.0
+		if day >= 7 {
+			rate, count = 50, 10500
+		}
+		heartRate.Data = append(heartRate.Data, models.MetricData{Date: date, Qty: rate})
+		steps.Data = append(steps.Data, models.MetricData{Date: date, Qty: count})
+	}
+	return []models.Metric{heartRate, steps}
+}
+
+func TestWeeklyReport(t *testing.T) {
+	date := time.Date(2024, time.March, 7, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2024, time.March, 20, 0, 0, 0, 0, time.UTC)
+	report := utils.CalculateReport(reportWorkouts(), reportMetrics(), mustCalendar(t, "UTC", time.Monday), utils.Week, date, now)
+
+	assert.Equal(t, "2024-03-04", report.Start)
+	assert.Equal(t, "2024-03-10", report.End)
+	assert.Equal(t, "2024-02-26", report.Previous)
+	assert.False(t, report.Partial)
+	assert.Equal(t, models.ReportTotals{Workouts: 3, ActiveDays: 2, Duration: 8100, Distance: 15, Energy: 1150}, report.Totals)
+	require.Len(t, report.Changes, 5)
+	assert.Equal(t, "workouts", report.Changes[0].Field)
+	assert.Equal(t, 1.0, report.Changes[0].Delta)
+	assert.Equal(t, 50.0, *report.Changes[0].Percent)
+
+	require.Len(t, report.Buckets, 7)
+	assert.Equal(t, "2024-03-06", report.Buckets[2].Key)
+	assert.Equal(t, 2, report.Buckets[2].Workouts)
+	assert.Equal(t, 5.0, report.Buckets[2].Distance)
+
+	// The 20 km run of the next week does not replace the distance record
+	var distance []models.PersonalRecord
+	for _, record := range report.Records {
+		if record.Category == models.RecordLongestDistance {
+			distance = append(distance, record)
+		}
+	}
+	require.Len(t, distance, 1)
+	assert.Equal(t, "c", distance[0].WorkoutID)
+	assert.Equal(t, 5.0, *distance[0].Previous)
+
+	assert.Equal(t, 2, report.Streaks.Longest.Length)
+	assert.Equal(t, 0, report.Streaks.Current.Length, "The streak broke before the end of the week")
+	assert.Equal(t, 2, report.WeekStreaks.Current.Length)
+
+	require.Len(t, report.TopWorkouts, 3)
+	assert.Equal(t, "c", report.TopWorkouts[0].WorkoutID)
+	assert.Equal(t, "d", report.TopWorkouts[2].WorkoutID)
+
+	require.Len(t, report.Metrics, 1, "A 5 percent change in steps is not notable")
+	assert.Equal(t, "resting_heart_rate", report.Metrics[0].Name)
+	assert.Equal(t, -16.67, report.Metrics[0].Percent)
+	assert.Len(t, report.Metrics[0].Daily, 7)
+}
+
+func TestPartialReportComparesSameLength(t *testing.T) {
+	date := time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2024, time.March, 6, 12, 0, 0, 0, time.UTC)
+	report := utils.CalculateReport(reportWorkouts(), nil, mustCalendar(t, "UTC", time.Monday), utils.Week, date, now)
+
+	assert.True(t, report.Partial)
+	assert.Equal(t, 1.0, report.Changes[0].Previous, "The walk on Friday is past the same point of the previous week")
+	assert.Equal(t, 2, report.Streaks.Current.Length, "The streak runs through today")
+}
+
+func TestReportEndpoint(t *testing.T) {
+	reporter := signup(t, "recap@example.com")
+	body, err := json.Marshal(map[string]any{"data": map[string]any{"workouts": reportWorkouts(), "metrics": reportMetrics()}})
+	require.NoError(t, err)
+	ingest(t, reporter.AccessToken, string(body))
+
+	response := serve(http.MethodGet, "/reports/week/2024-03-07", "", reporter.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	var report models.Report
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &report))
+	assert.Equal(t, "2024-03-04", report.Key)
+	assert.Equal(t, 3, report.Totals.Workouts)
+
+	response = serve(http.MethodGet, "/reports/week/2024-03-07?format=markdown", "", reporter.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Header().Get("Content-Type"), "text/markdown")
+	assert.Contains(t, response.Body.String(), "# Week of 2024-03-04")
+	assert.Contains(t, response.Body.String(), "| Workouts | 3 | 2 | +1 (+50.0%) |")
+	assert.Contains(t, response.Body.String(), "Outdoor Run, longest distance: 10.00 km on 2024-03-05, previously 5.00 km")
+
+	request := httptest.NewRequest(http.MethodGet, "/reports/month/2024-03-01", nil)
+	request.Header.Set("Authorization", "Bearer "+reporter.AccessToken)
+	request.Header.Set("Accept", "text/html")
+	recorder := httptest.NewRecorder()
+	serveAPI().ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, recorder.Body.String(), "<h1>March 2024</h1>")
+	assert.Contains(t, recorder.Body.String(), "<svg class=\"sparkline\"")
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, "/reports/day/2024-03-07", "", reporter.AccessToken).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, "/reports/year/2999-01-01", "", reporter.AccessToken).Code)
+
+	// The CLI reports from the same store
+	var output bytes.Buffer
+	require.NoError(t, api.RunReport([]string{"-user", "recap@example.com", "-format", "markdown", "week", "2024-03-07"}, &output))
+	assert.Contains(t, output.String(), "# Week of 2024-03-04")
+	assert.Error(t, api.RunReport([]string{"-user", "nobody@example.com", "week"}, &output))
+}
@@ -0,0 +1,112 @@
+// test/review_test.go
+
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fitness/api"
+	"fitness/config"
+	"fitness/models"
+	"fitness/utils"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reviewWorkouts are five workouts in 2023 and one in the years before and after
+func reviewWorkouts() []models.Workout {
+	run := func(id, start string, km, seconds, kcal float64) models.Workout {
+		return models.Workout{
+			ID: id, Name: "Outdoor Run", Start: start, Duration: seconds,
+			Distance:           &models.Measurement{Units: "km", Qty: km},
+			ActiveEnergyBurned: &models.Measurement{Units: "kcal", Qty: kcal},
+		}
+	}
+	return []models.Workout{
+		run("before", "2022-06-01 07:00:00 +0000", 5, 1800, 300),
+		run("first", "2023-01-02 07:00:00 +0000", 5, 1500, 300),
+		run("longer", "2023-01-03 07:30:00 +0000", 10, 3300, 600),
+		{ID: "yoga", Name: "Yoga", Start: "2023-01-04 18:00:00 +0000", Duration: 3600, ActiveEnergyBurned: &models.Measurement{Units: "kcal", Qty: 150}},
+		run("half", "2023-03-15 07:00:00 +0000", 21.1, 7200, 1200),
+		{ID: "last", Name: "Walk", Start: "2023-12-31 20:00:00 +0000", Duration: 1800},
+		run("after", "2024-01-01 07:00:00 +0000", 30, 10800, 1800),
+	}
+}
+
+func TestYearInReview(t *testing.T) {
+	now := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	review := utils.CalculateYearInReview(reviewWorkouts(), mustCalendar(t, "UTC", time.Monday), 2023, now)
+
+	assert.False(t, review.Partial)
+	assert.Equal(t, models.ReportTotals{Workouts: 5, ActiveDays: 5, Duration: 17400, Distance: 36.1, Energy: 2250}, review.Totals)
+	assert.Equal(t, 400.0, *review.Changes[0].Percent)
+	require.Len(t, review.Types, 3)
+	assert.Equal(t, models.ReviewType{Name: "Outdoor Run", Workouts: 3, Duration: 12000, Distance: 36.1, Energy: 2100}, review.Types[0])
+	assert.Equal(t, "Yoga", review.Types[1].Name)
+
+	require.NotNil(t, review.BiggestMonth)
+	assert.Equal(t, "2023-01", review.BiggestMonth.Key)
+	require.NotNil(t, review.BiggestWeek)
+	assert.Equal(t, "2023-01-02", review.BiggestWeek.Key)
+	assert.Equal(t, 8400.0, review.BiggestWeek.Duration)
+	assert.Equal(t, models.Streak{Length: 3, Start: "2023-01-02", End: "2023-01-04"}, review.LongestStreak)
+	assert.Equal(t, 1, review.LongestWeekStreak.Length)
+
+	assert.Equal(t, "morning", review.FavoriteTime)
+	assert.Equal(t, 7, *review.FavoriteHour)
+	assert.Equal(t, "Wednesday", review.FavoriteWeekday)
+	assert.Equal(t, "first", review.FirstWorkout.WorkoutID)
+	assert.Equal(t, "last", review.LastWorkout.WorkoutID)
+
+	// The distance record improved twice and is listed once, against the record of 2022
+	var distance []models.PersonalRecord
+	for _, record := range review.Records {
+		if record.Category == models.RecordLongestDistance {
+			distance = append(distance, record)
+		}
+	}
+	require.Len(t, distance, 1)
+	assert.Equal(t, 21.1, distance[0].Value)
+	assert.Equal(t, 5.0, *distance[0].Previous)
+
+	require.NotEmpty(t, review.Equivalences)
+	assert.Equal(t, "marathons", review.Equivalences[0].Name)
+	assert.Equal(t, 0.86, review.Equivalences[0].Value)
+}
+
+func TestYearInReviewEndpoint(t *testing.T) {
+	// Days follow the server's default calendar, which is local time unless pinned
+	timezone := config.Timezone
+	config.Timezone = "UTC"
+	t.Cleanup(func() { config.Timezone = timezone })
+
+	reviewer := signup(t, "review@example.com")
+	body, err := json.Marshal(map[string]any{"data": map[string]any{"workouts": reviewWorkouts(), "metrics": []models.Metric{}}})
+	require.NoError(t, err)
+	ingest(t, reviewer.AccessToken, string(body))
+
+	response := serve(http.MethodGet, "/review/2023", "", reviewer.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	var review models.YearInReview
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &review))
+	assert.Equal(t, 5, review.Totals.Workouts)
+	assert.Len(t, review.Weeks, 53)
+
+	response = serve(http.MethodGet, "/review/2023?format=html", "", reviewer.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, response.Body.String(), "<h1>2023 in review</h1>")
+	assert.Contains(t, response.Body.String(), "Biggest month: January")
+	assert.Contains(t, response.Body.String(), "<svg class=\"sparkline\"")
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, "/review/2999", "", reviewer.AccessToken).Code)
+
+	var output bytes.Buffer
+	require.NoError(t, api.RunReview([]string{"-user", "review@example.com", "-format", "json", "2023"}, &output))
+	require.NoError(t, json.Unmarshal(output.Bytes(), &review))
+	assert.Equal(t, 2023, review.Year)
+}
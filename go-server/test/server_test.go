@@ -0,0 +1,48 @@
+// test/server_test.go
+
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fitness/config"
+	"fitness/data"
+	"fitness/models"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthAndReadiness(t *testing.T) {
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/healthz", "", "").Code)
+
+	// The tests never run the initial import
+	response := serve(http.MethodGet, "/readyz", "", "")
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+	var status models.HealthStatus
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &status))
+	assert.Equal(t, "importing", status.Status)
+}
+
+func TestImportStopsWhenCancelled(t *testing.T) {
+	dir := t.TempDir()
+	export := `{"data": {"workouts": [{"id": "import-1", "name": "Outdoor Run", "start": "2024-03-04 07:00:00 +0000"}], "metrics": []}}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "HealthAutoExport-2024-03-04.json"), []byte(export), 0644))
+	user := models.User{ID: "import-user", Email: "importer@example.com"}
+	config.ImportDirs[user.Email] = dir
+	defer delete(config.ImportDirs, user.Email)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	data.ImportData(cancelled, []models.User{user})
+	assert.Empty(t, data.ForUser(user).Workouts())
+
+	data.ImportData(context.Background(), []models.User{user})
+	workouts := data.ForUser(user).Workouts()
+	require.Len(t, workouts, 1)
+	assert.Equal(t, "import-1", workouts[0].ID)
+}
@@ -0,0 +1,96 @@
+// test/sleep_test.go
+
+package test
+
+import (
+	"encoding/json"
+	"fitness/models"
+	"fitness/utils"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sleepExport is a sleep_analysis metric as Health Auto Export writes it, one
+// night aggregated and the next one as unaggregated stages
+const sleepExport = `{"data": {"workouts": [], "metrics": [{"name": "sleep_analysis", "units": "hr", "data": [
+	{"date": "2024-03-02 00:00:00 +0000", "totalSleep": 7, "asleep": 0, "core": 4, "deep": 1, "rem": 2, "awake": 0.5, "inBed": 8,
+	 "sleepStart": "2024-03-01 22:45:00 +0000", "sleepEnd": "2024-03-02 06:15:00 +0000",
+	 "inBedStart": "2024-03-01 22:30:00 +0000", "inBedEnd": "2024-03-02 06:30:00 +0000", "source": "Apple Watch"},
+	{"startDate": "2024-03-02 23:00:00 +0000", "endDate": "2024-03-03 07:00:00 +0000", "qty": 8, "value": "In Bed", "source": "iPhone"},
+	{"startDate": "2024-03-02 23:00:00 +0000", "endDate": "2024-03-03 02:00:00 +0000", "qty": 3, "value": "Core", "source": "Apple Watch"},
+	{"startDate": "2024-03-03 02:00:00 +0000", "endDate": "2024-03-03 03:00:00 +0000", "qty": 1, "value": "Deep", "source": "Apple Watch"},
+	{"startDate": "2024-03-03 03:00:00 +0000", "endDate": "2024-03-03 05:00:00 +0000", "qty": 2, "value": "REM", "source": "Apple Watch"},
+	{"startDate": "2024-03-03 05:00:00 +0000", "endDate": "2024-03-03 05:30:00 +0000", "qty": 0.5, "value": "Awake", "source": "Apple Watch"},
+	{"startDate": "2024-03-03 05:30:00 +0000", "endDate": "2024-03-03 06:30:00 +0000", "qty": 1, "value": "Core", "source": "Apple Watch"}
+]}]}}`
+
+func TestSleepNights(t *testing.T) {
+	var export models.HealthData
+	require.NoError(t, json.Unmarshal([]byte(sleepExport), &export))
+	// Stages are dated by their start when stored
+	for i := range export.Data.Metrics[0].Data {
+		if point := &export.Data.Metrics[0].Data[i]; point.Date == "" {
+			point.Date = point.StartDate
+		}
+	}
+
+	nights := utils.SleepNights(export.Data.Metrics)
+	require.Len(t, nights, 2)
+	aggregated, staged := nights[0], nights[1]
+	assert.Equal(t, "2024-03-02", aggregated.Night)
+	assert.Equal(t, 7.0, aggregated.Asleep)
+	assert.Equal(t, 8.0, aggregated.InBed)
+	assert.Equal(t, 87.5, *aggregated.Efficiency)
+	assert.Equal(t, "2024-03-01 22:30:00 +0000", aggregated.BedTime)
+	assert.Equal(t, models.SleepStages{Core: 57.14, Deep: 14.29, REM: 28.57}, *aggregated.Stages)
+
+	assert.Equal(t, "2024-03-03", staged.Night)
+	assert.Equal(t, 7.0, staged.Asleep)
+	assert.Equal(t, 8.0, staged.InBed)
+	assert.Equal(t, 0.5, staged.Awake)
+	assert.Equal(t, "2024-03-03 07:00:00 +0000", staged.WakeTime)
+	assert.Equal(t, "iPhone, Apple Watch", staged.Source)
+
+	utils.CalculateSleepDebt(nights, 8)
+	assert.Equal(t, 1.0, nights[0].Debt)
+	assert.Equal(t, 2.0, nights[1].Debt)
+
+	consistency := utils.NewSleepConsistency(nights)
+	assert.Equal(t, "22:45", consistency.BedTime)
+	assert.Equal(t, 21.21, consistency.BedTimeStdDev)
+	assert.Equal(t, "06:45", consistency.WakeTime)
+}
+
+func TestSleepEndpoints(t *testing.T) {
+	sleeper := signup(t, "sleepy@example.com")
+	ingest(t, sleeper.AccessToken, sleepExport)
+
+	response := serve(http.MethodGet, "/sleep/nights?target=7.5", "", sleeper.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	var report models.SleepReport
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &report))
+	require.Len(t, report.Nights, 2, "Stages starting together are kept apart")
+	assert.Equal(t, 7.0, report.Nights[1].Asleep)
+	assert.Equal(t, 7.0, report.Asleep)
+	assert.Equal(t, 1.0, report.Debt)
+
+	// The debt of a night still counts the nights before the range
+	response = serve(http.MethodGet, "/sleep/nights?start=2024-03-03", "", sleeper.AccessToken)
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &report))
+	require.Len(t, report.Nights, 1)
+	assert.Equal(t, 2.0, report.Debt)
+
+	response = serve(http.MethodGet, "/sleep/weekly", "", sleeper.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	var weeks []models.SleepWeek
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &weeks))
+	require.Len(t, weeks, 1)
+	assert.Equal(t, "2024-02-26", weeks[0].Week)
+	assert.Equal(t, 2, weeks[0].Nights)
+	assert.Equal(t, 87.5, weeks[0].Efficiency)
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, "/sleep/nights?target=20", "", sleeper.AccessToken).Code)
+}
@@ -0,0 +1,100 @@
+// test/splits_test.go
+
+package test
+
+import (
+	"encoding/json"
+	"fitness/models"
+	"fitness/utils"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// negativeSplitRun is a 5 km run at 5:00 per km for the first half and 4:40 per km for the second
+func negativeSplitRun() models.Workout {
+	start := time.Date(2024, time.April, 6, 8, 0, 0, 0, time.UTC)
+	run := models.Workout{
+		ID: "run-1", Name: "Outdoor Run", Start: start.Format("2006-01-02 15:04:05 -0700"),
+		End: start.Add(1450 * time.Second).Format("2006-01-02 15:04:05 -0700"), Duration: 1450,
+		Distance: &models.Measurement{Units: "km", Qty: 5},
+	}
+	elapsed := 0
+	for i := 0; i < 10; i++ {
+		run.WalkingAndRunningDistance = append(run.WalkingAndRunningDistance, models.QuantitySample{
+			Date: start.Add(time.Duration(elapsed) * time.Second).Format("2006-01-02 15:04:05 -0700"), Qty: 0.5, Units: "km",
+		})
+		if i < 5 {
+			elapsed += 150
+		} else {
+			elapsed += 140
+		}
+	}
+	return run
+}
+
+func TestSplitsDetectNegativeSplits(t *testing.T) {
+	splits := utils.CalculateSplits(negativeSplitRun(), utils.Metric)
+	require.Len(t, splits.Splits, 5)
+	var durations []float64
+	for _, split := range splits.Splits {
+		durations = append(durations, split.Duration)
+	}
+	assert.Equal(t, []float64{300, 300, 290, 280, 280}, durations)
+	assert.Equal(t, "05:00", splits.Splits[0].PaceText)
+	assert.Equal(t, 1450.0, splits.Splits[4].Elapsed)
+	assert.Equal(t, 750.0, splits.FirstHalf)
+	assert.Equal(t, 700.0, splits.SecondHalf)
+	assert.True(t, splits.NegativeSplit)
+
+	// Miles end with a partial split
+	splits = utils.CalculateSplits(negativeSplitRun(), utils.Imperial)
+	require.Len(t, splits.Splits, 4)
+	assert.Equal(t, 1.0, splits.Splits[0].Distance)
+	assert.InDelta(t, 0.11, splits.Splits[3].Distance, 0.001)
+	require.NotNil(t, splits.Pace)
+	assert.Equal(t, 3.11, splits.Pace.Distance)
+}
+
+func TestWorkoutsIncludePace(t *testing.T) {
+	runner := signup(t, "pacer@example.com")
+	body, err := json.Marshal(map[string]any{"data": map[string]any{
+		"workouts": []models.Workout{negativeSplitRun()}, "metrics": []models.Metric{},
+	}})
+	require.NoError(t, err)
+	ingest(t, runner.AccessToken, string(body))
+
+	response := serve(http.MethodGet, "/workouts?units=imperial", "", runner.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code)
+	var workouts []models.Workout
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &workouts))
+	require.Len(t, workouts, 1)
+	require.NotNil(t, workouts[0].Pace)
+	assert.Equal(t, utils.Imperial, workouts[0].Pace.Units)
+	assert.Equal(t, "07:47", workouts[0].Pace.PaceText)
+	assert.Equal(t, 7.71, workouts[0].Pace.Speed)
+	require.NotNil(t, workouts[0].Pace.NegativeSplit)
+	assert.True(t, *workouts[0].Pace.NegativeSplit)
+
+	// The profile sets the default unit system
+	response = serve(http.MethodPatch, "/auth/me", `{"units": "imperial"}`, runner.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code)
+	response = serve(http.MethodGet, "/workouts/run-1/splits", "", runner.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code)
+	var splits models.WorkoutSplits
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &splits))
+	assert.Equal(t, utils.Imperial, splits.Units)
+	assert.Len(t, splits.Splits, 4)
+
+	response = serve(http.MethodGet, "/workouts/run-1/splits?format=csv", "", runner.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code)
+	// A header and one row per split
+	assert.Equal(t, 5, strings.Count(response.Body.String(), "\n"))
+
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/workouts/unknown/splits", "", runner.AccessToken).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, "/workouts/run-1/splits?units=furlongs", "", runner.AccessToken).Code)
+}
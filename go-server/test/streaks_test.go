@@ -0,0 +1,40 @@
+// test/streaks_test.go
+
+package test
+
+import (
+	"fitness/models"
+	"fitness/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreaksUseTheUserTimezone(t *testing.T) {
+	calendar, err := utils.NewCalendar("America/New_York", time.Monday)
+	require.NoError(t, err)
+	workouts := []models.Workout{
+		// 01:30 UTC on the 5th is still the evening of the 4th in New York
+		{Name: "Outdoor Run", Start: "2024-03-05 01:30:00 +0000", Duration: 1800},
+		{Name: "Outdoor Run", Start: "2024-03-05 07:00:00 -0500", Duration: 1800},
+		{Name: "Outdoor Run", Start: "2024-03-06 07:00:00 -0500", Duration: 600},
+		{Name: "Outdoor Run", Start: "2024-03-08 07:00:00 -0500", Duration: 1800},
+	}
+	now := time.Date(2024, time.March, 8, 20, 0, 0, 0, calendar.Location)
+
+	streaks := utils.CalculateStreaks(workouts, calendar, utils.StreakRules{}, now)
+	assert.Equal(t, models.Streak{Length: 3, Start: "2024-03-04", End: "2024-03-06"}, streaks.Daily.Longest)
+	assert.Equal(t, models.Streak{Length: 1, Start: "2024-03-08", End: "2024-03-08"}, streaks.Daily.Current)
+
+	// A rest day keeps the streak alive, a short session does not count
+	rules := utils.StreakRules{MinDuration: 900, RestDays: 1}
+	streaks = utils.CalculateStreaks(workouts, calendar, rules, now)
+	assert.Equal(t, models.Streak{Length: 2, Start: "2024-03-04", End: "2024-03-05"}, streaks.Daily.Longest)
+	assert.Equal(t, 1, streaks.Daily.Current.Length)
+
+	require.Len(t, streaks.Weeks, 1)
+	assert.Equal(t, 3, streaks.Weeks[0].ActiveDays)
+	assert.Equal(t, 1, streaks.Weekly.Current.Length)
+}
@@ -0,0 +1,112 @@
+// test/training_test.go
+
+package test
+
+import (
+	"fitness/models"
+	"fitness/utils"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkoutLoadPrefersHeartRate(t *testing.T) {
+	heartRate := utils.HeartRateProfile{Resting: 50, Max: 190, Sex: "male"}
+	run := models.Workout{
+		Duration:  3600,
+		HeartRate: &models.HeartRate{Avg: &models.Measurement{Units: "bpm", Qty: 155}},
+		Intensity: &models.Measurement{Units: "kcal/hr·kg", Qty: 9},
+	}
+	load, method, ok := utils.WorkoutLoad(run, heartRate)
+	require.True(t, ok)
+	assert.Equal(t, utils.LoadTRIMP, method)
+	// 60 minutes at 75% of the heart rate reserve
+	assert.InDelta(t, 60*0.75*0.64*4.2207, load, 0.1)
+
+	// Women are weighted with both their own factor and exponent
+	heartRate.Sex = "female"
+	load, _, _ = utils.WorkoutLoad(run, heartRate)
+	assert.InDelta(t, 135.41, load, 0.1)
+	heartRate.Sex = ""
+	load, _, _ = utils.WorkoutLoad(run, heartRate)
+	assert.InDelta(t, 60*0.75*0.75*math.Exp(1.795*0.75), load, 0.1)
+	heartRate.Sex = "male"
+
+	run.HeartRate = nil
+	load, method, ok = utils.WorkoutLoad(run, heartRate)
+	require.True(t, ok)
+	assert.Equal(t, utils.LoadMETs, method)
+	assert.InDelta(t, 90, load, 0.001)
+
+	run.Intensity = nil
+	_, _, ok = utils.WorkoutLoad(run, heartRate)
+	assert.False(t, ok)
+}
+
+func TestHeartRateProfileFallsBackToData(t *testing.T) {
+	workouts := []models.Workout{
+		{HeartRate: &models.HeartRate{Max: &models.Measurement{Qty: 182}}},
+		{HeartRate: &models.HeartRate{Max: &models.Measurement{Qty: 187}}},
+	}
+	metrics := []models.Metric{{Name: "resting_heart_rate", Data: []models.MetricData{
+		{Date: "2024-03-02 00:00:00 +0000", Qty: 52},
+		{Date: "2024-03-01 00:00:00 +0000", Qty: 58},
+	}}}
+
+	heartRate := utils.NewHeartRateProfile(models.Profile{}, workouts, metrics)
+	assert.Equal(t, 52.0, heartRate.Resting)
+	assert.Equal(t, 187.0, heartRate.Max)
+	assert.InDelta(t, 168.3, heartRate.LactateThreshold, 1e-9)
+
+	profile := models.Profile{RestingHeartRate: 45, MaxHeartRate: 195, LactateThresholdHeartRate: 172, Sex: "female"}
+	heartRate = utils.NewHeartRateProfile(profile, workouts, metrics)
+	assert.Equal(t, utils.HeartRateProfile{Resting: 45, Max: 195, Sex: "female", LactateThreshold: 172}, heartRate)
+}
+
+func TestTrainingLoadAverages(t *testing.T) {
+	calendar := mustCalendar(t, "UTC", time.Monday)
+	var workouts []models.Workout
+	// Twelve weeks of an hour at 8 METs a day, then a hard week
+	start := time.Date(2024, time.January, 1, 7, 0, 0, 0, time.UTC)
+	for day := 0; day < 91; day++ {
+		intensity := 8.0
+		if day >= 84 {
+			intensity = 16
+		}
+		workouts = append(workouts, models.Workout{
+			ID: "w", Name: "Outdoor Run", Start: start.AddDate(0, 0, day).Format("2006-01-02 15:04:05 -0700"),
+			Duration: 3600, Intensity: &models.Measurement{Qty: intensity},
+		})
+	}
+	now := start.AddDate(0, 0, 90).Add(12 * time.Hour)
+
+	trainingLoad := utils.CalculateTrainingLoad(workouts, calendar, utils.HeartRateProfile{}, now)
+	require.Len(t, trainingLoad.Days, 91)
+	assert.Len(t, trainingLoad.Workouts, len(workouts))
+
+	first := trainingLoad.Days[0]
+	assert.Equal(t, "2024-01-01", first.Date)
+	assert.Equal(t, 80.0, first.Load)
+	assert.Zero(t, first.TSB)
+	// A new history has no chronic load to compare with yet
+	assert.Nil(t, first.ACWR)
+	assert.Empty(t, first.Risk)
+	assert.Nil(t, trainingLoad.Days[40].ACWR)
+	require.NotNil(t, trainingLoad.Days[41].ACWR)
+
+	// Steady training keeps the ratio in the optimal band
+	steady := trainingLoad.Days[83]
+	require.NotNil(t, steady.ACWR)
+	assert.Equal(t, "optimal", steady.Risk)
+	assert.Greater(t, steady.ATL, steady.CTL*0.8)
+
+	// A sudden jump in load raises the acute load well above the chronic load
+	last := trainingLoad.Days[90]
+	require.NotNil(t, last.ACWR)
+	assert.Greater(t, *last.ACWR, 1.5)
+	assert.Equal(t, "high", last.Risk)
+	assert.Less(t, last.TSB, 0.0)
+}
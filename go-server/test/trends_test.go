@@ -0,0 +1,112 @@
+// test/trends_test.go
+
+package test
+
+import (
+	"encoding/json"
+	"fitness/models"
+	"fitness/utils"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStudentTDistribution(t *testing.T) {
+	assert.InDelta(t, 2.228, utils.TCritical(0.95, 10), 0.001)
+	assert.InDelta(t, 1.960, utils.TCritical(0.95, 10000), 0.001)
+	assert.InDelta(t, 0.05, utils.TTestPValue(2.228, 10), 0.001)
+	assert.InDelta(t, 1, utils.TTestPValue(0, 5), 1e-9)
+}
+
+func TestRegressionAndForecast(t *testing.T) {
+	// Three years of months with a rising trend and a summer peak
+	var values []float64
+	for month := 0; month < 36; month++ {
+		values = append(values, 100+2*float64(month)+20*math.Sin(2*math.Pi*float64(month)/12))
+	}
+
+	regression, ok := utils.LinearRegression(values)
+	require.True(t, ok)
+	assert.Equal(t, "up", regression.Direction)
+	assert.Less(t, regression.Lower, 2.0)
+	assert.Greater(t, regression.Upper, 2.0)
+	assert.Less(t, regression.PValue, 0.001)
+
+	forecast, _, method := utils.Forecast(values, 12, 12)
+	assert.Equal(t, utils.HoltWinters, method)
+	require.Len(t, forecast, 12)
+	for h, value := range forecast {
+		month := 36 + h
+		expected := 100 + 2*float64(month) + 20*math.Sin(2*math.Pi*float64(month)/12)
+		assert.InDelta(t, expected, value, 5, "Forecast %d months ahead", h+1)
+	}
+
+	// Without two full seasons the forecast follows the trend only
+	_, _, method = utils.Forecast(values[:20], 12, 4)
+	assert.Equal(t, utils.Holt, method)
+
+	assert.Equal(t, []float64{2, 3, 4}, utils.MovingAverage([]float64{1, 2, 3, 4, 5}, 3))
+	_, ok = utils.LinearRegression([]float64{1, 2})
+	assert.False(t, ok)
+}
+
+func TestTrendSeriesSkipsTheCurrentPeriod(t *testing.T) {
+	calendar := mustCalendar(t, "UTC", time.Monday)
+	workouts := []models.Workout{
+		{Start: "2024-03-04 07:00:00 +0000", Distance: &models.Measurement{Units: "km", Qty: 10}},
+		{Start: "2024-03-19 07:00:00 +0000", Distance: &models.Measurement{Units: "km", Qty: 8}},
+		{Start: "2024-03-26 07:00:00 +0000", Distance: &models.Measurement{Units: "km", Qty: 5}},
+	}
+	now := time.Date(2024, time.March, 27, 12, 0, 0, 0, time.UTC)
+
+	buckets := utils.TrendSeries(models.GoalDistance, "km", workouts, nil, calendar, utils.Week, now)
+	var values []float64
+	for _, bucket := range buckets {
+		values = append(values, bucket.Value)
+	}
+	assert.Equal(t, []float64{10, 0, 8}, values)
+
+	metrics := []models.Metric{{Name: "weight_body_mass", Data: []models.MetricData{
+		{Date: "2024-03-04 00:00:00 +0000", Qty: 80}, {Date: "2024-03-06 00:00:00 +0000", Qty: 79},
+		{Date: "2024-03-19 00:00:00 +0000", Qty: 78},
+	}}}
+	buckets = utils.TrendSeries("weight_body_mass", "km", nil, metrics, calendar, utils.Week, now)
+	values = nil
+	for _, bucket := range buckets {
+		values = append(values, bucket.Value)
+	}
+	assert.Equal(t, []float64{79.5, 79.5, 78}, values)
+}
+
+func TestTrendsEndpoint(t *testing.T) {
+	runner := signup(t, "trender@example.com")
+	start := time.Now().AddDate(0, 0, -70)
+	var workouts []models.Workout
+	for week := 0; week < 10; week++ {
+		day := start.AddDate(0, 0, 7*week).Format("2006-01-02 15:04:05 -0700")
+		workouts = append(workouts, models.Workout{
+			ID: "run-" + day, Name: "Outdoor Run", Start: day, End: day, Duration: 1800,
+			Distance: &models.Measurement{Units: "km", Qty: float64(5 + week)},
+		})
+	}
+	body, err := json.Marshal(map[string]any{"data": map[string]any{"workouts": workouts, "metrics": []models.Metric{}}})
+	require.NoError(t, err)
+	ingest(t, runner.AccessToken, string(body))
+
+	response := serve(http.MethodGet, "/stats/trends?series=distance&bucket=week&horizon=3", "", runner.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code)
+	var trends models.Trends
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &trends))
+	assert.Equal(t, "distance", trends.Series)
+	assert.NotEmpty(t, trends.Points)
+	require.NotNil(t, trends.Regression)
+	assert.Greater(t, trends.Regression.Slope, 0.0)
+	assert.Len(t, trends.Forecast, 3)
+	assert.Equal(t, utils.Holt, trends.Method)
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, "/stats/trends?horizon=500", "", runner.AccessToken).Code)
+}
@@ -0,0 +1,118 @@
+// test/zones_test.go
+
+package test
+
+import (
+	"encoding/json"
+	"fitness/models"
+	"fitness/utils"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// intervalRun returns a ten minute run with a heart rate sample every minute
+func intervalRun(id string, start time.Time) models.Workout {
+	workout := models.Workout{
+		ID: id, Name: "Outdoor Run", Duration: 600,
+		Start: start.Format("2006-01-02 15:04:05 -0700"), End: start.Add(10 * time.Minute).Format("2006-01-02 15:04:05 -0700"),
+	}
+	for minute, bpm := range []float64{110, 110, 110, 110, 130, 130, 130, 170, 170, 185} {
+		workout.HeartRateData = append(workout.HeartRateData, models.HeartRateSample{
+			Date: start.Add(time.Duration(minute) * time.Minute).Format("2006-01-02 15:04:05 -0700"),
+			Avg:  bpm, Units: "count/min",
+		})
+	}
+	return workout
+}
+
+func TestHeartRateZoneModels(t *testing.T) {
+	heartRate := utils.HeartRateProfile{Resting: 50, Max: 200, LactateThreshold: 170}
+	lows := func(zones []models.HeartRateZone) []float64 {
+		var values []float64
+		for _, zone := range zones {
+			values = append(values, zone.Low)
+		}
+		return values
+	}
+	assert.Equal(t, []float64{100, 120, 140, 160, 180}, lows(utils.HeartRateZones(utils.ZoneMax, heartRate)))
+	assert.Equal(t, []float64{125, 140, 155, 170, 185}, lows(utils.HeartRateZones(utils.ZoneReserve, heartRate)))
+	assert.Equal(t, []float64{0, 144.5, 153, 161.5, 170}, lows(utils.HeartRateZones(utils.ZoneThreshold, heartRate)))
+
+	zones := utils.CalculateWorkoutZones(intervalRun("intervals", time.Date(2024, time.April, 2, 7, 0, 0, 0, time.UTC)), utils.ZoneMax, heartRate)
+	assert.Equal(t, utils.ZoneSamples, zones.Source)
+	var seconds []float64
+	for _, zone := range zones.Time {
+		seconds = append(seconds, zone.Seconds)
+	}
+	assert.Equal(t, []float64{240, 180, 0, 120, 60}, seconds)
+	assert.Equal(t, 70.0, zones.Distribution.Low)
+	assert.Equal(t, 30.0, zones.Distribution.High)
+	assert.Equal(t, "polarized", zones.Distribution.Pattern)
+	require.NotNil(t, zones.Distribution.PolarizationIndex)
+	assert.Equal(t, 3.32, *zones.Distribution.PolarizationIndex)
+
+	// Without samples the workout counts in the zone of its average
+	average := models.Workout{Duration: 1800, HeartRate: &models.HeartRate{Avg: &models.Measurement{Qty: 150}}}
+	zones = utils.CalculateWorkoutZones(average, utils.ZoneMax, heartRate)
+	assert.Equal(t, utils.ZoneAverage, zones.Source)
+	assert.Equal(t, 1800.0, zones.Time[2].Seconds)
+	assert.Equal(t, "threshold", zones.Distribution.Pattern)
+}
+
+func TestIntensityDistributionPatterns(t *testing.T) {
+	assert.Equal(t, "pyramidal", utils.NewIntensityDistribution([]float64{50, 20, 15, 10, 5}).Pattern)
+	assert.Equal(t, "threshold", utils.NewIntensityDistribution([]float64{10, 10, 50, 20, 10}).Pattern)
+	assert.Equal(t, "high-intensity", utils.NewIntensityDistribution([]float64{10, 10, 10, 40, 30}).Pattern)
+	assert.Empty(t, utils.NewIntensityDistribution([]float64{0, 0, 0, 0, 0}).Pattern)
+}
+
+func TestZoneEndpoints(t *testing.T) {
+	runner := signup(t, "zoned@example.com")
+	require.Equal(t, http.StatusOK, serve(http.MethodPatch, "/auth/me", `{"restingHeartRate": 50, "maxHeartRate": 200}`, runner.AccessToken).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPatch, "/auth/me", `{"zoneModel": "lactate"}`, runner.AccessToken).Code)
+
+	first := time.Date(2024, time.April, 2, 7, 0, 0, 0, time.UTC)
+	easy := first.AddDate(0, 0, 14)
+	workouts := []models.Workout{
+		intervalRun("intervals", first),
+		{
+			ID: "easy", Name: "Outdoor Run", Duration: 1800,
+			Start: easy.Format("2006-01-02 15:04:05 -0700"), End: easy.Add(30 * time.Minute).Format("2006-01-02 15:04:05 -0700"),
+			HeartRate: &models.HeartRate{Avg: &models.Measurement{Units: "count/min", Qty: 125}},
+		},
+	}
+	body, err := json.Marshal(map[string]any{"data": map[string]any{"workouts": workouts, "metrics": []models.Metric{}}})
+	require.NoError(t, err)
+	ingest(t, runner.AccessToken, string(body))
+
+	response := serve(http.MethodGet, "/workouts/intervals/zones", "", runner.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	var zones models.WorkoutZones
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &zones))
+	assert.Equal(t, utils.ZoneMax, zones.Model)
+	assert.Equal(t, 120.0, zones.Time[3].Seconds)
+
+	response = serve(http.MethodGet, "/stats/zones?bucket=week", "", runner.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	var trends models.ZoneTrends
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &trends))
+	require.Len(t, trends.Periods, 3)
+	assert.Equal(t, 600.0, trends.Periods[0].Total)
+	assert.Equal(t, 0, trends.Periods[1].Workouts)
+	assert.Equal(t, 1800.0, trends.Periods[2].Zone2)
+
+	response = serve(http.MethodGet, "/stats/zones/distribution?model=reserve", "", runner.AccessToken)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	var distribution models.ZoneDistribution
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &distribution))
+	assert.Equal(t, 2, distribution.Workouts)
+	assert.Equal(t, 1, distribution.Samples)
+	assert.Equal(t, 2400.0, distribution.Time[0].Seconds+distribution.Time[1].Seconds+distribution.Time[2].Seconds+distribution.Time[3].Seconds+distribution.Time[4].Seconds)
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, "/stats/zones?model=lactate", "", runner.AccessToken).Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/workouts/missing/zones", "", runner.AccessToken).Code)
+}
@@ -0,0 +1,112 @@
+// utils/anomalies.go
+package utils
+
+import (
+	"fitness/config"
+	"fitness/models"
+	"math"
+	"sort"
+)
+
+// Scale that makes the median absolute deviation comparable to a standard deviation
+const madScale = 0.6745
+
+// AnomalyRules decide how a metric's baseline is built and when a day is flagged
+type AnomalyRules struct {
+	Window     int     // Days of data in the rolling baseline
+	MinHistory int     // Days of data needed before a day can be flagged
+	Threshold  float64 // Score both the z-score and the robust score must reach
+}
+
+// DailyValue is the average value of a metric on a day
+type DailyValue struct {
+	Date  string
+	Value float64
+}
+
+// DailyMetricValues averages the data points of a metric per day, taking the
+// day from the date as recorded so it follows the device's timezone. Sleep
+// analysis points carry no quantity, its value is the hours asleep per night
+// on the day the night ends.
+func DailyMetricValues(metric models.Metric) []DailyValue {
+	if metric.Name == SleepMetric {
+		nights := SleepNights([]models.Metric{metric})
+		days := make([]DailyValue, 0, len(nights))
+		for _, night := range nights {
+			days = append(days, DailyValue{Date: night.Night, Value: night.Asleep})
+		}
+		return days
+	}
+	sums := make(map[string]float64)
+	counts := make(map[string]float64)
+	for _, point := range metric.Data {
+		if len(point.Date) < len(config.DateFormat) {
+			continue
+		}
+		day := point.Date[:len(config.DateFormat)]
+		sums[day] += point.Qty
+		counts[day]++
+	}
+	days := make([]DailyValue, 0, len(sums))
+	for day, sum := range sums {
+		days = append(days, DailyValue{Date: day, Value: sum / counts[day]})
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Date < days[j].Date })
+	return days
+}
+
+// DetectAnomalies compares every day on or after from with the days of data
+// before it. A day is flagged when it strays from the rolling mean and median
+// by at least the threshold, the median absolute deviation being ignored when
+// it is zero.
+func DetectAnomalies(name string, days []DailyValue, rules AnomalyRules, from string) []models.Anomaly {
+	var anomalies []models.Anomaly
+	minHistory := max(rules.MinHistory, 2)
+	for i, day := range days {
+		if day.Date < from || i < minHistory {
+			continue
+		}
+		window := make([]float64, 0, rules.Window)
+		for _, previous := range days[max(0, i-rules.Window):i] {
+			window = append(window, previous.Value)
+		}
+		stdDev := StdDev(window)
+		if stdDev == 0 {
+			continue
+		}
+		median := Median(window)
+		deviations := make([]float64, len(window))
+		for j, value := range window {
+			deviations[j] = math.Abs(value - median)
+		}
+		mad := Median(deviations)
+
+		zScore := (day.Value - Mean(window)) / stdDev
+		score := math.Abs(zScore)
+		robust := 0.0
+		if mad > 0 {
+			robust = madScale * (day.Value - median) / mad
+			score = math.Min(score, math.Abs(robust))
+		}
+		if score < rules.Threshold {
+			continue
+		}
+
+		anomaly := models.Anomaly{
+			Metric: name, Date: day.Date, Value: round(day.Value), Baseline: round(median),
+			ZScore: round(zScore), RobustScore: round(robust), Direction: "high",
+			Severity: "mild", Threshold: rules.Threshold,
+		}
+		if day.Value < median {
+			anomaly.Direction = "low"
+		}
+		switch {
+		case score >= 2*rules.Threshold:
+			anomaly.Severity = "severe"
+		case score >= 1.5*rules.Threshold:
+			anomaly.Severity = "moderate"
+		}
+		anomalies = append(anomalies, anomaly)
+	}
+	return anomalies
+}
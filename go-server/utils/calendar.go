@@ -0,0 +1,201 @@
+// utils/calendar.go
+package utils
+
+import (
+	"fitness/config"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Period is the size of a calendar bucket
+type Period string
+
+// Supported calendar bucket sizes
+const (
+	Day     Period = "day"
+	Week    Period = "week"    // Starts on the calendar's configured first weekday
+	ISOWeek Period = "isoweek" // ISO 8601 week, always starting on Monday
+	Month   Period = "month"
+	Year    Period = "year"
+)
+
+// Calendar buckets timestamps into days, weeks, months and years in a timezone
+type Calendar struct {
+	Location  *time.Location // Timezone used for day boundaries
+	WeekStart time.Weekday   // First day of the week for Week buckets
+}
+
+// Bucket is a single calendar period and its aggregated value
+type Bucket struct {
+	Key   string    `json:"key"`   // Label of the period, see Calendar.Key
+	Start time.Time `json:"start"` // Start of the period in the calendar's timezone
+	Value float64   `json:"value"` // Aggregated value for the period
+}
+
+// NewCalendar creates a calendar for the IANA timezone name and first weekday
+func NewCalendar(timezone string, weekStart time.Weekday) (Calendar, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return Calendar{}, fmt.Errorf("invalid timezone %q: %v", timezone, err)
+	}
+	return Calendar{Location: location, WeekStart: weekStart}, nil
+}
+
+// DefaultCalendar returns the calendar described by the config settings,
+// falling back to UTC weeks starting on Monday when they are invalid
+func DefaultCalendar() Calendar {
+	weekStart, err := ParseWeekday(config.WeekStart)
+	if err != nil {
+		weekStart = time.Monday
+	}
+	calendar, err := NewCalendar(config.Timezone, weekStart)
+	if err != nil {
+		return Calendar{Location: time.UTC, WeekStart: weekStart}
+	}
+	return calendar
+}
+
+// ParseWeekday parses an English weekday name such as "monday" or "Sun"
+func ParseWeekday(name string) (time.Weekday, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		full := strings.ToLower(day.String())
+		if name == full || (len(name) >= 3 && strings.HasPrefix(full, name)) {
+			return day, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("invalid weekday %q", name)
+}
+
+// ParsePeriod parses a bucket size name, accepting "iso" as an alias for ISO weeks
+func ParsePeriod(name string) (Period, error) {
+	switch period := Period(strings.ToLower(name)); period {
+	case Day, Week, ISOWeek, Month, Year:
+		return period, nil
+	case "iso":
+		return ISOWeek, nil
+	}
+	return "", fmt.Errorf("invalid period %q", name)
+}
+
+// ParseTime parses a workout or metric timestamp in the Health Auto Export format,
+// also accepting RFC 3339 timestamps
+func ParseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(config.TimeFormat, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// In converts the time into the calendar's timezone
+func (c Calendar) In(t time.Time) time.Time {
+	if c.Location == nil {
+		return t.UTC()
+	}
+	return t.In(c.Location)
+}
+
+// StartOf returns the start of the period containing t. Day boundaries are
+// computed on the wall clock, so days spanning a DST change stay whole.
+func (c Calendar) StartOf(t time.Time, period Period) time.Time {
+	t = c.In(t)
+	year, month, day := t.Date()
+	switch period {
+	case Week, ISOWeek:
+		weekStart := c.WeekStart
+		if period == ISOWeek {
+			weekStart = time.Monday
+		}
+		offset := (int(t.Weekday()) - int(weekStart) + 7) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
+	case Month:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	case Year:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// Next returns the start of the period following the one that starts at start
+func (c Calendar) Next(start time.Time, period Period) time.Time {
+	start = c.StartOf(start, period)
+	switch period {
+	case Week, ISOWeek:
+		return start.AddDate(0, 0, 7)
+	case Month:
+		return start.AddDate(0, 1, 0)
+	case Year:
+		return start.AddDate(1, 0, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// Key labels the period containing t: "2006-01-02" for days and weeks (the
+// first day of the week), "2006-W01" for ISO weeks, "2006-01" for months and
+// "2006" for years
+func (c Calendar) Key(t time.Time, period Period) string {
+	start := c.StartOf(t, period)
+	switch period {
+	case ISOWeek:
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	case Month:
+		return start.Format("2006-01")
+	case Year:
+		return start.Format("2006")
+	default:
+		return start.Format(config.DateFormat)
+	}
+}
+
+// Range returns the start of every period between from and to, inclusive
+func (c Calendar) Range(from, to time.Time, period Period) []time.Time {
+	var starts []time.Time
+	end := c.StartOf(to, period)
+	for start := c.StartOf(from, period); !start.After(end); start = c.Next(start, period) {
+		starts = append(starts, start)
+	}
+	return starts
+}
+
+// Aggregate sums value(item) per period for items with a parsable timestamp.
+// Periods between the first and last item without data are filled with zeros.
+func Aggregate[T any](c Calendar, items []T, period Period, timestamp func(T) string, value func(T) float64) []Bucket {
+	totals := make(map[string]float64)
+	var first, last time.Time
+	for _, item := range items {
+		t, err := ParseTime(timestamp(item))
+		if err != nil {
+			continue
+		}
+		if first.IsZero() || t.Before(first) {
+			first = t
+		}
+		if last.IsZero() || t.After(last) {
+			last = t
+		}
+		totals[c.Key(t, period)] += value(item)
+	}
+	if first.IsZero() {
+		return nil
+	}
+
+	var buckets []Bucket
+	for _, start := range c.Range(first, last, period) {
+		key := c.Key(start, period)
+		buckets = append(buckets, Bucket{Key: key, Start: start, Value: totals[key]})
+	}
+	return buckets
+}
+
+// BucketMap converts buckets into a map keyed by period label
+func BucketMap(buckets []Bucket) map[string]float64 {
+	result := make(map[string]float64, len(buckets))
+	for _, bucket := range buckets {
+		result[bucket.Key] = bucket.Value
+	}
+	return result
+}
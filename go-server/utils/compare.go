@@ -0,0 +1,118 @@
+// utils/compare.go
+package utils
+
+import (
+	"fitness/config"
+	"fitness/models"
+	"time"
+)
+
+// ComparisonPeriods returns the period in progress up to today and the period
+// offset periods before it. The earlier period is cut to the same number of
+// days, so month to date is compared with the same days of the month before.
+func ComparisonPeriods(calendar Calendar, period Period, offset int, now time.Time) (current, previous models.ComparisonPeriod) {
+	start := calendar.StartOf(now, period)
+	today := calendar.StartOf(now, Day)
+	elapsed := daysBetween(start, today) + 1
+	current = models.ComparisonPeriod{
+		Key: calendar.Key(start, period), Start: start.Format(config.DateFormat),
+		End: today.Format(config.DateFormat), Days: elapsed,
+	}
+
+	var earlier time.Time
+	switch period {
+	case Week, ISOWeek:
+		earlier = start.AddDate(0, 0, -7*offset)
+	case Month:
+		earlier = start.AddDate(0, -offset, 0)
+	case Year:
+		earlier = start.AddDate(-offset, 0, 0)
+	default:
+		earlier = start.AddDate(0, 0, -offset)
+	}
+	earlier = calendar.StartOf(earlier, period)
+	last := calendar.Next(earlier, period).AddDate(0, 0, -1)
+	end := earlier.AddDate(0, 0, elapsed-1)
+	if end.After(last) {
+		end = last
+	}
+	previous = models.ComparisonPeriod{
+		Key: calendar.Key(earlier, period), Start: earlier.Format(config.DateFormat),
+		End: end.Format(config.DateFormat), Days: daysBetween(earlier, end) + 1,
+	}
+	return current, previous
+}
+
+// CompareWorkouts compares the totals of two sets of workouts and their
+// averages per workout
+func CompareWorkouts(current, previous []models.Workout) (totals, averages []models.ReportChange) {
+	sum := func(workouts []models.Workout) (count, duration, distance, energy float64) {
+		for _, workout := range workouts {
+			count++
+			duration += workout.Duration
+			distance += workoutKm(workout)
+			energy += workoutKcal(workout)
+		}
+		return
+	}
+	count, duration, distance, energy := sum(current)
+	previousCount, previousDuration, previousDistance, previousEnergy := sum(previous)
+	totals = []models.ReportChange{
+		newChange("workouts", count, previousCount),
+		newChange("duration", duration, previousDuration),
+		newChange("distance", distance, previousDistance),
+		newChange("energy", energy, previousEnergy),
+	}
+	per := func(total, count float64) float64 {
+		if count == 0 {
+			return 0
+		}
+		return total / count
+	}
+	averages = []models.ReportChange{
+		newChange("duration", per(duration, count), per(previousDuration, previousCount)),
+		newChange("distance", per(distance, count), per(previousDistance, previousCount)),
+		newChange("energy", per(energy, count), per(previousEnergy, previousCount)),
+	}
+	return totals, averages
+}
+
+// CompareMetric compares the sums of the data points of a metric in two
+// periods and the averages of its daily values
+func CompareMetric(current, previous models.Metric) (totals, averages []models.ReportChange) {
+	sum := func(metric models.Metric) float64 {
+		total := 0.0
+		for _, point := range metric.Data {
+			total += point.Qty
+		}
+		return total
+	}
+	average := func(metric models.Metric) float64 {
+		var values []float64
+		for _, day := range DailyMetricValues(metric) {
+			values = append(values, day.Value)
+		}
+		return Mean(values)
+	}
+	totals = []models.ReportChange{newChange(current.Name, sum(current), sum(previous))}
+	averages = []models.ReportChange{newChange(current.Name, average(current), average(previous))}
+	return totals, averages
+}
+
+// newChange compares a value with the previous one, the percent being unset when that is zero
+func newChange(field string, current, previous float64) models.ReportChange {
+	change := models.ReportChange{
+		Field: field, Current: round(current), Previous: round(previous), Delta: round(current - previous),
+	}
+	if previous != 0 {
+		change.Percent = roundedPointer(100 * (current - previous) / previous)
+	}
+	return change
+}
+
+// daysBetween counts the calendar days from one day to another, ignoring DST changes
+func daysBetween(from, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
@@ -0,0 +1,168 @@
+// utils/correlation.go
+package utils
+
+import (
+	"fitness/config"
+	"fitness/models"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Correlation coefficients
+const (
+	Pearson  = "pearson"
+	Spearman = "spearman"
+)
+
+// Significance level of correlations
+const significanceLevel = 0.05
+
+// DailySeries returns the values of a series per day. Workout fields are summed
+// per calendar day, with zeros on the days between workouts. Health metrics are
+// averaged per recorded day and missing on days without data.
+func DailySeries(name string, workouts []models.Workout, metrics []models.Metric, calendar Calendar) map[string]float64 {
+	series := make(map[string]float64)
+	switch name {
+	case models.GoalWorkouts, models.GoalDistance, models.GoalDuration, models.GoalEnergy:
+		value := func(workout models.Workout) float64 {
+			amount, _ := WorkoutField(name, "km", workout)
+			return amount
+		}
+		for _, bucket := range Aggregate(calendar, workouts, Day, workoutStart, value) {
+			series[bucket.Key] = bucket.Value
+		}
+	default:
+		for _, metric := range metrics {
+			if !strings.EqualFold(metric.Name, name) {
+				continue
+			}
+			for _, day := range DailyMetricValues(metric) {
+				series[day.Date] = day.Value
+			}
+		}
+	}
+	return series
+}
+
+// Correlate aligns the two daily series, taking y lag days after x, and
+// computes their Pearson and Spearman correlations
+func Correlate(xName, yName string, x, y map[string]float64, lag int) models.Correlation {
+	correlation := models.Correlation{X: xName, Y: yName, Lag: lag, PearsonP: 1, SpearmanP: 1}
+	days := make([]string, 0, len(x))
+	for day := range x {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+
+	var xs, ys []float64
+	for _, day := range days {
+		date, err := time.Parse(config.DateFormat, day)
+		if err != nil {
+			continue
+		}
+		if value, ok := y[date.AddDate(0, 0, lag).Format(config.DateFormat)]; ok {
+			xs = append(xs, x[day])
+			ys = append(ys, value)
+		}
+	}
+	correlation.N = len(xs)
+
+	if r, ok := PearsonCorrelation(xs, ys); ok {
+		correlation.Pearson, correlation.PearsonP = round(r), correlationPValue(r, len(xs))
+	}
+	if rho, ok := SpearmanCorrelation(xs, ys); ok {
+		correlation.Spearman, correlation.SpearmanP = round(rho), correlationPValue(rho, len(xs))
+	}
+	correlation.Significant = correlation.PearsonP < significanceLevel && correlation.SpearmanP < significanceLevel
+	return correlation
+}
+
+// CalculateCorrelationMatrix correlates every pair of the named daily series,
+// taking the column series lag days after the row series
+func CalculateCorrelationMatrix(names []string, series map[string]map[string]float64, lag int, method string) models.CorrelationMatrix {
+	matrix := models.CorrelationMatrix{
+		Series: names, Lag: lag, Method: method,
+		Matrix: make([][]float64, len(names)), Pairs: []models.Correlation{},
+	}
+	for i, row := range names {
+		matrix.Matrix[i] = make([]float64, len(names))
+		for j, column := range names {
+			correlation := Correlate(row, column, series[row], series[column], lag)
+			matrix.Matrix[i][j] = correlation.Pearson
+			if method == Spearman {
+				matrix.Matrix[i][j] = correlation.Spearman
+			}
+			// Without a lag the matrix is symmetric, so each pair is listed once
+			if i != j && (lag != 0 || i < j) {
+				matrix.Pairs = append(matrix.Pairs, correlation)
+			}
+		}
+	}
+	return matrix
+}
+
+// PearsonCorrelation returns the Pearson correlation coefficient of the paired
+// values. It is not ok with fewer than three pairs or a constant series.
+func PearsonCorrelation(xs, ys []float64) (float64, bool) {
+	if len(xs) < 3 || len(xs) != len(ys) {
+		return 0, false
+	}
+	meanX, meanY := Mean(xs), Mean(ys)
+	var sxy, sxx, syy float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return 0, false
+	}
+	return math.Max(-1, math.Min(1, sxy/math.Sqrt(sxx*syy))), true
+}
+
+// SpearmanCorrelation returns the Spearman rank correlation coefficient of the
+// paired values, ties sharing their average rank
+func SpearmanCorrelation(xs, ys []float64) (float64, bool) {
+	if len(xs) != len(ys) {
+		return 0, false
+	}
+	return PearsonCorrelation(ranks(xs), ranks(ys))
+}
+
+// ranks returns the rank of every value from 1, ties sharing their average rank
+func ranks(values []float64) []float64 {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return values[order[a]] < values[order[b]] })
+
+	result := make([]float64, len(values))
+	for start := 0; start < len(order); {
+		end := start
+		for end+1 < len(order) && values[order[end+1]] == values[order[start]] {
+			end++
+		}
+		rank := float64(start+end)/2 + 1
+		for k := start; k <= end; k++ {
+			result[order[k]] = rank
+		}
+		start = end + 1
+	}
+	return result
+}
+
+// correlationPValue tests a correlation coefficient of n pairs against zero
+func correlationPValue(r float64, n int) float64 {
+	if n < 3 {
+		return 1
+	}
+	if math.Abs(r) >= 1 {
+		return 0
+	}
+	df := float64(n - 2)
+	return TTestPValue(r*math.Sqrt(df/(1-r*r)), df)
+}
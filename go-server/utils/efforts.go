@@ -0,0 +1,167 @@
+// utils/efforts.go
+package utils
+
+import (
+	"fitness/models"
+	"math"
+	"sort"
+	"time"
+)
+
+// DistancePoint is the distance covered a number of seconds into a workout
+type DistancePoint struct {
+	Elapsed float64 // Seconds since the start of the workout
+	Meters  float64 // Distance covered so far, in meters
+}
+
+// StandardDistance is a distance best efforts are tracked for
+type StandardDistance struct {
+	Name   string
+	Meters float64
+}
+
+// StandardDistances are the distances of the best efforts, shortest first
+var StandardDistances = []StandardDistance{
+	{Name: "1k", Meters: 1000},
+	{Name: "1mi", Meters: 1609.344},
+	{Name: "5k", Meters: 5000},
+	{Name: "10k", Meters: 10000},
+	{Name: "half", Meters: 21097.5},
+}
+
+// DistanceSeries returns the cumulative distance over time of a workout, from
+// its GPS route when recorded and otherwise from its distance samples. It is
+// empty when the workout has neither.
+func DistanceSeries(workout models.Workout) []DistancePoint {
+	if series := routeSeries(workout); len(series) > 1 {
+		return series
+	}
+	return sampleSeries(workout)
+}
+
+// routeSeries sums the great-circle distance between consecutive route points
+func routeSeries(workout models.Workout) []DistancePoint {
+	start, err := ParseTime(workout.Start)
+	if err != nil {
+		return nil
+	}
+	var series []DistancePoint
+	var previous *models.RoutePoint
+	meters := 0.0
+	for i := range workout.Route {
+		point := &workout.Route[i]
+		timestamp, err := ParseTime(point.Timestamp)
+		if err != nil {
+			continue
+		}
+		if previous != nil {
+			meters += haversine(previous.Latitude, previous.Longitude, point.Latitude, point.Longitude)
+		}
+		series = append(series, DistancePoint{Elapsed: timestamp.Sub(start).Seconds(), Meters: meters})
+		previous = point
+	}
+	return monotonic(series)
+}
+
+// sampleSeries accumulates distance samples, each covering the interval up to the next sample
+func sampleSeries(workout models.Workout) []DistancePoint {
+	start, err := ParseTime(workout.Start)
+	if err != nil || len(workout.WalkingAndRunningDistance) == 0 {
+		return nil
+	}
+	type sample struct {
+		at     time.Time
+		meters float64
+	}
+	var samples []sample
+	for _, s := range workout.WalkingAndRunningDistance {
+		at, err := ParseTime(s.Date)
+		meters, ok := ToMeters(s.Qty, s.Units)
+		if err == nil && ok {
+			samples = append(samples, sample{at, meters})
+		}
+	}
+	if len(samples) == 0 {
+		return nil
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].at.Before(samples[j].at) })
+
+	// The last sample ends with the workout, or after the usual sample interval
+	end, err := ParseTime(workout.End)
+	if err != nil || !end.After(samples[len(samples)-1].at) {
+		interval := time.Minute
+		if len(samples) > 1 {
+			interval = samples[1].at.Sub(samples[0].at)
+		}
+		end = samples[len(samples)-1].at.Add(interval)
+	}
+
+	series := make([]DistancePoint, 0, len(samples)+1)
+	meters := 0.0
+	for _, s := range samples {
+		series = append(series, DistancePoint{Elapsed: s.at.Sub(start).Seconds(), Meters: meters})
+		meters += s.meters
+	}
+	series = append(series, DistancePoint{Elapsed: end.Sub(start).Seconds(), Meters: meters})
+	return monotonic(series)
+}
+
+// monotonic drops points that do not move forward in time
+func monotonic(series []DistancePoint) []DistancePoint {
+	var result []DistancePoint
+	for _, point := range series {
+		if len(result) > 0 && point.Elapsed <= result[len(result)-1].Elapsed {
+			continue
+		}
+		result = append(result, point)
+	}
+	return result
+}
+
+// BestEffort returns the fastest time, in seconds, in which the series covers
+// the distance, interpolating between points. ok is false when the series is
+// shorter than the distance.
+func BestEffort(series []DistancePoint, meters float64) (seconds float64, ok bool) {
+	if len(series) < 2 || series[len(series)-1].Meters-series[0].Meters < meters {
+		return 0, false
+	}
+	best := math.Inf(1)
+	end := 1
+	for start := range series {
+		target := series[start].Meters + meters
+		for end < len(series) && series[end].Meters < target {
+			end++
+		}
+		if end == len(series) {
+			break
+		}
+		finish := TimeAt(series, end, target)
+		if elapsed := finish - series[start].Elapsed; elapsed > 0 && elapsed < best {
+			best = elapsed
+		}
+	}
+	return best, !math.IsInf(best, 1)
+}
+
+// TimeAt interpolates the time the distance was reached between the point at
+// index and the one before it
+func TimeAt(series []DistancePoint, index int, meters float64) float64 {
+	after := series[index]
+	if index == 0 || after.Meters == series[index-1].Meters {
+		return after.Elapsed
+	}
+	before := series[index-1]
+	fraction := (meters - before.Meters) / (after.Meters - before.Meters)
+	return before.Elapsed + fraction*(after.Elapsed-before.Elapsed)
+}
+
+// haversine returns the great-circle distance between two coordinates in meters
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadius = 6371008.8
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+	dLat := toRadians(lat2 - lat1)
+	dLon := toRadians(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,216 @@
+// utils/energy.go
+package utils
+
+import (
+	"fitness/config"
+	"fitness/models"
+	"math"
+	"sort"
+	"time"
+)
+
+// Health Auto Export metrics the energy balance is computed from
+const (
+	ActiveEnergyMetric  = "active_energy"
+	BasalEnergyMetric   = "basal_energy_burned"
+	DietaryEnergyMetric = "dietary_energy"
+	WeightMetric        = "weight_body_mass"
+)
+
+// Kilocalories in a kilogram of body weight
+const kcalPerKg = 7700
+
+// Daily kcal the energy balance may miss while still agreeing with the weight
+const maxDiscrepancy = 250
+
+// Weigh-ins and days between the first and last one needed to check the balance
+const (
+	minWeighIns    = 3
+	minWeighInDays = 7
+)
+
+// CalculateEnergyBalance compares the energy eaten with the basal and active
+// energy burned every day between start and end, either bound being ignored
+// when empty. Active energy comes from the active_energy metric, which already
+// counts workouts, and from workout energy only on days without the metric.
+// Days are taken from the dates as recorded, following the device's timezone.
+func CalculateEnergyBalance(workouts []models.Workout, metrics []models.Metric, window int, start, end string) models.EnergyBalance {
+	balance := models.EnergyBalance{Window: window, Days: []models.EnergyBalanceDay{}}
+	intake := dailyEnergy(metrics, DietaryEnergyMetric)
+	basal := dailyEnergy(metrics, BasalEnergyMetric)
+	active := dailyEnergy(metrics, ActiveEnergyMetric)
+	workoutEnergy := make(map[string]float64)
+	for _, workout := range workouts {
+		kcal, ok := EnergyKcal(workout.ActiveEnergyBurned)
+		if ok && len(workout.Start) >= len(config.DateFormat) {
+			workoutEnergy[workout.Start[:len(config.DateFormat)]] += kcal
+		}
+	}
+	weights := dailyWeight(metrics)
+
+	days := make(map[string]bool)
+	for _, values := range []map[string]float64{intake, basal, active, workoutEnergy, weights} {
+		for day := range values {
+			days[day] = true
+		}
+	}
+	keys := make([]string, 0, len(days))
+	for day := range days {
+		keys = append(keys, day)
+	}
+	sort.Strings(keys)
+
+	// Balances of every day, before the range too so the rolling average is full
+	balances := make(map[string]float64)
+	for _, day := range keys {
+		kcal, hasIntake := intake[day]
+		burned, hasBasal := basal[day]
+		if hasIntake && hasBasal {
+			activeKcal, ok := active[day]
+			if !ok {
+				activeKcal = workoutEnergy[day]
+			}
+			balances[day] = kcal - burned - activeKcal
+		}
+	}
+
+	total := 0.0
+	for _, day := range keys {
+		if (start != "" && day < start) || (end != "" && day > end) {
+			continue
+		}
+		entry := models.EnergyBalanceDay{Date: day}
+		if kcal, ok := intake[day]; ok {
+			entry.Intake = roundedPointer(kcal)
+		}
+		if kcal, ok := active[day]; ok {
+			entry.Active, entry.ActiveSource = round(kcal), "metric"
+		} else if kcal, ok := workoutEnergy[day]; ok {
+			entry.Active, entry.ActiveSource = round(kcal), "workouts"
+		}
+		if kcal, ok := basal[day]; ok {
+			entry.Basal = roundedPointer(kcal)
+			entry.Expenditure = roundedPointer(kcal + entry.Active)
+		}
+		if kcal, ok := balances[day]; ok {
+			entry.Balance = roundedPointer(kcal)
+			total += kcal
+			balance.CompleteDays++
+		}
+		if average, ok := rollingBalance(balances, day, window); ok {
+			entry.Rolling = roundedPointer(average)
+		}
+		entry.Projected = round(total / kcalPerKg)
+		if kg, ok := weights[day]; ok {
+			entry.Weight = roundedPointer(kg)
+		}
+		balance.Days = append(balance.Days, entry)
+	}
+
+	if balance.CompleteDays > 0 {
+		average := total / float64(balance.CompleteDays)
+		balance.AverageBalance = round(average)
+		balance.ProjectedChange = round(total / kcalPerKg)
+		balance.WeeklyChange = round(7 * average / kcalPerKg)
+		balance.Weight = checkWeight(balance.Days, average)
+	}
+	return balance
+}
+
+// checkWeight fits a line through the weigh-ins of the days and compares its
+// change with the one the average balance projects over the same days
+func checkWeight(days []models.EnergyBalanceDay, average float64) *models.WeightCheck {
+	var first time.Time
+	var xs, ys []float64
+	var from, to string
+	for _, day := range days {
+		if day.Weight == nil {
+			continue
+		}
+		date, err := time.Parse(config.DateFormat, day.Date)
+		if err != nil {
+			continue
+		}
+		if first.IsZero() {
+			first, from = date, day.Date
+		}
+		xs = append(xs, date.Sub(first).Hours()/24)
+		ys = append(ys, *day.Weight)
+		to = day.Date
+	}
+	if len(xs) < minWeighIns || xs[len(xs)-1] < minWeighInDays {
+		return nil
+	}
+
+	meanX, meanY := Mean(xs), Mean(ys)
+	var sxx, sxy float64
+	for i := range xs {
+		sxx += (xs[i] - meanX) * (xs[i] - meanX)
+		sxy += (xs[i] - meanX) * (ys[i] - meanY)
+	}
+	span := xs[len(xs)-1]
+	actual := sxy / sxx * span
+	projected := average * span / kcalPerKg
+	discrepancy := (actual - projected) * kcalPerKg / span
+	return &models.WeightCheck{
+		From: from, To: to, WeighIns: len(xs),
+		Actual: round(actual), Projected: round(projected), Discrepancy: round(discrepancy),
+		Agrees: math.Abs(discrepancy) <= maxDiscrepancy,
+	}
+}
+
+// rollingBalance averages the balances of the days in the window ending on the day
+func rollingBalance(balances map[string]float64, day string, window int) (float64, bool) {
+	date, err := time.Parse(config.DateFormat, day)
+	if err != nil {
+		return 0, false
+	}
+	var values []float64
+	for i := 0; i < window; i++ {
+		if kcal, ok := balances[date.AddDate(0, 0, -i).Format(config.DateFormat)]; ok {
+			values = append(values, kcal)
+		}
+	}
+	return Mean(values), len(values) > 0
+}
+
+// dailyEnergy sums the data points of an energy metric per day in kcal, taking
+// the day from the date as recorded
+func dailyEnergy(metrics []models.Metric, name string) map[string]float64 {
+	days := make(map[string]float64)
+	for _, metric := range metrics {
+		if metric.Name != name {
+			continue
+		}
+		for _, point := range metric.Data {
+			kcal, ok := EnergyKcal(&models.Measurement{Units: metric.Units, Qty: point.Qty})
+			if ok && len(point.Date) >= len(config.DateFormat) {
+				days[point.Date[:len(config.DateFormat)]] += kcal
+			}
+		}
+	}
+	return days
+}
+
+// dailyWeight averages the weight metric per day in kilograms
+func dailyWeight(metrics []models.Metric) map[string]float64 {
+	days := make(map[string]float64)
+	for _, metric := range metrics {
+		if metric.Name != WeightMetric {
+			continue
+		}
+		if _, ok := MassKg(1, metric.Units); !ok {
+			continue
+		}
+		for _, day := range DailyMetricValues(metric) {
+			days[day.Date], _ = MassKg(day.Value, metric.Units)
+		}
+	}
+	return days
+}
+
+// roundedPointer rounds the value and returns a pointer to it
+func roundedPointer(value float64) *float64 {
+	rounded := round(value)
+	return &rounded
+}
@@ -1,17 +1,14 @@
 package utils
 
 import (
-	"fitness/config"
 	"fitness/models"
-	"time"
 )
 
-func CalculateWorkoutsPerMonth(workouts []models.Workout) map[string]int {
+func CalculateWorkoutsPerMonth(workouts []models.Workout, calendar Calendar) map[string]int {
 	workoutsPerMonth := make(map[string]int)
-	for _, workout := range workouts {
-		if startTime, err := time.Parse(config.TimeFormat, workout.Start); err == nil {
-			workoutsPerMonth[startTime.Format("2006-01")]++
-		}
+	buckets := Aggregate(calendar, workouts, Month, workoutStart, func(models.Workout) float64 { return 1 })
+	for _, bucket := range buckets {
+		workoutsPerMonth[bucket.Key] = int(bucket.Value)
 	}
 
 	return workoutsPerMonth
@@ -27,8 +24,8 @@ func CalculateDistancePerWorkout(workouts []models.Workout) map[string]float64 {
 	return distancePerWorkout
 }
 
-func CalculateDistancePerWeek(workouts []models.Workout) map[string]float64 {
-	return aggregateByWeek(workouts, func(w models.Workout) float64 {
+func CalculateDistancePerWeek(workouts []models.Workout, calendar Calendar) map[string]float64 {
+	return aggregateByWeek(workouts, calendar, func(w models.Workout) float64 {
 		if w.Distance != nil {
 			return w.Distance.Qty
 		}
@@ -36,8 +33,8 @@ func CalculateDistancePerWeek(workouts []models.Workout) map[string]float64 {
 	})
 }
 
-func CalculateEnergyPerWeek(workouts []models.Workout) map[string]float64 {
-	return aggregateByWeek(workouts, func(w models.Workout) float64 {
+func CalculateEnergyPerWeek(workouts []models.Workout, calendar Calendar) map[string]float64 {
+	return aggregateByWeek(workouts, calendar, func(w models.Workout) float64 {
 		if w.ActiveEnergyBurned != nil {
 			return w.ActiveEnergyBurned.Qty
 		}
@@ -45,14 +42,12 @@ func CalculateEnergyPerWeek(workouts []models.Workout) map[string]float64 {
 	})
 }
 
-func aggregateByWeek(workouts []models.Workout, getValue func(models.Workout) float64) map[string]float64 {
-	result := make(map[string]float64)
-	for _, workout := range workouts {
-		if startTime, err := time.Parse(config.TimeFormat, workout.Start); err == nil {
-			weekStart := startTime.AddDate(0, 0, -int(startTime.Weekday()-time.Monday))
-			weekOf := weekStart.Format(config.DateFormat)
-			result[weekOf] += getValue(workout)
-		}
-	}
-	return result
+// aggregateByWeek sums getValue per calendar week, keyed by the first day of the week
+func aggregateByWeek(workouts []models.Workout, calendar Calendar, getValue func(models.Workout) float64) map[string]float64 {
+	return BucketMap(Aggregate(calendar, workouts, Week, workoutStart, getValue))
+}
+
+// workoutStart returns the start timestamp of a workout
+func workoutStart(workout models.Workout) string {
+	return workout.Start
 }
@@ -0,0 +1,179 @@
+// utils/goals.go
+package utils
+
+import (
+	"errors"
+	"fitness/models"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// goalEntry is an amount counted towards a goal at a point in time
+type goalEntry struct {
+	at    time.Time
+	value float64
+}
+
+// ValidateGoal checks that a goal totals exactly one workout field or health
+// metric over a known period, normalizing its period and units
+func ValidateGoal(goal *models.Goal) error {
+	period, err := ParsePeriod(goal.Period)
+	if err != nil {
+		return errors.New("period must be day, week, isoweek, month or year")
+	}
+	goal.Period = string(period)
+	switch {
+	case goal.Field != "" && goal.Metric != "":
+		return errors.New("a goal totals either a workout field or a metric")
+	case goal.Field == "" && goal.Metric == "":
+		return errors.New("field or metric is required")
+	}
+	switch goal.Field {
+	case "", models.GoalWorkouts, models.GoalDuration, models.GoalEnergy:
+	case models.GoalDistance:
+		if goal.Units == "" {
+			goal.Units = "km"
+		}
+		if _, ok := ToMeters(1, goal.Units); !ok {
+			return errors.New("units must be km, mi or m")
+		}
+	default:
+		return errors.New("field must be workouts, distance, duration or energy")
+	}
+	if goal.Target <= 0 || math.IsInf(goal.Target, 0) {
+		return errors.New("target must be positive")
+	}
+	return nil
+}
+
+// CalculateGoalProgress totals the goal in every period from the one it was
+// created in, or the first data it counts when unknown, up to now. It reports the current period with a
+// projection at the current pace, and up to history completed periods.
+// Workouts must already be filtered by the goal's workout names.
+func CalculateGoalProgress(goal models.Goal, workouts []models.Workout, metrics []models.Metric, calendar Calendar, now time.Time, history int) models.GoalProgress {
+	period := Period(goal.Period)
+	entries := goalEntries(goal, workouts, metrics)
+
+	// Periods before the goal existed are neither met nor missed, goals
+	// without a creation time count from the first data
+	from := now
+	switch {
+	case goal.CreatedAt != nil:
+		if goal.CreatedAt.Before(now) {
+			from = *goal.CreatedAt
+		}
+	case len(entries) > 0 && entries[0].at.Before(now):
+		from = entries[0].at
+	}
+
+	// Total every period, noting the day the target was reached
+	starts := calendar.Range(from, now, period)
+	periods := make([]models.GoalPeriod, len(starts))
+	index := make(map[string]int, len(starts))
+	for i, start := range starts {
+		key := calendar.Key(start, period)
+		index[key] = i
+		periods[i] = models.GoalPeriod{
+			Period: key,
+			Start:  calendar.Key(start, Day),
+			End:    calendar.Key(calendar.Next(start, period).Add(-time.Nanosecond), Day),
+		}
+	}
+	for _, entry := range entries {
+		i, ok := index[calendar.Key(entry.at, period)]
+		if !ok || entry.at.After(now) {
+			continue
+		}
+		periods[i].Value += entry.value
+		if !periods[i].Met && periods[i].Value >= goal.Target {
+			periods[i].Met = true
+			periods[i].MetOn = calendar.Key(entry.at, Day)
+		}
+	}
+	for i := range periods {
+		periods[i].Percent = round(periods[i].Value / goal.Target * 100)
+		periods[i].Value = round(periods[i].Value)
+	}
+
+	progress := models.GoalProgress{Goal: goal, Current: periods[len(periods)-1], History: []models.GoalPeriod{}}
+	for i := len(periods) - 2; i >= 0; i-- {
+		if periods[i].Met {
+			progress.Met++
+		} else {
+			progress.Missed++
+		}
+		if len(progress.History) < history {
+			progress.History = append(progress.History, periods[i])
+		}
+	}
+
+	// Project the current pace to the end of the period
+	current := progress.Current
+	start := starts[len(starts)-1]
+	end := calendar.Next(start, period)
+	elapsed := now.Sub(start).Seconds()
+	progress.Projected = current.Value
+	if elapsed > 0 {
+		progress.Projected = round(current.Value / elapsed * end.Sub(start).Seconds())
+	}
+	switch {
+	case current.Met:
+		progress.ProjectedCompletion = current.MetOn
+	case current.Value > 0 && elapsed > 0:
+		remaining := (goal.Target - current.Value) / (current.Value / elapsed)
+		if completion := now.Add(time.Duration(remaining * float64(time.Second))); completion.Before(end) {
+			progress.ProjectedCompletion = calendar.Key(completion, Day)
+		}
+	}
+	progress.OnTrack = current.Met || progress.Projected >= goal.Target
+	return progress
+}
+
+// goalEntries lists the amounts the goal counts, oldest first
+func goalEntries(goal models.Goal, workouts []models.Workout, metrics []models.Metric) []goalEntry {
+	var entries []goalEntry
+	if goal.Metric != "" {
+		for _, metric := range metrics {
+			if !strings.EqualFold(metric.Name, goal.Metric) {
+				continue
+			}
+			for _, point := range metric.Data {
+				if at, err := ParseTime(point.Date); err == nil {
+					entries = append(entries, goalEntry{at, point.Qty})
+				}
+			}
+		}
+	} else {
+		for _, workout := range workouts {
+			at, err := ParseTime(workout.Start)
+			if err != nil {
+				continue
+			}
+			if value, ok := WorkoutField(goal.Field, goal.Units, workout); ok {
+				entries = append(entries, goalEntry{at, value})
+			}
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].at.Before(entries[j].at) })
+	return entries
+}
+
+// WorkoutField returns the amount a workout adds to a total of one of its
+// fields, with distances in the units
+func WorkoutField(field, units string, workout models.Workout) (float64, bool) {
+	switch field {
+	case models.GoalWorkouts:
+		return 1, true
+	case models.GoalDuration:
+		return workout.Duration / 60, true
+	case models.GoalEnergy:
+		return EnergyKcal(workout.ActiveEnergyBurned)
+	case models.GoalDistance:
+		km, ok := DistanceKm(workout.Distance)
+		unit, known := ToMeters(1, units)
+		return km * 1000 / unit, ok && known
+	}
+	return 0, false
+}
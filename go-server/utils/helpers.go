@@ -14,9 +14,16 @@ func Truncate(s string, n int) string {
 	return s[:n-3] + "..."
 }
 
-// Format time in seconds to a human-readable format
+// Format time in seconds to a human-readable format, mm:ss or h:mm:ss from an hour
 func FormatTime(seconds float64) string {
-	minutes := math.Floor(seconds / 60)
-	remainingSeconds := math.Round(math.Mod(seconds, 60))
-	return fmt.Sprintf("%02d:%02d", int(minutes), int(remainingSeconds))
+	total := int(math.Round(seconds))
+	if total >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", total/3600, total%3600/60, total%60)
+	}
+	return fmt.Sprintf("%02d:%02d", total/60, total%60)
+}
+
+// Round a value to two decimals
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
 }
@@ -0,0 +1,60 @@
+// utils/logger.go
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// NewLogger creates a logger writing "json" or "text" records at or above the level,
+// including the attributes stored in the context of each record
+func NewLogger(w io.Writer, format, level string) (*slog.Logger, error) {
+	var minimum slog.Level
+	if err := minimum.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %v", level, err)
+	}
+	options := &slog.HandlerOptions{Level: minimum}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "json":
+		handler = slog.NewJSONHandler(w, options)
+	case "text", "":
+		handler = slog.NewTextHandler(w, options)
+	default:
+		return nil, fmt.Errorf("invalid log format %q: expected json or text", format)
+	}
+	return slog.New(contextHandler{handler}), nil
+}
+
+type logAttrsKey struct{}
+
+// WithLogAttrs returns a context whose log records carry the attributes, such as a request ID
+func WithLogAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(logAttrsKey{}).([]slog.Attr)
+	combined := append(append([]slog.Attr(nil), existing...), attrs...)
+	return context.WithValue(ctx, logAttrsKey{}, combined)
+}
+
+// contextHandler adds the attributes of WithLogAttrs to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs, ok := ctx.Value(logAttrsKey{}).([]slog.Attr); ok {
+		record.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,133 @@
+// utils/pace.go
+package utils
+
+import (
+	"fitness/models"
+	"fmt"
+	"math"
+)
+
+// Unit systems pace, speed and splits are reported in
+const (
+	Metric   = "metric"
+	Imperial = "imperial"
+)
+
+// Shortest remainder, in meters, reported as a final partial split
+const minSplitMeters = 10
+
+// ParseUnitSystem validates the name of a unit system, metric when empty
+func ParseUnitSystem(name string) (string, error) {
+	switch name {
+	case "":
+		return Metric, nil
+	case Metric, Imperial:
+		return name, nil
+	}
+	return "", fmt.Errorf("unknown unit system %q", name)
+}
+
+// splitMeters returns the meters in a kilometer or mile of the unit system
+func splitMeters(system string) float64 {
+	if system == Imperial {
+		return metersPer["mi"]
+	}
+	return metersPer["km"]
+}
+
+// WorkoutPace returns the average pace and speed of a workout in the unit
+// system. It is nil without a distance or duration.
+func WorkoutPace(workout models.Workout, system string) *models.Pace {
+	km, ok := DistanceKm(workout.Distance)
+	if !ok || km <= 0 || workout.Duration <= 0 {
+		return nil
+	}
+	distance := km * 1000 / splitMeters(system)
+	pace := newPace(system, distance, workout.Duration)
+	if first, second, ok := halves(DistanceSeries(workout)); ok {
+		negative := second < first
+		pace.NegativeSplit = &negative
+	}
+	return pace
+}
+
+// CalculateSplits divides a workout into kilometers or miles, following its
+// route or distance samples, and compares the time taken for its two halves
+func CalculateSplits(workout models.Workout, system string) models.WorkoutSplits {
+	splits := models.WorkoutSplits{
+		WorkoutID: workout.ID, Units: system,
+		Pace: WorkoutPace(workout, system), Splits: []models.Split{},
+	}
+	series := DistanceSeries(workout)
+	if len(series) < 2 {
+		return splits
+	}
+
+	unit := splitMeters(system)
+	total := series[len(series)-1].Meters
+	previous := series[0].Elapsed
+	for number := 1; ; number++ {
+		meters := math.Min(float64(number)*unit, total)
+		if meters-float64(number-1)*unit < minSplitMeters {
+			break
+		}
+		elapsed := elapsedAt(series, meters)
+		split := newSplit(number, (meters-float64(number-1)*unit)/unit, elapsed-previous)
+		split.Elapsed = round(elapsed - series[0].Elapsed)
+		splits.Splits = append(splits.Splits, split)
+		previous = elapsed
+		if meters == total {
+			break
+		}
+	}
+
+	if first, second, ok := halves(series); ok {
+		splits.FirstHalf, splits.SecondHalf = round(first), round(second)
+		splits.NegativeSplit = second < first
+	}
+	return splits
+}
+
+// newPace derives pace and speed from a distance in kilometers or miles
+func newPace(system string, distance, seconds float64) *models.Pace {
+	return &models.Pace{
+		Units:    system,
+		Distance: round(distance),
+		Pace:     round(seconds / distance),
+		PaceText: FormatTime(seconds / distance),
+		Speed:    round(distance / seconds * 3600),
+	}
+}
+
+// newSplit derives the pace and speed of a split of the given length
+func newSplit(number int, distance, seconds float64) models.Split {
+	pace := newPace("", distance, seconds)
+	return models.Split{
+		Split: number, Distance: pace.Distance, Duration: round(seconds),
+		Pace: pace.Pace, PaceText: pace.PaceText, Speed: pace.Speed,
+	}
+}
+
+// halves returns the seconds taken for the first and second half of the
+// distance in the series. ok is false when the series covers no distance.
+func halves(series []DistancePoint) (first, second float64, ok bool) {
+	if len(series) < 2 {
+		return 0, 0, false
+	}
+	start, finish := series[0], series[len(series)-1]
+	if finish.Meters <= start.Meters {
+		return 0, 0, false
+	}
+	middle := elapsedAt(series, (start.Meters+finish.Meters)/2)
+	return middle - start.Elapsed, finish.Elapsed - middle, true
+}
+
+// elapsedAt interpolates the time the series first reached the distance
+func elapsedAt(series []DistancePoint, meters float64) float64 {
+	for i, point := range series {
+		if point.Meters >= meters {
+			return TimeAt(series, i, meters)
+		}
+	}
+	return series[len(series)-1].Elapsed
+}
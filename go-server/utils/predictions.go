@@ -0,0 +1,245 @@
+// utils/predictions.go
+package utils
+
+import (
+	"fitness/config"
+	"fitness/models"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Sources of the VO2max used for VDOT predictions
+const (
+	VO2MaxMetric    = "metric"     // Latest value of the VO2 Max metric
+	VO2MaxHeartRate = "heart rate" // Estimated from the pace and heart rate of runs
+	VO2MaxEffort    = "effort"     // VDOT of the best effort
+)
+
+// RaceDistances are the distances race times are predicted for, shortest first
+var RaceDistances = []StandardDistance{
+	{Name: "1mi", Meters: 1609.344},
+	{Name: "5k", Meters: 5000},
+	{Name: "10k", Meters: 10000},
+	{Name: "half", Meters: 21097.5},
+	{Name: "marathon", Meters: 42195},
+}
+
+// Riegel's fatigue exponent
+const riegelExponent = 1.06
+
+// Shortest effort, in meters, race times are predicted from
+const minEffortMeters = 1500
+
+// Efforts reported with the predictions at most
+const maxReportedEfforts = 10
+
+// Fraction of the heart rate reserve a run needs to estimate the VO2max from
+const minReserveFraction = 0.6
+
+// CalculateRacePredictions predicts race times from the best running effort
+// since the day, with Riegel's and Cameron's formulas, and from the VO2max of
+// the VO2 Max metric, the pace and heart rate of runs or the best effort
+func CalculateRacePredictions(workouts []models.Workout, metrics []models.Metric, heartRate HeartRateProfile, since time.Time) models.RacePredictions {
+	predictions := models.RacePredictions{
+		Since: since.Format(config.DateFormat), Efforts: []models.RaceEffort{},
+		Predictions: []models.RacePrediction{}, Notes: []string{},
+	}
+	var runs []models.Workout
+	for _, workout := range workouts {
+		start, err := ParseTime(workout.Start)
+		if err == nil && !start.Before(since) && strings.Contains(strings.ToLower(workout.Name), "run") {
+			runs = append(runs, workout)
+		}
+	}
+
+	efforts := RunningEfforts(runs)
+	var best *models.RaceEffort
+	if len(efforts) > 0 {
+		efforts[0].Used = true
+		best = &efforts[0]
+		predictions.Notes = append(predictions.Notes, fmt.Sprintf(
+			"Riegel and Cameron predictions start from the %s effort of %s in the %s workout %s on %s, the highest VDOT since %s",
+			best.Distance, best.Time, best.Name, best.WorkoutID, best.Date[:min(len(best.Date), len(config.DateFormat))], predictions.Since))
+	} else {
+		predictions.Notes = append(predictions.Notes, fmt.Sprintf("No running efforts of at least %d m since %s", minEffortMeters, predictions.Since))
+	}
+	predictions.Efforts = efforts[:min(len(efforts), maxReportedEfforts)]
+
+	predictions.VO2Max = EstimateVO2Max(runs, metrics, heartRate, best)
+	if vo2Max := predictions.VO2Max; vo2Max != nil {
+		switch vo2Max.Source {
+		case VO2MaxMetric:
+			predictions.Notes = append(predictions.Notes, fmt.Sprintf("VDOT predictions use the VO2 Max metric of %g on %s", vo2Max.Value, vo2Max.Date))
+		case VO2MaxHeartRate:
+			predictions.Notes = append(predictions.Notes, fmt.Sprintf(
+				"VDOT predictions use a VO2max of %g estimated from the pace and heart rate of %d runs: %s",
+				vo2Max.Value, len(vo2Max.Workouts), strings.Join(vo2Max.Workouts, ", ")))
+		case VO2MaxEffort:
+			predictions.Notes = append(predictions.Notes, fmt.Sprintf("VDOT predictions use the VDOT of %g of the best effort, without a VO2 Max metric or runs with heart rate", vo2Max.Value))
+		}
+	}
+
+	for _, distance := range RaceDistances {
+		prediction := models.RacePrediction{Distance: distance.Name, Meters: distance.Meters}
+		if best != nil {
+			riegel := round(RiegelTime(best.Seconds, best.Meters, distance.Meters))
+			cameron := round(CameronTime(best.Seconds, best.Meters, distance.Meters))
+			prediction.Riegel, prediction.RiegelTime = &riegel, FormatTime(riegel)
+			prediction.Cameron, prediction.CameronTime = &cameron, FormatTime(cameron)
+		}
+		if predictions.VO2Max != nil {
+			if seconds, ok := VDOTTime(predictions.VO2Max.Value, distance.Meters); ok {
+				seconds = round(seconds)
+				prediction.VDOT, prediction.VDOTTime = &seconds, FormatTime(seconds)
+			}
+		}
+		if prediction.Riegel != nil || prediction.VDOT != nil {
+			predictions.Predictions = append(predictions.Predictions, prediction)
+		}
+	}
+	return predictions
+}
+
+// RunningEfforts lists the best efforts over the standard distances and the
+// whole distance of the runs, highest VDOT first
+func RunningEfforts(runs []models.Workout) []models.RaceEffort {
+	var efforts []models.RaceEffort
+	add := func(workout models.Workout, distance string, meters, seconds float64) {
+		if meters < minEffortMeters || seconds <= 0 {
+			return
+		}
+		efforts = append(efforts, models.RaceEffort{
+			WorkoutID: workout.ID, Name: workout.Name, Date: workout.Start, Distance: distance,
+			Meters: round(meters), Seconds: round(seconds), Time: FormatTime(seconds), VDOT: round(VDOT(meters, seconds)),
+		})
+	}
+	for _, workout := range runs {
+		series := DistanceSeries(workout)
+		for _, standard := range StandardDistances {
+			seconds, ok := BestEffort(series, standard.Meters)
+			if !ok {
+				break
+			}
+			add(workout, standard.Name, standard.Meters, seconds)
+		}
+		if km, ok := DistanceKm(workout.Distance); ok {
+			add(workout, "workout", km*1000, workout.Duration)
+		}
+	}
+	sort.SliceStable(efforts, func(i, j int) bool { return efforts[i].VDOT > efforts[j].VDOT })
+	return efforts
+}
+
+// EstimateVO2Max returns the latest value of the VO2 Max metric, otherwise the
+// median estimate from the pace and heart rate reserve of runs, otherwise the
+// VDOT of the best effort. It is nil without any of them.
+func EstimateVO2Max(runs []models.Workout, metrics []models.Metric, heartRate HeartRateProfile, best *models.RaceEffort) *models.VO2Max {
+	var latest *models.VO2Max
+	var latestTime time.Time
+	for _, metric := range metrics {
+		if strings.ReplaceAll(strings.ToLower(metric.Name), " ", "_") != "vo2_max" {
+			continue
+		}
+		for _, point := range metric.Data {
+			date, err := ParseTime(point.Date)
+			if err == nil && point.Qty > 0 && !date.Before(latestTime) {
+				latestTime = date
+				latest = &models.VO2Max{Value: round(point.Qty), Source: VO2MaxMetric, Date: date.Format(config.DateFormat)}
+			}
+		}
+	}
+	if latest != nil {
+		return latest
+	}
+
+	// The heart rate reserve used tracks the share of the VO2 reserve used (Swain)
+	var estimates []float64
+	var used []string
+	for _, run := range runs {
+		average, ok := averageHeartRate(run)
+		km, hasDistance := DistanceKm(run.Distance)
+		minutes := run.Duration / 60
+		if !ok || !hasDistance || minutes < 10 || heartRate.Max <= heartRate.Resting {
+			continue
+		}
+		fraction := (average - heartRate.Resting) / (heartRate.Max - heartRate.Resting)
+		if fraction < minReserveFraction || fraction > 1 {
+			continue
+		}
+		cost := oxygenCost(km * 1000 / minutes)
+		estimates = append(estimates, (cost-3.5)/fraction+3.5)
+		used = append(used, run.ID)
+	}
+	if len(estimates) > 0 {
+		return &models.VO2Max{Value: round(Median(estimates)), Source: VO2MaxHeartRate, Workouts: used}
+	}
+
+	if best != nil {
+		return &models.VO2Max{Value: best.VDOT, Source: VO2MaxEffort, Workouts: []string{best.WorkoutID}}
+	}
+	return nil
+}
+
+// averageHeartRate returns the average heart rate of a workout from its summary,
+// otherwise from its samples
+func averageHeartRate(workout models.Workout) (float64, bool) {
+	if workout.HeartRate != nil && workout.HeartRate.Avg != nil && workout.HeartRate.Avg.Qty > 0 {
+		return workout.HeartRate.Avg.Qty, true
+	}
+	var values []float64
+	for _, sample := range workout.HeartRateData {
+		if sample.Avg > 0 {
+			values = append(values, sample.Avg)
+		}
+	}
+	return Mean(values), len(values) > 0
+}
+
+// RiegelTime predicts the time of a distance from a performance
+func RiegelTime(seconds, meters, distance float64) float64 {
+	return seconds * math.Pow(distance/meters, riegelExponent)
+}
+
+// CameronTime predicts the time of a distance from a performance with Dave
+// Cameron's formula, fitted to world class performances from 400 m up
+func CameronTime(seconds, meters, distance float64) float64 {
+	factor := func(d float64) float64 {
+		return 13.49681 - 0.000030363*d + 835.7114/math.Pow(d, 0.7905)
+	}
+	return seconds / meters * factor(meters) / factor(distance) * distance
+}
+
+// VDOT returns Jack Daniels' VDOT of running the distance in the time
+func VDOT(meters, seconds float64) float64 {
+	minutes := seconds / 60
+	fraction := 0.8 + 0.1894393*math.Exp(-0.012778*minutes) + 0.2989558*math.Exp(-0.1932605*minutes)
+	return oxygenCost(meters/minutes) / fraction
+}
+
+// VDOTTime returns the time in which a runner with the VDOT covers the distance.
+// It is not ok when the time falls outside of a minute to a day.
+func VDOTTime(vdot, meters float64) (float64, bool) {
+	// VDOT falls as the time grows, so the time is found by bisection
+	low, high := 60.0, 86400.0
+	if VDOT(meters, low) < vdot || VDOT(meters, high) > vdot {
+		return 0, false
+	}
+	for i := 0; i < 60; i++ {
+		middle := (low + high) / 2
+		if VDOT(meters, middle) > vdot {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+	return (low + high) / 2, true
+}
+
+// oxygenCost returns the oxygen uptake, in ml/kg/min, of running at the speed
+// in meters per minute
+func oxygenCost(speed float64) float64 {
+	return -4.6 + 0.182258*speed + 0.000104*speed*speed
+}
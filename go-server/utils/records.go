@@ -0,0 +1,127 @@
+// utils/records.go
+package utils
+
+import (
+	"fitness/models"
+	"sort"
+)
+
+// recordCandidate is a value a workout sets for one record category
+type recordCandidate struct {
+	category string
+	distance string
+	value    float64
+	units    string
+	lower    bool // Whether lower values are better, as for paces and times
+}
+
+// CalculateRecords walks the workouts in chronological order and returns the
+// personal records of every workout type, with the history of records set
+func CalculateRecords(workouts []models.Workout) models.Records {
+	ordered := append([]models.Workout(nil), workouts...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, errA := ParseTime(ordered[i].Start)
+		b, errB := ParseTime(ordered[j].Start)
+		if errA != nil || errB != nil {
+			return ordered[i].Start < ordered[j].Start
+		}
+		return a.Before(b)
+	})
+
+	history := []models.PersonalRecord{}
+	standing := make(map[string]int) // Index in history of the standing record per type and category
+	var keys []string
+	for _, workout := range ordered {
+		for _, candidate := range recordCandidates(workout) {
+			key := workout.Name + "\x00" + candidate.category + "\x00" + candidate.distance
+			record := models.PersonalRecord{
+				WorkoutType: workout.Name,
+				Category:    candidate.category,
+				Distance:    candidate.distance,
+				Value:       candidate.value,
+				Units:       candidate.units,
+				WorkoutID:   workout.ID,
+				Date:        workout.Start,
+			}
+			if i, ok := standing[key]; ok {
+				previous := history[i].Value
+				better := candidate.value > previous
+				if candidate.lower {
+					better = candidate.value < previous
+				}
+				if !better {
+					continue
+				}
+				record.Previous = &previous
+			} else {
+				keys = append(keys, key)
+			}
+			standing[key] = len(history)
+			history = append(history, record)
+		}
+	}
+
+	current := []models.PersonalRecord{}
+	for _, key := range keys {
+		history[standing[key]].Current = true
+		current = append(current, history[standing[key]])
+	}
+	sort.SliceStable(current, func(i, j int) bool {
+		return current[i].WorkoutType < current[j].WorkoutType
+	})
+	return models.Records{Current: current, History: history}
+}
+
+// Breakthroughs returns the records of a history that beat an earlier one. The
+// first record of every type, category and distance only sets a baseline.
+func Breakthroughs(history []models.PersonalRecord) []models.PersonalRecord {
+	breakthroughs := []models.PersonalRecord{}
+	for _, record := range history {
+		// Only the records after the baseline of their key replace a previous value
+		if record.Previous != nil {
+			breakthroughs = append(breakthroughs, record)
+		}
+	}
+	return breakthroughs
+}
+
+// WorkoutAchievements returns the records set by a workout
+func WorkoutAchievements(records models.Records, workoutID string) []models.PersonalRecord {
+	achievements := []models.PersonalRecord{}
+	for _, record := range records.History {
+		if record.WorkoutID == workoutID {
+			achievements = append(achievements, record)
+		}
+	}
+	return achievements
+}
+
+// recordCandidates lists the values a workout could set a record with
+func recordCandidates(workout models.Workout) []recordCandidate {
+	var candidates []recordCandidate
+	km, hasDistance := DistanceKm(workout.Distance)
+	if hasDistance && km > 0 {
+		candidates = append(candidates, recordCandidate{category: models.RecordLongestDistance, value: km, units: "km"})
+	}
+	if workout.Duration > 0 {
+		candidates = append(candidates, recordCandidate{category: models.RecordLongestDuration, value: workout.Duration, units: "s"})
+	}
+	if kcal, ok := EnergyKcal(workout.ActiveEnergyBurned); ok && kcal > 0 {
+		candidates = append(candidates, recordCandidate{category: models.RecordMostEnergy, value: kcal, units: "kcal"})
+	}
+	if hasDistance && km > 0 && workout.Duration > 0 {
+		candidates = append(candidates, recordCandidate{category: models.RecordFastestPace, value: workout.Duration / km, units: "s/km", lower: true})
+	}
+
+	series := DistanceSeries(workout)
+	for _, standard := range StandardDistances {
+		seconds, ok := BestEffort(series, standard.Meters)
+		if !ok {
+			break
+		}
+		candidates = append(candidates, recordCandidate{
+			category: models.RecordBestEffort, distance: standard.Name, value: seconds, units: "s", lower: true,
+		})
+	}
+	return candidates
+}
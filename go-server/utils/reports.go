@@ -0,0 +1,217 @@
+// utils/reports.go
+package utils
+
+import (
+	"fitness/config"
+	"fitness/models"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Workouts listed as the top workouts of a report
+const reportTopWorkouts = 5
+
+// Metrics listed as notable changes of a report at most
+const reportMetrics = 8
+
+// Percent change of a metric's daily average that makes it notable
+const notableMetricChange = 10
+
+// ParseReportPeriod parses the period of a report, either week, month or year
+func ParseReportPeriod(name string) (Period, error) {
+	switch period := Period(name); period {
+	case Week, Month, Year:
+		return period, nil
+	}
+	return "", fmt.Errorf("invalid report period %q", name)
+}
+
+// CalculateReport summarizes the period containing date as of now. Totals are
+// compared with the previous period, cut to the same length while the period
+// is in progress so a partial week is not compared with a whole one.
+func CalculateReport(workouts []models.Workout, metrics []models.Metric, calendar Calendar, period Period, date, now time.Time) models.Report {
+	start := calendar.StartOf(date, period)
+	next := calendar.Next(start, period)
+	previousStart := calendar.StartOf(start.AddDate(0, 0, -1), period)
+	previousEnd := start
+	report := models.Report{
+		Period: string(period), Key: calendar.Key(start, period),
+		Start: start.Format(config.DateFormat), End: next.AddDate(0, 0, -1).Format(config.DateFormat),
+		Previous: calendar.Key(previousStart, period), Partial: now.Before(next),
+		Changes: []models.ReportChange{}, Records: []models.PersonalRecord{},
+		TopWorkouts: []models.ReportWorkout{}, Metrics: []models.ReportMetric{},
+	}
+	if report.Partial {
+		previousEnd = minTime(previousStart.Add(now.Sub(start)), start)
+	}
+
+	var current, previous, history []models.Workout
+	for _, workout := range workouts {
+		started, err := ParseTime(workout.Start)
+		if err != nil || !started.Before(next) {
+			continue
+		}
+		history = append(history, workout)
+		if !started.Before(start) {
+			current = append(current, workout)
+		} else if !started.Before(previousStart) && started.Before(previousEnd) {
+			previous = append(previous, workout)
+		}
+	}
+
+	report.Totals = reportTotals(current, calendar)
+	previousTotals := reportTotals(previous, calendar)
+	for _, change := range []struct {
+		field             string
+		current, previous float64
+	}{
+		{"workouts", float64(report.Totals.Workouts), float64(previousTotals.Workouts)},
+		{"activeDays", float64(report.Totals.ActiveDays), float64(previousTotals.ActiveDays)},
+		{"duration", report.Totals.Duration, previousTotals.Duration},
+		{"distance", report.Totals.Distance, previousTotals.Distance},
+		{"energy", report.Totals.Energy, previousTotals.Energy},
+	} {
+		entry := models.ReportChange{
+			Field: change.field, Current: change.current, Previous: change.previous,
+			Delta: round(change.current - change.previous),
+		}
+		if change.previous != 0 {
+			entry.Percent = roundedPointer(100 * (change.current - change.previous) / change.previous)
+		}
+		report.Changes = append(report.Changes, entry)
+	}
+
+	// Yearly reports are bucketed per week, shorter periods per day
+	bucket := Day
+	if period == Year {
+		bucket = Week
+	}
+	buckets := make(map[string]int)
+	for _, bucketStart := range calendar.Range(start, next.Add(-time.Nanosecond), bucket) {
+		key := calendar.Key(bucketStart, bucket)
+		buckets[key] = len(report.Buckets)
+		report.Buckets = append(report.Buckets, models.ReportBucket{Key: key})
+	}
+	for _, workout := range current {
+		started, _ := ParseTime(workout.Start)
+		if i, ok := buckets[calendar.Key(started, bucket)]; ok {
+			entry := &report.Buckets[i]
+			entry.Workouts++
+			entry.Duration = round(entry.Duration + workout.Duration)
+			entry.Distance = round(entry.Distance + workoutKm(workout))
+			entry.Energy = round(entry.Energy + workoutKcal(workout))
+		}
+	}
+
+	// Records are replayed up to the end of the period so later ones do not hide them
+	for _, record := range Breakthroughs(CalculateRecords(history).History) {
+		if started, err := ParseTime(record.Date); err == nil && !started.Before(start) {
+			record.Current = false
+			report.Records = append(report.Records, record)
+		}
+	}
+
+	streaks := CalculateStreaks(history, calendar, StreakRules{}, minTime(now, next.Add(-time.Nanosecond)))
+	report.Streaks, report.WeekStreaks = streaks.Daily, streaks.Weekly
+
+	top := append([]models.Workout(nil), current...)
+	sort.SliceStable(top, func(i, j int) bool {
+		if a, b := workoutKcal(top[i]), workoutKcal(top[j]); a != b {
+			return a > b
+		}
+		return top[i].Duration > top[j].Duration
+	})
+	for _, workout := range top[:min(len(top), reportTopWorkouts)] {
+		report.TopWorkouts = append(report.TopWorkouts, newReportWorkout(workout))
+	}
+
+	report.Metrics = notableMetrics(metrics, report.Start, next.Format(config.DateFormat), previousStart.Format(config.DateFormat))
+	return report
+}
+
+// reportTotals totals the workouts of a period
+func reportTotals(workouts []models.Workout, calendar Calendar) models.ReportTotals {
+	var totals models.ReportTotals
+	days := make(map[string]bool)
+	for _, workout := range workouts {
+		if started, err := ParseTime(workout.Start); err == nil {
+			days[calendar.Key(started, Day)] = true
+		}
+		totals.Workouts++
+		totals.Duration += workout.Duration
+		totals.Distance += workoutKm(workout)
+		totals.Energy += workoutKcal(workout)
+	}
+	totals.ActiveDays = len(days)
+	totals.Duration, totals.Distance, totals.Energy = round(totals.Duration), round(totals.Distance), round(totals.Energy)
+	return totals
+}
+
+// notableMetrics compares the daily averages of every metric between the days
+// from start up to next and the previous period, which starts on previous.
+// Daily averages do not depend on the length of the periods.
+func notableMetrics(metrics []models.Metric, start, next, previous string) []models.ReportMetric {
+	notable := []models.ReportMetric{}
+	for _, metric := range metrics {
+		if metric.Name == SleepMetric {
+			continue
+		}
+		var currentValues, previousValues []float64
+		for _, day := range DailyMetricValues(metric) {
+			switch {
+			case day.Date >= start && day.Date < next:
+				currentValues = append(currentValues, day.Value)
+			case day.Date >= previous && day.Date < start:
+				previousValues = append(previousValues, day.Value)
+			}
+		}
+		average, before := Mean(currentValues), Mean(previousValues)
+		if len(currentValues) == 0 || len(previousValues) == 0 || before == 0 {
+			continue
+		}
+		percent := 100 * (average - before) / math.Abs(before)
+		if math.Abs(percent) < notableMetricChange {
+			continue
+		}
+		daily := make([]float64, len(currentValues))
+		for i, value := range currentValues {
+			daily[i] = round(value)
+		}
+		notable = append(notable, models.ReportMetric{
+			Name: metric.Name, Units: metric.Units, Average: round(average), Previous: round(before),
+			Percent: round(percent), Daily: daily,
+		})
+	}
+	sort.SliceStable(notable, func(i, j int) bool { return math.Abs(notable[i].Percent) > math.Abs(notable[j].Percent) })
+	return notable[:min(len(notable), reportMetrics)]
+}
+
+// newReportWorkout summarizes a workout for a report
+func newReportWorkout(workout models.Workout) models.ReportWorkout {
+	return models.ReportWorkout{
+		WorkoutID: workout.ID, Name: workout.Name, Start: workout.Start, Duration: round(workout.Duration),
+		Distance: round(workoutKm(workout)), Energy: round(workoutKcal(workout)),
+	}
+}
+
+// workoutKm returns the distance of a workout in kilometers, 0 without one
+func workoutKm(workout models.Workout) float64 {
+	km, _ := DistanceKm(workout.Distance)
+	return km
+}
+
+// workoutKcal returns the active energy of a workout in kilocalories, 0 without one
+func workoutKcal(workout models.Workout) float64 {
+	kcal, _ := EnergyKcal(workout.ActiveEnergyBurned)
+	return kcal
+}
+
+// minTime returns the earlier of two times
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,178 @@
+// utils/review.go
+package utils
+
+import (
+	"fitness/models"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Everyday measures the yearly totals are compared with
+const (
+	marathonKm     = 42.195
+	earthKm        = 40075 // Circumference at the equator
+	trackLapKm     = 0.4
+	pizzaSliceKcal = 285
+	secondsPerDay  = 86400
+)
+
+// CalculateYearInReview summarizes the workouts of a year as of now. Totals,
+// the comparison with the previous year and the records come from the yearly
+// report, the rest is computed from the workouts of the year alone.
+func CalculateYearInReview(workouts []models.Workout, calendar Calendar, year int, now time.Time) models.YearInReview {
+	location := calendar.Location
+	if location == nil {
+		location = time.UTC
+	}
+	start := calendar.StartOf(time.Date(year, time.January, 1, 12, 0, 0, 0, location), Year)
+	end := calendar.Next(start, Year).Add(-time.Nanosecond)
+	report := CalculateReport(workouts, nil, calendar, Year, start, now)
+	review := models.YearInReview{
+		Year: year, Partial: report.Partial, Totals: report.Totals, Changes: report.Changes,
+		Types: []models.ReviewType{}, Weeks: report.Buckets, Records: []models.PersonalRecord{}, Equivalences: []models.ReviewEquivalence{},
+	}
+	// Records improved more than once in the year are listed once, at their best
+	best := make(map[string]int)
+	for _, record := range report.Records {
+		key := record.WorkoutType + "\x00" + record.Category + "\x00" + record.Distance
+		if i, ok := best[key]; ok {
+			record.Previous = review.Records[i].Previous
+			review.Records[i] = record
+			continue
+		}
+		best[key] = len(review.Records)
+		review.Records = append(review.Records, record)
+	}
+
+	var ofYear []models.Workout
+	for _, workout := range workouts {
+		started, err := ParseTime(workout.Start)
+		if err == nil && calendar.Key(started, Year) == strconv.Itoa(year) {
+			ofYear = append(ofYear, workout)
+		}
+	}
+	if len(ofYear) == 0 {
+		return review
+	}
+	sort.SliceStable(ofYear, func(i, j int) bool {
+		a, _ := ParseTime(ofYear[i].Start)
+		b, _ := ParseTime(ofYear[j].Start)
+		return a.Before(b)
+	})
+	first, last := newReportWorkout(ofYear[0]), newReportWorkout(ofYear[len(ofYear)-1])
+	review.FirstWorkout, review.LastWorkout = &first, &last
+
+	types := make(map[string]*models.ReviewType)
+	months := make(map[string]*models.ReportBucket)
+	hours := make([]int, 24)
+	weekdays := make([]int, 7)
+	parts := make(map[string]int)
+	for _, workout := range ofYear {
+		started, _ := ParseTime(workout.Start)
+		started = calendar.In(started)
+		if types[workout.Name] == nil {
+			types[workout.Name] = &models.ReviewType{Name: workout.Name}
+		}
+		kind := types[workout.Name]
+		kind.Workouts++
+		kind.Duration += workout.Duration
+		kind.Distance += workoutKm(workout)
+		kind.Energy += workoutKcal(workout)
+
+		month := calendar.Key(started, Month)
+		if months[month] == nil {
+			months[month] = &models.ReportBucket{Key: month}
+		}
+		months[month].Workouts++
+		months[month].Duration += workout.Duration
+		months[month].Distance += workoutKm(workout)
+		months[month].Energy += workoutKcal(workout)
+
+		hours[started.Hour()]++
+		weekdays[started.Weekday()]++
+		parts[timeOfDay(started.Hour())]++
+	}
+
+	for _, kind := range types {
+		kind.Duration, kind.Distance, kind.Energy = round(kind.Duration), round(kind.Distance), round(kind.Energy)
+		review.Types = append(review.Types, *kind)
+	}
+	sort.Slice(review.Types, func(i, j int) bool {
+		if review.Types[i].Duration != review.Types[j].Duration {
+			return review.Types[i].Duration > review.Types[j].Duration
+		}
+		return review.Types[i].Name < review.Types[j].Name
+	})
+
+	for _, month := range months {
+		if review.BiggestMonth == nil || month.Duration > review.BiggestMonth.Duration ||
+			(month.Duration == review.BiggestMonth.Duration && month.Key < review.BiggestMonth.Key) {
+			review.BiggestMonth = month
+		}
+	}
+	review.BiggestMonth.Duration, review.BiggestMonth.Distance, review.BiggestMonth.Energy =
+		round(review.BiggestMonth.Duration), round(review.BiggestMonth.Distance), round(review.BiggestMonth.Energy)
+	for i, week := range review.Weeks {
+		if week.Workouts > 0 && (review.BiggestWeek == nil || week.Duration > review.BiggestWeek.Duration) {
+			review.BiggestWeek = &review.Weeks[i]
+		}
+	}
+
+	// Streaks only count the days of the year
+	streaks := CalculateStreaks(ofYear, calendar, StreakRules{}, minTime(now, end))
+	review.LongestStreak, review.LongestWeekStreak = streaks.Daily.Longest, streaks.Weekly.Longest
+
+	hour := mostFrequent(hours)
+	review.FavoriteHour = &hour
+	review.FavoriteWeekday = time.Weekday(mostFrequent(weekdays)).String()
+	for _, part := range []string{"morning", "afternoon", "evening", "night"} {
+		if parts[part] > parts[review.FavoriteTime] {
+			review.FavoriteTime = part
+		}
+	}
+
+	review.Equivalences = equivalences(review.Totals)
+	return review
+}
+
+// equivalences puts the totals of a year in everyday terms, leaving out the zero ones
+func equivalences(totals models.ReportTotals) []models.ReviewEquivalence {
+	result := []models.ReviewEquivalence{}
+	add := func(name string, value float64, description string) {
+		if value > 0 {
+			result = append(result, models.ReviewEquivalence{Name: name, Value: round(value), Description: fmt.Sprintf(description, value)})
+		}
+	}
+	add("marathons", totals.Distance/marathonKm, "You covered the distance of %.1f marathons")
+	add("earth", 100*totals.Distance/earthKm, "You went %.2f%% of the way around the Earth")
+	add("track laps", totals.Distance/trackLapKm, "That is %.0f laps of a 400 m track")
+	add("days", totals.Duration/secondsPerDay, "You spent %.1f days working out")
+	add("pizza slices", totals.Energy/pizzaSliceKcal, "You burned the energy of %.0f slices of pizza")
+	return result
+}
+
+// timeOfDay names the part of the day an hour falls in
+func timeOfDay(hour int) string {
+	switch {
+	case hour >= 5 && hour < 12:
+		return "morning"
+	case hour >= 12 && hour < 17:
+		return "afternoon"
+	case hour >= 17 && hour < 21:
+		return "evening"
+	}
+	return "night"
+}
+
+// mostFrequent returns the index of the highest count, the first one on ties
+func mostFrequent(counts []int) int {
+	best := 0
+	for i, count := range counts {
+		if count > counts[best] {
+			best = i
+		}
+	}
+	return best
+}
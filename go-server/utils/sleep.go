@@ -0,0 +1,290 @@
+// utils/sleep.go
+package utils
+
+import (
+	"fitness/config"
+	"fitness/models"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Health Auto Export metric holding the sleep analysis
+const SleepMetric = "sleep_analysis"
+
+// Nights the sleep debt is summed over
+const sleepDebtNights = 14
+
+// sleepNight accumulates the stages of a night, in hours
+type sleepNight struct {
+	bed, wake                      time.Time
+	inBed, asleep, core, deep, rem float64
+	awake                          float64
+	sources                        []string
+}
+
+// SleepNights parses the sleep_analysis metric into nights, oldest first.
+// Aggregated data points are a night each. Unaggregated stages are grouped into
+// the night of the day they end on, counting from noon to noon.
+func SleepNights(metrics []models.Metric) []models.SleepNight {
+	nights := make(map[string]*sleepNight)
+	night := func(key string) *sleepNight {
+		if nights[key] == nil {
+			nights[key] = &sleepNight{}
+		}
+		return nights[key]
+	}
+	for _, metric := range metrics {
+		if metric.Name != SleepMetric {
+			continue
+		}
+		for _, point := range metric.Data {
+			sleep := point.SleepData
+			if sleep == nil {
+				continue
+			}
+			if sleep.Value != "" {
+				addSleepStage(night, *sleep, point.Qty)
+				continue
+			}
+			if len(point.Date) < len(config.DateFormat) {
+				continue
+			}
+			n := night(point.Date[:len(config.DateFormat)])
+			n.core += sleep.Core
+			n.deep += sleep.Deep
+			n.rem += sleep.REM
+			n.awake += sleep.Awake
+			n.inBed += sleep.InBed
+			if asleep := sleep.Core + sleep.Deep + sleep.REM; sleep.TotalSleep > asleep {
+				n.asleep += sleep.TotalSleep - asleep
+			} else {
+				n.asleep += sleep.Asleep
+			}
+			n.include(firstTime(sleep.InBedStart, sleep.SleepStart), firstTime(sleep.InBedEnd, sleep.SleepEnd), sleep.Source)
+		}
+	}
+
+	keys := make([]string, 0, len(nights))
+	for key := range nights {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make([]models.SleepNight, 0, len(keys))
+	for _, key := range keys {
+		n := nights[key]
+		asleep := n.asleep + n.core + n.deep + n.rem
+		inBed := n.inBed
+		if inBed == 0 && !n.bed.IsZero() && !n.wake.IsZero() {
+			inBed = n.wake.Sub(n.bed).Hours()
+		}
+		if asleep == 0 && inBed == 0 {
+			continue
+		}
+		entry := models.SleepNight{
+			Night: key, InBed: round(inBed), Asleep: round(asleep), Awake: round(n.awake),
+			Source: strings.Join(n.sources, ", "),
+		}
+		if !n.bed.IsZero() {
+			entry.BedTime = n.bed.Format(config.TimeFormat)
+		}
+		if !n.wake.IsZero() {
+			entry.WakeTime = n.wake.Format(config.TimeFormat)
+		}
+		if inBed > 0 {
+			efficiency := round(100 * math.Min(asleep/inBed, 1))
+			entry.Efficiency = &efficiency
+		}
+		if staged := n.core + n.deep + n.rem; staged > 0 {
+			entry.Stages = &models.SleepStages{
+				Core: round(100 * n.core / asleep), Deep: round(100 * n.deep / asleep),
+				REM: round(100 * n.rem / asleep), Unspecified: round(100 * n.asleep / asleep),
+			}
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+// addSleepStage adds an unaggregated stage to its night, its length taken from
+// its start and end or otherwise from its quantity in hours
+func addSleepStage(night func(string) *sleepNight, stage models.SleepData, qty float64) {
+	start, err := ParseTime(stage.StartDate)
+	if err != nil {
+		return
+	}
+	end, err := ParseTime(stage.EndDate)
+	hours := qty
+	if err == nil && end.After(start) {
+		hours = end.Sub(start).Hours()
+	} else {
+		end = start.Add(time.Duration(hours * float64(time.Hour)))
+	}
+	// Stages from noon to noon belong to the night ending on the next day
+	n := night(start.Add(12 * time.Hour).Format(config.DateFormat))
+	switch strings.ToLower(strings.ReplaceAll(stage.Value, " ", "")) {
+	case "inbed":
+		n.inBed += hours
+	case "core":
+		n.core += hours
+	case "deep":
+		n.deep += hours
+	case "rem":
+		n.rem += hours
+	case "awake":
+		n.awake += hours
+	case "asleep", "asleepunspecified":
+		n.asleep += hours
+	default:
+		return
+	}
+	n.include(start, end, stage.Source)
+}
+
+// include widens the night to the period and records its source
+func (n *sleepNight) include(start, end time.Time, source string) {
+	if !start.IsZero() && (n.bed.IsZero() || start.Before(n.bed)) {
+		n.bed = start
+	}
+	if end.After(n.wake) {
+		n.wake = end
+	}
+	if source != "" && !slices.Contains(n.sources, source) {
+		n.sources = append(n.sources, source)
+	}
+}
+
+// CalculateSleepDebt sets the debt of every night: the hours slept below the
+// target over the nights of the last 14 days, never below zero
+func CalculateSleepDebt(nights []models.SleepNight, target float64) {
+	for i := range nights {
+		end, err := time.Parse(config.DateFormat, nights[i].Night)
+		if err != nil {
+			continue
+		}
+		since := end.AddDate(0, 0, -sleepDebtNights).Format(config.DateFormat)
+		debt := 0.0
+		for j := i; j >= 0 && nights[j].Night > since; j-- {
+			debt += target - nights[j].Asleep
+		}
+		nights[i].Debt = round(math.Max(debt, 0))
+	}
+}
+
+// CalculateSleepReport averages the nights, whose debt is already set
+func CalculateSleepReport(nights []models.SleepNight, target float64) models.SleepReport {
+	report := models.SleepReport{Target: target, Nights: nights}
+	if len(nights) == 0 {
+		report.Nights = []models.SleepNight{}
+		return report
+	}
+	asleep, efficiency := sleepAverages(nights)
+	report.Asleep, report.Efficiency = round(asleep), round(efficiency)
+	report.Debt = nights[len(nights)-1].Debt
+	report.Consistency = NewSleepConsistency(nights)
+	return report
+}
+
+// WeeklySleep summarizes the nights per week, from the first to the last night
+func WeeklySleep(nights []models.SleepNight, calendar Calendar) []models.SleepWeek {
+	weeks := []models.SleepWeek{}
+	if len(nights) == 0 {
+		return weeks
+	}
+	// Nights are calendar days as recorded, noon keeps them on the same day in any timezone
+	day := func(night models.SleepNight) time.Time {
+		date, _ := time.Parse(config.DateFormat, night.Night)
+		return time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, calendar.Location)
+	}
+	byWeek := make(map[string][]models.SleepNight)
+	for _, night := range nights {
+		key := calendar.Key(day(night), Week)
+		byWeek[key] = append(byWeek[key], night)
+	}
+
+	for _, start := range calendar.Range(day(nights[0]), day(nights[len(nights)-1]), Week) {
+		key := calendar.Key(start, Week)
+		week := models.SleepWeek{Week: key, Nights: len(byWeek[key])}
+		if week.Nights > 0 {
+			group := byWeek[key]
+			asleep, efficiency := sleepAverages(group)
+			week.Asleep, week.Efficiency = round(asleep), round(efficiency)
+			var inBed, core, deep, rem, unspecified []float64
+			for _, night := range group {
+				inBed = append(inBed, night.InBed)
+				if night.Stages != nil {
+					core = append(core, night.Stages.Core)
+					deep = append(deep, night.Stages.Deep)
+					rem = append(rem, night.Stages.REM)
+					unspecified = append(unspecified, night.Stages.Unspecified)
+				}
+			}
+			week.InBed = round(Mean(inBed))
+			week.Stages = models.SleepStages{
+				Core: round(Mean(core)), Deep: round(Mean(deep)), REM: round(Mean(rem)), Unspecified: round(Mean(unspecified)),
+			}
+			week.Consistency = NewSleepConsistency(group)
+			week.Debt = group[len(group)-1].Debt
+		} else if len(weeks) > 0 {
+			week.Debt = weeks[len(weeks)-1].Debt
+		}
+		weeks = append(weeks, week)
+	}
+	return weeks
+}
+
+// NewSleepConsistency averages the bed and wake times of the nights and
+// measures their spread. Bed times count from noon so midnight does not split them.
+func NewSleepConsistency(nights []models.SleepNight) models.SleepConsistency {
+	var bedTimes, wakeTimes []float64
+	for _, night := range nights {
+		if bed, err := ParseTime(night.BedTime); err == nil {
+			bedTimes = append(bedTimes, math.Mod(float64(bed.Hour()*60+bed.Minute())+12*60, 24*60))
+		}
+		if wake, err := ParseTime(night.WakeTime); err == nil {
+			wakeTimes = append(wakeTimes, float64(wake.Hour()*60+wake.Minute()))
+		}
+	}
+	var consistency models.SleepConsistency
+	if len(bedTimes) > 0 {
+		consistency.BedTime = clockTime(Mean(bedTimes) + 12*60)
+		consistency.BedTimeStdDev = round(StdDev(bedTimes))
+	}
+	if len(wakeTimes) > 0 {
+		consistency.WakeTime = clockTime(Mean(wakeTimes))
+		consistency.WakeTimeStdDev = round(StdDev(wakeTimes))
+	}
+	return consistency
+}
+
+// sleepAverages returns the average hours asleep of the nights and the average
+// efficiency of the nights with one
+func sleepAverages(nights []models.SleepNight) (asleep, efficiency float64) {
+	var hours, efficiencies []float64
+	for _, night := range nights {
+		hours = append(hours, night.Asleep)
+		if night.Efficiency != nil {
+			efficiencies = append(efficiencies, *night.Efficiency)
+		}
+	}
+	return Mean(hours), Mean(efficiencies)
+}
+
+// clockTime formats minutes since midnight as hh:mm, wrapping around midnight
+func clockTime(minutes float64) string {
+	total := int(math.Round(minutes)) % (24 * 60)
+	return fmt.Sprintf("%02d:%02d", total/60, total%60)
+}
+
+// firstTime parses the first of the timestamps that is set
+func firstTime(values ...string) time.Time {
+	for _, value := range values {
+		if parsed, err := ParseTime(value); err == nil {
+			return parsed
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,141 @@
+// utils/statistics.go
+package utils
+
+import (
+	"math"
+	"sort"
+)
+
+// Sum adds up the values
+func Sum(values []float64) float64 {
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	return total
+}
+
+// Mean returns the arithmetic mean of the values, 0 when there are none
+func Mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+// StdDev returns the sample standard deviation of the values
+func StdDev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	mean := Mean(values)
+	sum := 0.0
+	for _, value := range values {
+		sum += (value - mean) * (value - mean)
+	}
+	return math.Sqrt(sum / float64(len(values)-1))
+}
+
+// Median returns the middle of the values, averaging the two middle ones
+func Median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// TTestPValue returns the two-sided p-value of a t statistic with the degrees of freedom
+func TTestPValue(t, df float64) float64 {
+	if df <= 0 || math.IsNaN(t) {
+		return 1
+	}
+	if math.IsInf(t, 0) {
+		return 0
+	}
+	return regularizedBeta(df/(df+t*t), df/2, 0.5)
+}
+
+// TCritical returns the t value a two-sided confidence interval at the
+// confidence level, such as 0.95, reaches with the degrees of freedom
+func TCritical(confidence, df float64) float64 {
+	alpha := 1 - confidence
+	low, high := 0.0, 1.0
+	for TTestPValue(high, df) > alpha && high < 1e6 {
+		high *= 2
+	}
+	// The p-value falls as t grows, so bisect towards alpha
+	for i := 0; i < 100; i++ {
+		middle := (low + high) / 2
+		if TTestPValue(middle, df) > alpha {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+	return (low + high) / 2
+}
+
+// regularizedBeta computes the regularized incomplete beta function I_x(a, b)
+func regularizedBeta(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lgammaAB, _ := math.Lgamma(a + b)
+	lgammaA, _ := math.Lgamma(a)
+	lgammaB, _ := math.Lgamma(b)
+	front := math.Exp(lgammaAB - lgammaA - lgammaB + a*math.Log(x) + b*math.Log(1-x))
+	// The continued fraction converges quickly on this side of the mean
+	if x < (a+1)/(a+b+2) {
+		return front * betaFraction(x, a, b) / a
+	}
+	return 1 - front*betaFraction(1-x, b, a)/b
+}
+
+// betaFraction evaluates the continued fraction of the incomplete beta function
+// with the modified Lentz method
+func betaFraction(x, a, b float64) float64 {
+	const (
+		maxIterations = 300
+		epsilon       = 1e-14
+		tiny          = 1e-300
+	)
+	clamp := func(value float64) float64 {
+		if math.Abs(value) < tiny {
+			return tiny
+		}
+		return value
+	}
+	c := 1.0
+	d := 1 / clamp(1-(a+b)*x/(a+1))
+	h := d
+	for m := 1.0; m <= maxIterations; m++ {
+		// Even step
+		numerator := m * (b - m) * x / ((a + 2*m - 1) * (a + 2*m))
+		d = 1 / clamp(1+numerator*d)
+		c = clamp(1 + numerator/c)
+		h *= d * c
+		// Odd step
+		numerator = -(a + m) * (a + b + m) * x / ((a + 2*m) * (a + 2*m + 1))
+		d = 1 / clamp(1+numerator*d)
+		c = clamp(1 + numerator/c)
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
@@ -0,0 +1,116 @@
+// utils/streaks.go
+package utils
+
+import (
+	"fitness/models"
+	"time"
+)
+
+// StreakRules decide which days are active and how streaks continue
+type StreakRules struct {
+	MinDuration float64 // Seconds of exercise a day needs to be active
+	MinEnergy   float64 // Kilocalories a day needs to be active
+	RestDays    int     // Inactive days allowed in a row without breaking a daily streak
+	WeekDays    int     // Active days a week needs to continue a weekly streak, at least 1
+}
+
+// CalculateStreaks computes daily and weekly streaks of the workouts up to now,
+// with day and week boundaries taken from the calendar
+func CalculateStreaks(workouts []models.Workout, calendar Calendar, rules StreakRules, now time.Time) models.Streaks {
+	streaks := models.Streaks{Weeks: []models.WeekConsistency{}}
+
+	// Total the counted exercise of every day
+	type dayTotal struct {
+		duration, energy float64
+	}
+	days := make(map[string]*dayTotal)
+	var first time.Time
+	for _, workout := range workouts {
+		start, err := ParseTime(workout.Start)
+		if err != nil || start.After(now) {
+			continue
+		}
+		key := calendar.Key(start, Day)
+		if days[key] == nil {
+			days[key] = &dayTotal{}
+		}
+		days[key].duration += workout.Duration
+		if kcal, ok := EnergyKcal(workout.ActiveEnergyBurned); ok {
+			days[key].energy += kcal
+		}
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+	}
+	if first.IsZero() {
+		return streaks
+	}
+	active := func(key string) bool {
+		total := days[key]
+		return total != nil && total.duration >= rules.MinDuration && total.energy >= rules.MinEnergy
+	}
+
+	// Daily streaks continue through at most RestDays inactive days in a row
+	var current, longest models.Streak
+	rest := 0
+	dayStarts := calendar.Range(first, now, Day)
+	for i, start := range dayStarts {
+		key := calendar.Key(start, Day)
+		if active(key) {
+			if current.Length == 0 {
+				current.Start = key
+			}
+			current.Length++
+			current.End = key
+			rest = 0
+		} else if i < len(dayStarts)-1 {
+			// Today is not over yet and never breaks a streak
+			rest++
+			if rest > rules.RestDays {
+				current = models.Streak{}
+			}
+		}
+		if current.Length > longest.Length {
+			longest = current
+		}
+	}
+	streaks.Daily = models.StreakSummary{Current: current, Longest: longest}
+
+	// Weekly streaks count weeks with enough active days
+	weekDays := max(rules.WeekDays, 1)
+	current, longest = models.Streak{}, models.Streak{}
+	weekStarts := calendar.Range(first, now, Week)
+	total := 0.0
+	for i, start := range weekStarts {
+		week := calendar.Key(start, Week)
+		activeDays := 0
+		for day := start; day.Before(calendar.Next(start, Week)); day = calendar.Next(day, Day) {
+			if active(calendar.Key(day, Day)) {
+				activeDays++
+			}
+		}
+		score := float64(activeDays) / 7
+		streaks.Weeks = append(streaks.Weeks, models.WeekConsistency{Week: week, ActiveDays: activeDays, Score: score})
+		// The week in progress only counts when it is the only one
+		if i < len(weekStarts)-1 || len(weekStarts) == 1 {
+			total += score
+		}
+
+		if activeDays >= weekDays {
+			if current.Length == 0 {
+				current.Start = week
+			}
+			current.Length++
+			current.End = week
+		} else if i < len(weekStarts)-1 {
+			// The current week can still reach the target
+			current = models.Streak{}
+		}
+		if current.Length > longest.Length {
+			longest = current
+		}
+	}
+	streaks.Weekly = models.StreakSummary{Current: current, Longest: longest}
+	streaks.Consistency = total / float64(max(len(weekStarts)-1, 1))
+	return streaks
+}
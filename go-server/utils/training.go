@@ -0,0 +1,173 @@
+// utils/training.go
+package utils
+
+import (
+	"fitness/models"
+	"math"
+	"time"
+)
+
+// Defaults used when neither the profile nor the data provide a heart rate
+const (
+	DefaultRestingHeartRate = 60.0
+	DefaultMaxHeartRate     = 190.0
+)
+
+// Training load methods
+const (
+	LoadTRIMP = "trimp" // Banister training impulse from the average heart rate
+	LoadMETs  = "mets"  // Duration weighted by the intensity in METs
+)
+
+// Time constants of the exponentially weighted load averages, in days
+const (
+	acuteDays   = 7
+	chronicDays = 42
+)
+
+// HeartRateProfile holds the heart rates the training load is computed from
+type HeartRateProfile struct {
+	Resting float64 // Resting heart rate in bpm
+	Max     float64 // Maximum heart rate in bpm
+	Sex     string  // Either "male", "female" or empty, weights the training impulse
+
+	LactateThreshold float64 // Lactate threshold heart rate in bpm
+}
+
+// NewHeartRateProfile resolves heart rates from the profile, falling back to the
+// latest resting_heart_rate metric, the highest workout heart rate and defaults.
+// The lactate threshold falls back to 90% of the maximum heart rate.
+func NewHeartRateProfile(profile models.Profile, workouts []models.Workout, metrics []models.Metric) HeartRateProfile {
+	heartRate := HeartRateProfile{
+		Resting: profile.RestingHeartRate, Max: profile.MaxHeartRate, Sex: profile.Sex,
+		LactateThreshold: profile.LactateThresholdHeartRate,
+	}
+	if heartRate.Resting == 0 {
+		heartRate.Resting = DefaultRestingHeartRate
+		var latest time.Time
+		for _, metric := range metrics {
+			if metric.Name != "resting_heart_rate" {
+				continue
+			}
+			for _, point := range metric.Data {
+				date, err := ParseTime(point.Date)
+				if err == nil && point.Qty > 0 && !date.Before(latest) {
+					latest, heartRate.Resting = date, point.Qty
+				}
+			}
+		}
+	}
+	if heartRate.Max == 0 {
+		for _, workout := range workouts {
+			if workout.HeartRate != nil && workout.HeartRate.Max != nil {
+				heartRate.Max = max(heartRate.Max, workout.HeartRate.Max.Qty)
+			}
+			for _, sample := range workout.HeartRateData {
+				heartRate.Max = max(heartRate.Max, sample.Max, sample.Avg)
+			}
+		}
+		if heartRate.Max <= heartRate.Resting {
+			heartRate.Max = DefaultMaxHeartRate
+		}
+	}
+	if heartRate.LactateThreshold == 0 {
+		heartRate.LactateThreshold = 0.9 * heartRate.Max
+	}
+	return heartRate
+}
+
+// WorkoutLoad scores a workout with TRIMP when it has an average heart rate,
+// otherwise with its duration and intensity. It is not ok without either.
+func WorkoutLoad(workout models.Workout, heartRate HeartRateProfile) (load float64, method string, ok bool) {
+	minutes := workout.Duration / 60
+	if minutes <= 0 {
+		return 0, "", false
+	}
+	if workout.HeartRate != nil && workout.HeartRate.Avg != nil && heartRate.Max > heartRate.Resting {
+		// Banister TRIMP weights the heart rate reserve used exponentially
+		reserve := (workout.HeartRate.Avg.Qty - heartRate.Resting) / (heartRate.Max - heartRate.Resting)
+		reserve = math.Min(math.Max(reserve, 0), 1)
+		factor, weight := 0.64, 1.92
+		switch heartRate.Sex {
+		case "female":
+			factor, weight = 0.86, 1.67
+		case "":
+			factor, weight = (0.64+0.86)/2, (1.92+1.67)/2
+		}
+		return minutes * reserve * factor * math.Exp(weight*reserve), LoadTRIMP, true
+	}
+	if workout.Intensity != nil && workout.Intensity.Qty > 0 {
+		// Intensity is exported in kcal/hr·kg, which equals METs. Ten MET-hours
+		// score about the same as an hour of moderate heart rate training.
+		return minutes / 60 * workout.Intensity.Qty * 10, LoadMETs, true
+	}
+	return 0, "", false
+}
+
+// CalculateTrainingLoad scores the workouts and derives daily acute and chronic
+// load, training stress balance and the acute to chronic workload ratio from the
+// first workout up to now, with days taken from the calendar. The ratio is left
+// unset until the first workout is as old as the chronic window.
+func CalculateTrainingLoad(workouts []models.Workout, calendar Calendar, heartRate HeartRateProfile, now time.Time) models.TrainingLoad {
+	trainingLoad := models.TrainingLoad{Days: []models.TrainingLoadDay{}, Workouts: []models.WorkoutLoad{}}
+
+	daily := make(map[string]float64)
+	var first time.Time
+	for _, workout := range workouts {
+		start, err := ParseTime(workout.Start)
+		if err != nil || start.After(now) {
+			continue
+		}
+		load, method, ok := WorkoutLoad(workout, heartRate)
+		if !ok {
+			continue
+		}
+		trainingLoad.Workouts = append(trainingLoad.Workouts, models.WorkoutLoad{
+			WorkoutID: workout.ID, Name: workout.Name, Start: workout.Start, Load: round(load), Method: method,
+		})
+		daily[calendar.Key(start, Day)] += load
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+	}
+	if first.IsZero() {
+		return trainingLoad
+	}
+
+	// Exponentially weighted averages, updated once a day
+	acuteDecay := 1 - math.Exp(-1.0/acuteDays)
+	chronicDecay := 1 - math.Exp(-1.0/chronicDays)
+	var atl, ctl float64
+	for i, start := range calendar.Range(first, now, Day) {
+		key := calendar.Key(start, Day)
+		load := daily[key]
+		// Form is measured before the day's training
+		day := models.TrainingLoadDay{Date: key, Load: round(load), TSB: round(ctl - atl)}
+		atl += (load - atl) * acuteDecay
+		ctl += (load - ctl) * chronicDecay
+		day.ATL, day.CTL = round(atl), round(ctl)
+		// The ratio means little until the chronic window is covered, the chronic
+		// load starting from zero
+		if ctl > 0 && i+1 >= chronicDays {
+			ratio := round(atl / ctl)
+			day.ACWR = &ratio
+			day.Risk = workloadRisk(ratio)
+		}
+		trainingLoad.Days = append(trainingLoad.Days, day)
+	}
+	return trainingLoad
+}
+
+// workloadRisk bands the acute to chronic workload ratio by injury risk
+func workloadRisk(ratio float64) string {
+	switch {
+	case ratio < 0.8:
+		return "undertraining"
+	case ratio <= 1.3:
+		return "optimal"
+	case ratio <= 1.5:
+		return "elevated"
+	default:
+		return "high"
+	}
+}
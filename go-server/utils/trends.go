@@ -0,0 +1,288 @@
+// utils/trends.go
+package utils
+
+import (
+	"fitness/models"
+	"math"
+	"strings"
+	"time"
+)
+
+// Forecast methods
+const (
+	HoltWinters = "holt-winters" // Additive level, trend and seasonality
+	Holt        = "holt"         // Level and trend, used without two full seasons
+)
+
+// Periods in a season of each bucket size
+var seasonLengths = map[Period]int{Day: 7, Week: 52, ISOWeek: 52, Month: 12}
+
+// Smoothing factors tried when fitting a forecast
+var smoothingGrid = []float64{0.05, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9}
+
+// TrendSeries aggregates a workout field, or the average of a health metric,
+// per completed period up to now. Distances are in the units. Periods without
+// workouts count as zero, periods without metric data repeat the previous value.
+func TrendSeries(series, units string, workouts []models.Workout, metrics []models.Metric, calendar Calendar, period Period, now time.Time) []Bucket {
+	var totals, counts map[string]float64
+	first := now
+	switch series {
+	case models.GoalWorkouts, models.GoalDistance, models.GoalDuration, models.GoalEnergy:
+		value := func(workout models.Workout) float64 {
+			amount, _ := WorkoutField(series, units, workout)
+			return amount
+		}
+		buckets := Aggregate(calendar, workouts, period, workoutStart, value)
+		totals = BucketMap(buckets)
+		if len(buckets) > 0 {
+			first = buckets[0].Start
+		}
+	default:
+		var points []models.MetricData
+		for _, metric := range metrics {
+			if strings.EqualFold(metric.Name, series) {
+				points = append(points, metric.Data...)
+			}
+		}
+		date := func(point models.MetricData) string { return point.Date }
+		buckets := Aggregate(calendar, points, period, date, func(point models.MetricData) float64 { return point.Qty })
+		totals = BucketMap(buckets)
+		counts = BucketMap(Aggregate(calendar, points, period, date, func(models.MetricData) float64 { return 1 }))
+		if len(buckets) > 0 {
+			first = buckets[0].Start
+		}
+	}
+
+	// The current period is not over yet and would drag the trend down
+	var result []Bucket
+	current := calendar.StartOf(now, period)
+	for _, start := range calendar.Range(first, now, period) {
+		if !start.Before(current) {
+			break
+		}
+		key := calendar.Key(start, period)
+		value := totals[key]
+		if counts != nil {
+			if counts[key] > 0 {
+				value /= counts[key]
+			} else if len(result) > 0 {
+				value = result[len(result)-1].Value
+			}
+		}
+		result = append(result, Bucket{Key: key, Start: start, Value: value})
+	}
+	return result
+}
+
+// CalculateTrends derives moving averages over the window, a linear regression
+// and a forecast of horizon periods from the buckets of a series
+func CalculateTrends(buckets []Bucket, calendar Calendar, period Period, window, horizon int) models.Trends {
+	trends := models.Trends{Bucket: string(period), Window: window, Points: []models.TrendPoint{}, Forecast: []models.ForecastPoint{}}
+	if len(buckets) == 0 {
+		return trends
+	}
+	values := make([]float64, len(buckets))
+	for i, bucket := range buckets {
+		values[i] = bucket.Value
+	}
+
+	sma := MovingAverage(values, window)
+	ema := ExponentialMovingAverage(values, 2/float64(window+1))
+	for i, bucket := range buckets {
+		point := models.TrendPoint{Period: bucket.Key, Value: round(bucket.Value), EMA: round(ema[i])}
+		if i >= window-1 {
+			average := round(sma[i-window+1])
+			point.SMA = &average
+		}
+		trends.Points = append(trends.Points, point)
+	}
+	if regression, ok := LinearRegression(values); ok {
+		trends.Regression = &regression
+	}
+
+	// Forecasts of a series that never goes below zero stay at or above it
+	nonNegative := true
+	for _, value := range values {
+		nonNegative = nonNegative && value >= 0
+	}
+	trends.Season = seasonLengths[period]
+	forecast, sigma, method := Forecast(values, trends.Season, horizon)
+	trends.Method = method
+	start := buckets[len(buckets)-1].Start
+	for h, value := range forecast {
+		start = calendar.Next(start, period)
+		spread := 1.96 * sigma * math.Sqrt(float64(h+1))
+		point := models.ForecastPoint{
+			Period: calendar.Key(start, period),
+			Value:  round(value), Lower: round(value - spread), Upper: round(value + spread),
+		}
+		if nonNegative {
+			point.Value, point.Lower = math.Max(point.Value, 0), math.Max(point.Lower, 0)
+		}
+		trends.Forecast = append(trends.Forecast, point)
+	}
+	return trends
+}
+
+// MovingAverage returns the simple moving average of every full window
+func MovingAverage(values []float64, window int) []float64 {
+	if window < 1 || len(values) < window {
+		return nil
+	}
+	averages := make([]float64, 0, len(values)-window+1)
+	sum := 0.0
+	for i, value := range values {
+		sum += value
+		if i >= window {
+			sum -= values[i-window]
+		}
+		if i >= window-1 {
+			averages = append(averages, sum/float64(window))
+		}
+	}
+	return averages
+}
+
+// ExponentialMovingAverage smooths the values with the factor alpha, starting
+// from the first value
+func ExponentialMovingAverage(values []float64, alpha float64) []float64 {
+	averages := make([]float64, len(values))
+	for i, value := range values {
+		if i == 0 {
+			averages[i] = value
+			continue
+		}
+		averages[i] = alpha*value + (1-alpha)*averages[i-1]
+	}
+	return averages
+}
+
+// LinearRegression fits a least squares line through the values against their
+// index, with a 95% confidence interval of the slope. It is not ok with fewer
+// than three values.
+func LinearRegression(values []float64) (models.Regression, bool) {
+	n := float64(len(values))
+	if len(values) < 3 {
+		return models.Regression{}, false
+	}
+	meanX := (n - 1) / 2
+	meanY := Mean(values)
+	var sxx, sxy, syy float64
+	for i, value := range values {
+		dx, dy := float64(i)-meanX, value-meanY
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	slope := sxy / sxx
+	intercept := meanY - slope*meanX
+
+	residuals := 0.0
+	for i, value := range values {
+		residual := value - (intercept + slope*float64(i))
+		residuals += residual * residual
+	}
+	df := n - 2
+	stdErr := math.Sqrt(residuals / df / sxx)
+	margin := TCritical(0.95, df) * stdErr
+
+	regression := models.Regression{
+		Slope: round(slope), Intercept: round(intercept), StdErr: round(stdErr),
+		Lower: round(slope - margin), Upper: round(slope + margin), RSquared: 1, PValue: 0,
+		Direction: "flat",
+	}
+	if syy > 0 {
+		regression.RSquared = round(sxy * sxy / (sxx * syy))
+	}
+	if stdErr > 0 {
+		regression.PValue = TTestPValue(slope/stdErr, df)
+	} else if slope == 0 {
+		regression.PValue = 1
+	}
+	switch {
+	case slope-margin > 0:
+		regression.Direction = "up"
+	case slope+margin < 0:
+		regression.Direction = "down"
+	}
+	return regression, true
+}
+
+// Forecast predicts horizon values after the series with additive Holt-Winters
+// when it holds two full seasons, and with Holt's linear method otherwise. The
+// smoothing factors minimize the one step ahead errors, whose standard
+// deviation is returned with the forecast.
+func Forecast(values []float64, season, horizon int) (forecast []float64, sigma float64, method string) {
+	if len(values) < 3 || horizon < 1 {
+		return nil, 0, ""
+	}
+	method = Holt
+	if season > 1 && len(values) >= 2*season {
+		method = HoltWinters
+	}
+
+	best := math.Inf(1)
+	for _, alpha := range smoothingGrid {
+		for _, beta := range smoothingGrid {
+			gammas := []float64{0}
+			if method == HoltWinters {
+				gammas = smoothingGrid
+			}
+			for _, gamma := range gammas {
+				predicted, sse, steps := smooth(values, season, method, alpha, beta, gamma, horizon)
+				if sse < best {
+					best, forecast = sse, predicted
+					sigma = math.Sqrt(sse / float64(steps))
+				}
+			}
+		}
+	}
+	return forecast, sigma, method
+}
+
+// smooth runs exponential smoothing with the factors and returns the forecast,
+// the sum of squared one step ahead errors and the number of steps
+func smooth(values []float64, season int, method string, alpha, beta, gamma float64, horizon int) ([]float64, float64, int) {
+	var level, trend float64
+	var seasonal []float64
+	first := 1
+	if method == HoltWinters {
+		// Start from the first two seasons, whose means sit in their middle
+		firstMean, secondMean := Mean(values[:season]), Mean(values[season:2*season])
+		trend = (secondMean - firstMean) / float64(season)
+		middle := float64(season-1) / 2
+		level = firstMean + trend*middle
+		seasonal = make([]float64, season)
+		for i := range seasonal {
+			detrended := trend * (float64(i) - middle)
+			seasonal[i] = (values[i] - firstMean - detrended + values[season+i] - secondMean - detrended) / 2
+		}
+		first = season
+	} else {
+		level, trend = values[0], values[1]-values[0]
+	}
+	seasonOf := func(t int) float64 {
+		if seasonal == nil {
+			return 0
+		}
+		return seasonal[t%season]
+	}
+
+	sse := 0.0
+	for t := first; t < len(values); t++ {
+		err := values[t] - (level + trend + seasonOf(t))
+		sse += err * err
+		previous := level
+		level = alpha*(values[t]-seasonOf(t)) + (1-alpha)*(level+trend)
+		trend = beta*(level-previous) + (1-beta)*trend
+		if seasonal != nil {
+			seasonal[t%season] = gamma*(values[t]-level) + (1-gamma)*seasonal[t%season]
+		}
+	}
+
+	forecast := make([]float64, horizon)
+	for h := range forecast {
+		forecast[h] = level + float64(h+1)*trend + seasonOf(len(values)+h)
+	}
+	return forecast, sse, len(values) - first
+}
@@ -0,0 +1,62 @@
+// utils/units.go
+package utils
+
+import (
+	"fitness/models"
+	"strings"
+)
+
+// Meters in a unit of distance
+var metersPer = map[string]float64{
+	"m":  1,
+	"km": 1000,
+	"mi": 1609.344,
+	"yd": 0.9144,
+	"ft": 0.3048,
+}
+
+// Kilocalories in a unit of energy
+var kcalPer = map[string]float64{
+	"kcal": 1,
+	"cal":  1, // Health Auto Export writes dietary Calories as "cal"
+	"kj":   1 / 4.184,
+}
+
+// ToMeters converts a distance in the units to meters
+func ToMeters(qty float64, units string) (float64, bool) {
+	factor, ok := metersPer[strings.ToLower(units)]
+	return qty * factor, ok
+}
+
+// DistanceKm returns a distance measurement in kilometers
+func DistanceKm(distance *models.Measurement) (float64, bool) {
+	if distance == nil {
+		return 0, false
+	}
+	meters, ok := ToMeters(distance.Qty, distance.Units)
+	return meters / 1000, ok
+}
+
+// EnergyKcal returns an energy measurement in kilocalories
+func EnergyKcal(energy *models.Measurement) (float64, bool) {
+	if energy == nil {
+		return 0, false
+	}
+	factor, ok := kcalPer[strings.ToLower(energy.Units)]
+	return energy.Qty * factor, ok
+}
+
+// Kilograms in a unit of mass
+var kgPer = map[string]float64{
+	"kg":  1,
+	"g":   0.001,
+	"lb":  0.45359237,
+	"lbs": 0.45359237,
+	"st":  6.35029318,
+}
+
+// MassKg converts a mass in the units to kilograms
+func MassKg(qty float64, units string) (float64, bool) {
+	factor, ok := kgPer[strings.ToLower(units)]
+	return qty * factor, ok
+}
@@ -0,0 +1,270 @@
+// utils/zones.go
+package utils
+
+import (
+	"fitness/models"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Heart rate zone models
+const (
+	ZoneMax       = "max"       // Percent of the maximum heart rate
+	ZoneReserve   = "reserve"   // Percent of the heart rate reserve, after Karvonen
+	ZoneThreshold = "threshold" // Percent of the lactate threshold heart rate, after Friel
+)
+
+// Sources of the time in zones of a workout
+const (
+	ZoneSamples = "samples" // Heart rate samples recorded during the workout
+	ZoneAverage = "average" // Whole workout in the zone of its average heart rate
+)
+
+// Names of the five zones of every model
+var zoneNames = []string{"Recovery", "Endurance", "Tempo", "Threshold", "VO2 Max"}
+
+// Fractions of the reference heart rate every zone starts at
+var zoneFractions = map[string][]float64{
+	ZoneMax:       {0.5, 0.6, 0.7, 0.8, 0.9},
+	ZoneReserve:   {0.5, 0.6, 0.7, 0.8, 0.9},
+	ZoneThreshold: {0, 0.85, 0.9, 0.95, 1},
+}
+
+// Longest gap between two heart rate samples counted as time in a zone, in seconds
+const maxSampleGap = 300
+
+// ParseZoneModel validates the name of a zone model, max when empty
+func ParseZoneModel(name string) (string, error) {
+	switch name {
+	case "":
+		return ZoneMax, nil
+	case ZoneMax, ZoneReserve, ZoneThreshold:
+		return name, nil
+	}
+	return "", fmt.Errorf("unknown zone model %q", name)
+}
+
+// HeartRateZones returns the heart rate ranges of the zone model
+func HeartRateZones(model string, heartRate HeartRateProfile) []models.HeartRateZone {
+	fractions := zoneFractions[model]
+	bound := func(fraction float64) float64 {
+		switch model {
+		case ZoneReserve:
+			return round(heartRate.Resting + fraction*(heartRate.Max-heartRate.Resting))
+		case ZoneThreshold:
+			return round(fraction * heartRate.LactateThreshold)
+		default:
+			return round(fraction * heartRate.Max)
+		}
+	}
+	zones := make([]models.HeartRateZone, len(fractions))
+	for i, fraction := range fractions {
+		zones[i] = models.HeartRateZone{Zone: i + 1, Name: zoneNames[i], Low: bound(fraction)}
+		if i+1 < len(fractions) {
+			high := bound(fractions[i+1])
+			zones[i].High = &high
+		}
+	}
+	return zones
+}
+
+// zoneIndex returns the index of the zone a heart rate falls in, zone 1 holding
+// the heart rates below it
+func zoneIndex(zones []models.HeartRateZone, bpm float64) int {
+	for i := len(zones) - 1; i > 0; i-- {
+		if bpm >= zones[i].Low {
+			return i
+		}
+	}
+	return 0
+}
+
+// ZoneSeconds returns the seconds a workout spent in every zone. Every sample
+// lasts until the next one, gaps longer than five minutes and the last sample
+// counting as the typical interval. Without samples the whole workout is in the
+// zone of its average heart rate, and without either the source is empty.
+func ZoneSeconds(workout models.Workout, zones []models.HeartRateZone) (seconds []float64, source string) {
+	seconds = make([]float64, len(zones))
+	type sample struct {
+		time time.Time
+		bpm  float64
+	}
+	var samples []sample
+	for _, point := range workout.HeartRateData {
+		date, err := ParseTime(point.Date)
+		bpm := point.Avg
+		if bpm == 0 {
+			bpm = (point.Min + point.Max) / 2
+		}
+		if err == nil && bpm > 0 {
+			samples = append(samples, sample{date, bpm})
+		}
+	}
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].time.Before(samples[j].time) })
+
+	switch {
+	case len(samples) > 1:
+		var gaps []float64
+		for i := 1; i < len(samples); i++ {
+			if gap := samples[i].time.Sub(samples[i-1].time).Seconds(); gap > 0 && gap <= maxSampleGap {
+				gaps = append(gaps, gap)
+			}
+		}
+		interval := Median(gaps)
+		for i, current := range samples {
+			duration := interval
+			if i+1 < len(samples) {
+				if gap := samples[i+1].time.Sub(current.time).Seconds(); gap <= maxSampleGap {
+					duration = gap
+				}
+			} else if end, err := ParseTime(workout.End); err == nil {
+				duration = math.Max(0, math.Min(interval, end.Sub(current.time).Seconds()))
+			}
+			seconds[zoneIndex(zones, current.bpm)] += duration
+		}
+		return seconds, ZoneSamples
+	case len(samples) == 1:
+		seconds[zoneIndex(zones, samples[0].bpm)] = workout.Duration
+		return seconds, ZoneSamples
+	case workout.HeartRate != nil && workout.HeartRate.Avg != nil && workout.HeartRate.Avg.Qty > 0:
+		seconds[zoneIndex(zones, workout.HeartRate.Avg.Qty)] = workout.Duration
+		return seconds, ZoneAverage
+	}
+	return seconds, ""
+}
+
+// CalculateWorkoutZones breaks a workout down into the zones of the model
+func CalculateWorkoutZones(workout models.Workout, model string, heartRate HeartRateProfile) models.WorkoutZones {
+	zones := HeartRateZones(model, heartRate)
+	seconds, source := ZoneSeconds(workout, zones)
+	return models.WorkoutZones{
+		WorkoutID: workout.ID, Model: model, Source: source, Zones: zones,
+		Time: zoneTimes(zones, seconds), Distribution: NewIntensityDistribution(seconds),
+	}
+}
+
+// CalculateZoneTrends rolls the time in zones up per period, from the first to
+// the last workout with a heart rate
+func CalculateZoneTrends(workouts []models.Workout, calendar Calendar, period Period, model string, heartRate HeartRateProfile) models.ZoneTrends {
+	zones := HeartRateZones(model, heartRate)
+	trends := models.ZoneTrends{Model: model, Bucket: string(period), Zones: zones, Periods: []models.ZonePeriod{}}
+
+	totals := make(map[string][]float64)
+	counts := make(map[string]int)
+	var first, last time.Time
+	for _, workout := range workouts {
+		start, err := ParseTime(workout.Start)
+		if err != nil {
+			continue
+		}
+		seconds, source := ZoneSeconds(workout, zones)
+		if source == "" {
+			continue
+		}
+		key := calendar.Key(start, period)
+		if totals[key] == nil {
+			totals[key] = make([]float64, len(zones))
+		}
+		for i, value := range seconds {
+			totals[key][i] += value
+		}
+		counts[key]++
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		if start.After(last) {
+			last = start
+		}
+	}
+	if first.IsZero() {
+		return trends
+	}
+
+	for _, start := range calendar.Range(first, last, period) {
+		key := calendar.Key(start, period)
+		seconds := totals[key]
+		if seconds == nil {
+			seconds = make([]float64, len(zones))
+		}
+		trends.Periods = append(trends.Periods, models.ZonePeriod{
+			Period: key, Workouts: counts[key],
+			Zone1: round(seconds[0]), Zone2: round(seconds[1]), Zone3: round(seconds[2]),
+			Zone4: round(seconds[3]), Zone5: round(seconds[4]),
+			Total: round(Sum(seconds)), Distribution: NewIntensityDistribution(seconds),
+		})
+	}
+	return trends
+}
+
+// CalculateZoneDistribution totals the time in zones of the workouts and
+// classifies the training intensity distribution
+func CalculateZoneDistribution(workouts []models.Workout, model string, heartRate HeartRateProfile) models.ZoneDistribution {
+	zones := HeartRateZones(model, heartRate)
+	distribution := models.ZoneDistribution{Model: model, Zones: zones}
+	totals := make([]float64, len(zones))
+	for _, workout := range workouts {
+		seconds, source := ZoneSeconds(workout, zones)
+		if source == "" {
+			continue
+		}
+		distribution.Workouts++
+		if source == ZoneSamples {
+			distribution.Samples++
+		}
+		for i, value := range seconds {
+			totals[i] += value
+		}
+	}
+	distribution.Time = zoneTimes(zones, totals)
+	distribution.Distribution = NewIntensityDistribution(totals)
+	return distribution
+}
+
+// NewIntensityDistribution groups the seconds in five zones into the low,
+// moderate and high intensity domains and names the pattern they form
+func NewIntensityDistribution(seconds []float64) models.IntensityDistribution {
+	var distribution models.IntensityDistribution
+	total := Sum(seconds)
+	if total == 0 || len(seconds) < 5 {
+		return distribution
+	}
+	low := (seconds[0] + seconds[1]) / total
+	moderate := seconds[2] / total
+	high := (seconds[3] + seconds[4]) / total
+	distribution.Low, distribution.Moderate, distribution.High = round(100*low), round(100*moderate), round(100*high)
+
+	// Treff et al. (2019): log10(low / moderate * high * 100), zero without high
+	// intensity and with the moderate share floored at 1%
+	index := 0.0
+	if high > 0 {
+		index = round(math.Log10(low / math.Max(moderate, 0.01) * high * 100))
+	}
+	distribution.PolarizationIndex = &index
+
+	switch {
+	case high >= low && high >= moderate:
+		distribution.Pattern = "high-intensity"
+	case moderate >= low:
+		distribution.Pattern = "threshold"
+	case high > moderate && index > 2:
+		distribution.Pattern = "polarized"
+	default:
+		distribution.Pattern = "pyramidal"
+	}
+	return distribution
+}
+
+// zoneTimes pairs the seconds in every zone with its share of the total
+func zoneTimes(zones []models.HeartRateZone, seconds []float64) []models.ZoneTime {
+	total := Sum(seconds)
+	times := make([]models.ZoneTime, len(zones))
+	for i, zone := range zones {
+		times[i] = models.ZoneTime{Zone: zone.Zone, Name: zone.Name, Seconds: round(seconds[i])}
+		if total > 0 {
+			times[i].Percent = round(100 * seconds[i] / total)
+		}
+	}
+	return times
+}